@@ -30,11 +30,12 @@ func main() {
 		log.Fatal(err)
 	}
 
-	if _, err := io.Copy(io.Discard, res.Body); err != nil {
+	// Body ends the Result once the body was read.
+	body := httpstat.Body(res, &result)
+	if _, err := io.Copy(io.Discard, body); err != nil {
 		log.Fatal(err)
 	}
-	res.Body.Close()
-	result.End()
+	body.Close()
 
 	fmt.Printf("%+v\n", result)
 }
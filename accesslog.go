@@ -0,0 +1,122 @@
+package httpstat
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// LogFormat selects the lines written by LogWriter.
+type LogFormat int
+
+const (
+	// LogFormatLogfmt writes key=value pairs, like
+	//
+	//	time=2024-01-02T15:04:05Z method=GET url=https://example.com/ status=200 bytes=1256 dns=12ms connect=20ms tls=31ms server=80ms transfer=2ms total=145ms
+	LogFormatLogfmt LogFormat = iota
+
+	// LogFormatJSON writes the Records as JSON.
+	LogFormatJSON
+)
+
+// logPhases are the keys of the phases in logfmt lines.
+var logPhases = [...]string{
+	PhaseDNS:          "dns",
+	PhaseConnect:      "connect",
+	PhaseProxyConnect: "proxy",
+	PhaseTLS:          "tls",
+	PhaseContinueWait: "continue",
+	PhaseServer:       "server",
+	PhaseTransfer:     "transfer",
+}
+
+// LogWriter is a Sink writing a line per Record to W, a client-side access
+// log with the latency breakdown of each request. Phases which did not
+// happen are left out of logfmt lines, and the total of a failed request
+// is measured until it failed. A LogWriter is safe for concurrent use.
+type LogWriter struct {
+	W io.Writer
+
+	// Format is the format of the lines.
+	Format LogFormat
+
+	// Template, if not nil, is executed with each Record instead, followed
+	// by a newline, e.g. for the format of an existing log pipeline.
+	Template *template.Template
+
+	mu sync.Mutex
+}
+
+// WriteRecord implements Sink.
+func (l *LogWriter) WriteRecord(rec Record) error {
+	var buf bytes.Buffer
+	switch {
+	case l.Template != nil:
+		if err := l.Template.Execute(&buf, rec); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+	case l.Format == LogFormatJSON:
+		if err := json.NewEncoder(&buf).Encode(rec); err != nil {
+			return err
+		}
+	default:
+		buf.Write(appendLogfmt(nil, rec))
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err := l.W.Write(buf.Bytes())
+	return err
+}
+
+// LogRoundTripper returns an http.RoundTripper sending the requests
+// through base, which writes the Record of each request to sink once it
+// completed, e.g. a LogWriter or SlogSink.
+func LogRoundTripper(base http.RoundTripper, sink Sink) http.RoundTripper {
+	return &Transport{Base: base, Sink: sink}
+}
+
+func appendLogfmt(dst []byte, rec Record) []byte {
+	r := rec.Result
+	if r == nil {
+		r = &Result{}
+	}
+	dst = append(dst, "time="...)
+	dst = rec.Time.UTC().AppendFormat(dst, time.RFC3339Nano)
+	dst = appendLogValue(append(dst, " method="...), rec.Method)
+	dst = appendLogValue(append(dst, " url="...), rec.URL)
+	if rec.StatusCode != 0 {
+		dst = strconv.AppendInt(append(dst, " status="...), int64(rec.StatusCode), 10)
+	}
+	dst = strconv.AppendInt(append(dst, " bytes="...), r.BodyLength, 10)
+	for _, pt := range r.Phases() {
+		if pt.Skipped {
+			continue
+		}
+		dst = append(append(append(dst, ' '), logPhases[pt.Phase]...), '=')
+		dst = append(dst, pt.Duration.String()...)
+	}
+	if total := r.elapsed(); total > 0 {
+		dst = append(append(dst, " total="...), total.String()...)
+	}
+	if rec.Err != nil {
+		dst = appendLogValue(append(dst, " error="...), rec.Err.Error())
+	}
+	return append(dst, '\n')
+}
+
+// appendLogValue appends s, quoted if it is empty or contains spaces,
+// quotes or equal signs.
+func appendLogValue(dst []byte, s string) []byte {
+	if s == "" || strings.ContainsAny(s, " \t\r\n\"=") {
+		return strconv.AppendQuote(dst, s)
+	}
+	return append(dst, s...)
+}
@@ -0,0 +1,139 @@
+package httpstat
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+)
+
+func TestLogWriter(t *testing.T) {
+	r := NewResultFromPhases(map[Phase]time.Duration{
+		PhaseDNS:      12 * time.Millisecond,
+		PhaseConnect:  20 * time.Millisecond,
+		PhaseServer:   80 * time.Millisecond,
+		PhaseTransfer: 2 * time.Millisecond,
+	})
+	r.BodyLength = 1256
+	rec := Record{
+		Time:       time.Date(2024, 1, 2, 15, 4, 5, 0, time.UTC),
+		Method:     "GET",
+		URL:        "https://example.com/?q=a b",
+		StatusCode: 200,
+		Result:     r,
+	}
+
+	var buf bytes.Buffer
+	l := &LogWriter{W: &buf}
+	if err := l.WriteRecord(rec); err != nil {
+		t.Fatal("WriteRecord failed:", err)
+	}
+	want := `time=2024-01-02T15:04:05Z method=GET url="https://example.com/?q=a b" status=200 bytes=1256 dns=12ms connect=20ms server=80ms transfer=2ms total=114ms` + "\n"
+	if got := buf.String(); got != want {
+		t.Fatalf("got line\n%s\nwant\n%s", got, want)
+	}
+
+	buf.Reset()
+	l.Format = LogFormatJSON
+	rec.Err = errors.New("boom")
+	if err := l.WriteRecord(rec); err != nil {
+		t.Fatal("WriteRecord failed:", err)
+	}
+	var j struct {
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &j); err != nil || j.Error != "boom" || strings.Count(buf.String(), "\n") != 1 {
+		t.Fatalf("unexpected JSON line %q: %v", buf.String(), err)
+	}
+
+	buf.Reset()
+	l.Template = template.Must(template.New("").Parse("{{.Method}} {{.StatusCode}} {{.Result.Total}}"))
+	if err := l.WriteRecord(rec); err != nil {
+		t.Fatal("WriteRecord failed:", err)
+	}
+	if got := buf.String(); got != "GET 200 114ms\n" {
+		t.Fatalf("got templated line %q", got)
+	}
+}
+
+func TestLogRoundTripper(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "hello")
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	client := &http.Client{Transport: LogRoundTripper(DefaultTransport(), &LogWriter{W: &buf})}
+	res, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatal("client.Get failed:", err)
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+
+	line := regexp.MustCompile(`^time=\S+ method=GET url=` + regexp.QuoteMeta(ts.URL) + ` status=200 bytes=5 connect=\S+ server=\S+ transfer=\S+ total=\S+\n$`)
+	if !line.MatchString(buf.String()) {
+		t.Fatalf("unexpected line %q", buf.String())
+	}
+}
+
+// failedRecords returns the Records of a request refused by the server and
+// of a request failing before any phase started, once some time passed
+// after they failed.
+func failedRecords(t *testing.T) []Record {
+	t.Helper()
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	rc := NewRecorder(2)
+	client := &http.Client{Transport: &Transport{Base: DefaultTransport(), Recorder: rc}}
+	for _, url := range []string{closed.URL, "ftp://127.0.0.1:1/"} {
+		if res, err := client.Get(url); err == nil {
+			res.Body.Close()
+			t.Fatalf("expect request to %s to fail", url)
+		}
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	recs := rc.Records()
+	if len(recs) != 2 {
+		t.Fatalf("got %d Records, want 2", len(recs))
+	}
+	return recs
+}
+
+func TestLogWriter_Failed(t *testing.T) {
+	recs := failedRecords(t)
+
+	var buf bytes.Buffer
+	l := &LogWriter{W: &buf}
+	for _, rec := range recs {
+		if err := l.WriteRecord(rec); err != nil {
+			t.Fatal("WriteRecord failed:", err)
+		}
+	}
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2: %q", len(lines), buf.String())
+	}
+
+	// The refused request is measured until it failed, not until the line
+	// was written.
+	m := regexp.MustCompile(` total=(\S+) error=`).FindStringSubmatch(lines[0])
+	if m == nil {
+		t.Fatalf("expect the total of the refused request in %q", lines[0])
+	}
+	if d, err := time.ParseDuration(m[1]); err != nil || d <= 0 || d >= 100*time.Millisecond {
+		t.Fatalf("total = %s, want the time until the request failed", m[1])
+	}
+	if strings.Contains(lines[1], "total=") {
+		t.Fatalf("expect no total for a request failing before it started, got %q", lines[1])
+	}
+}
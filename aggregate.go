@@ -0,0 +1,163 @@
+package httpstat
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Stats summarizes the durations a phase took over many requests.
+type Stats struct {
+	Count int           `json:"count"`
+	Min   time.Duration `json:"min"`
+	Max   time.Duration `json:"max"`
+	Mean  time.Duration `json:"mean"`
+	P50   time.Duration `json:"p50"`
+	P90   time.Duration `json:"p90"`
+	P95   time.Duration `json:"p95"`
+	P99   time.Duration `json:"p99"`
+}
+
+// newStats summarizes durations, which are sorted in place.
+func newStats(durations []time.Duration) Stats {
+	if len(durations) == 0 {
+		return Stats{}
+	}
+	sort.Slice(durations, func(i, j int) bool {
+		return durations[i] < durations[j]
+	})
+
+	var sum time.Duration
+	for _, d := range durations {
+		sum += d
+	}
+	return Stats{
+		Count: len(durations),
+		Min:   durations[0],
+		Max:   durations[len(durations)-1],
+		Mean:  sum / time.Duration(len(durations)),
+		P50:   percentile(durations, 50),
+		P90:   percentile(durations, 90),
+		P95:   percentile(durations, 95),
+		P99:   percentile(durations, 99),
+	}
+}
+
+// percentile returns the nearest-rank percentile p of the sorted durations.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	i := (p*len(sorted)+99)/100 - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
+
+// Aggregator collects the Results of many requests and summarizes the
+// durations of their phases. An Aggregator is safe for concurrent use.
+type Aggregator struct {
+	// Window, if positive, limits the summary of each phase to its last
+	// Window durations. As skipped phases are not counted, these may span
+	// more than the last Window Results added. Count and the histograms
+	// written by WriteOpenMetrics are not limited.
+	Window int
+
+	mu     sync.Mutex
+	count  int
+	phases [len(phaseNames)][]time.Duration
+	total  []time.Duration
+	custom map[string][]time.Duration
+
+	// hists holds the histograms of the phases and, last, of the total
+	// duration over all Results added, which are not limited by Window.
+	hists [len(phaseNames) + 1]histogram
+}
+
+// Add adds the durations of r, including its custom phases by name. Phases
+// which were skipped by the request (e.g. the DNS lookup of a reused
+// connection) are not counted in the Stats of the phase.
+func (a *Aggregator) Add(r *Result) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.count++
+	for p := range a.phases {
+		if d := r.Duration(Phase(p)); d > 0 {
+			a.phases[p] = a.appendWindow(a.phases[p], d)
+			a.hists[p].add(OpenMetricsBuckets, d)
+		}
+	}
+	total := r.Total()
+	a.total = a.appendWindow(a.total, total)
+	a.hists[len(phaseNames)].add(OpenMetricsBuckets, total)
+	for _, p := range r.customPhases {
+		a.addCustom(p.Name, p.Duration)
+	}
+}
+
+// addCustom adds durations to the custom phase name. It must be called
+// with a locked.
+func (a *Aggregator) addCustom(name string, d ...time.Duration) {
+	if a.custom == nil {
+		a.custom = make(map[string][]time.Duration)
+	}
+	a.custom[name] = a.appendWindow(a.custom[name], d...)
+}
+
+// appendWindow appends d to durations, dropping the oldest durations
+// beyond the window.
+func (a *Aggregator) appendWindow(durations []time.Duration, d ...time.Duration) []time.Duration {
+	durations = append(durations, d...)
+	if a.Window > 0 && len(durations) > a.Window {
+		n := copy(durations, durations[len(durations)-a.Window:])
+		durations = durations[:n]
+	}
+	return durations
+}
+
+// Count returns the number of Results added.
+func (a *Aggregator) Count() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.count
+}
+
+// Phase returns the Stats of phase p.
+func (a *Aggregator) Phase(p Phase) Stats {
+	if p < 0 || int(p) >= len(a.phases) {
+		return Stats{}
+	}
+	a.mu.Lock()
+	durations := append([]time.Duration(nil), a.phases[p]...)
+	a.mu.Unlock()
+	return newStats(durations)
+}
+
+// CustomPhase returns the Stats of the custom phase name, over all custom
+// phases of that name of the Results added.
+func (a *Aggregator) CustomPhase(name string) Stats {
+	a.mu.Lock()
+	durations := append([]time.Duration(nil), a.custom[name]...)
+	a.mu.Unlock()
+	return newStats(durations)
+}
+
+// CustomPhaseNames returns the sorted names of the custom phases of the
+// Results added.
+func (a *Aggregator) CustomPhaseNames() []string {
+	a.mu.Lock()
+	names := make([]string, 0, len(a.custom))
+	for name := range a.custom {
+		names = append(names, name)
+	}
+	a.mu.Unlock()
+	sort.Strings(names)
+	return names
+}
+
+// Total returns the Stats of the total duration of the requests.
+func (a *Aggregator) Total() Stats {
+	a.mu.Lock()
+	durations := append([]time.Duration(nil), a.total...)
+	a.mu.Unlock()
+	return newStats(durations)
+}
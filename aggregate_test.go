@@ -0,0 +1,87 @@
+package httpstat
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAggregator(t *testing.T) {
+	var a Aggregator
+	for i := 1; i <= 100; i++ {
+		a.Add(NewResultFromPhases(map[Phase]time.Duration{
+			PhaseDNS:    time.Duration(i) * time.Millisecond,
+			PhaseServer: 10 * time.Millisecond,
+		}))
+	}
+
+	if a.Count() != 100 {
+		t.Fatalf("Count = %d, want 100", a.Count())
+	}
+
+	dns := a.Phase(PhaseDNS)
+	want := Stats{
+		Count: 100,
+		Min:   1 * time.Millisecond,
+		Max:   100 * time.Millisecond,
+		Mean:  50500 * time.Microsecond,
+		P50:   50 * time.Millisecond,
+		P90:   90 * time.Millisecond,
+		P95:   95 * time.Millisecond,
+		P99:   99 * time.Millisecond,
+	}
+	if dns != want {
+		t.Fatalf("DNS stats = %+v, want %+v", dns, want)
+	}
+
+	if s := a.Phase(PhaseTLS); s.Count != 0 {
+		t.Fatalf("expect skipped phase not to be counted, got %+v", s)
+	}
+	if s := a.Total(); s.Count != 100 || s.Min != 11*time.Millisecond {
+		t.Fatalf("unexpected total stats %+v", s)
+	}
+}
+
+func TestAggregator_Window(t *testing.T) {
+	a := Aggregator{Window: 10}
+	for i := 1; i <= 100; i++ {
+		a.Add(NewResultFromPhases(map[Phase]time.Duration{
+			PhaseDNS: time.Duration(i) * time.Millisecond,
+		}))
+	}
+
+	s := a.Phase(PhaseDNS)
+	if s.Count != 10 || s.Min != 91*time.Millisecond || s.Max != 100*time.Millisecond {
+		t.Fatalf("expect stats of the last 10 results, got %+v", s)
+	}
+	if a.Count() != 100 {
+		t.Fatalf("Count = %d, want 100", a.Count())
+	}
+}
+
+func TestAggregator_CustomPhases(t *testing.T) {
+	a := Aggregator{Window: 10}
+	for i := 1; i <= 20; i++ {
+		r := NewResultFromPhases(map[Phase]time.Duration{PhaseServer: 10 * time.Millisecond})
+		r.customPhases = []CustomPhase{
+			{Name: "sign", Duration: time.Duration(i) * time.Millisecond},
+			{Name: "auth", Duration: 5 * time.Millisecond},
+		}
+		a.Add(r)
+	}
+
+	if got := a.CustomPhaseNames(); len(got) != 2 || got[0] != "auth" || got[1] != "sign" {
+		t.Fatalf("CustomPhaseNames = %v, want [auth sign]", got)
+	}
+	if s := a.CustomPhase("sign"); s.Count != 10 || s.Min != 11*time.Millisecond || s.Max != 20*time.Millisecond {
+		t.Fatalf("expect the stats of the last 10 sign phases, got %+v", s)
+	}
+	if s := a.CustomPhase("unknown"); s.Count != 0 {
+		t.Fatalf("expect no stats of an unknown phase, got %+v", s)
+	}
+
+	var b Aggregator
+	b.MergeSnapshot(a.Snapshot())
+	if s := b.CustomPhase("auth"); s.Count != 10 || s.P50 != 5*time.Millisecond {
+		t.Fatalf("expect the merged auth phases, got %+v", s)
+	}
+}
@@ -0,0 +1,158 @@
+package httpstat
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Anomaly is a phase of a request which took significantly longer or
+// shorter than the same phase of recent requests.
+type Anomaly struct {
+	Phase    Phase
+	Duration time.Duration
+
+	// Mean and StdDev describe the recent durations of the phase.
+	Mean   time.Duration
+	StdDev time.Duration
+
+	// Score is the number of standard deviations Duration is away from
+	// Mean. It is negative if the phase was faster than usual.
+	Score float64
+}
+
+// AnomalyDetector is an Observer which keeps an exponentially weighted
+// moving mean and variance of the DNS, connect, TLS, server processing and
+// content transfer phases, and reports phases deviating from them. Register
+// it as Transport.Observer to watch a stream of requests. An
+// AnomalyDetector is safe for concurrent use.
+type AnomalyDetector struct {
+	// Threshold is the absolute Score from which a phase is reported as
+	// Anomaly. If zero, 3 is used.
+	Threshold float64
+
+	// Alpha is the weight of each new duration in the moving averages,
+	// between 0 and 1. If zero, 0.1 is used.
+	Alpha float64
+
+	// MinSamples is the number of durations of a phase which are needed
+	// before anomalies are reported. If zero, 10 is used.
+	MinSamples int
+
+	// OnAnomaly is called with each detected Anomaly and the Result of its
+	// request.
+	OnAnomaly func(r *Result, a Anomaly)
+
+	// Next, if not nil, is notified after the detector, so
+	// AnomalyDetector can be chained with another Observer.
+	Next Observer
+
+	mu     sync.Mutex
+	phases [len(phaseNames)]ewma
+}
+
+// ewma is an exponentially weighted moving mean and variance.
+type ewma struct {
+	n        int
+	mean     float64
+	variance float64
+}
+
+func (e *ewma) add(x, alpha float64) {
+	e.n++
+	if e.n == 1 {
+		e.mean = x
+		return
+	}
+	diff := x - e.mean
+	e.mean += alpha * diff
+	e.variance = (1 - alpha) * (e.variance + alpha*diff*diff)
+}
+
+// observe checks the duration of phase p of r against the recent durations
+// and then adds it to them.
+func (d *AnomalyDetector) observe(r *Result, p Phase) {
+	dur := r.Duration(p)
+	if dur <= 0 {
+		return
+	}
+
+	threshold, alpha, minSamples := d.Threshold, d.Alpha, d.MinSamples
+	if threshold <= 0 {
+		threshold = 3
+	}
+	if alpha <= 0 || alpha > 1 {
+		alpha = 0.1
+	}
+	if minSamples <= 0 {
+		minSamples = 10
+	}
+
+	d.mu.Lock()
+	e := &d.phases[p]
+	var a *Anomaly
+	if std := math.Sqrt(e.variance); e.n >= minSamples && std > 0 {
+		if score := (float64(dur) - e.mean) / std; math.Abs(score) >= threshold {
+			a = &Anomaly{
+				Phase:    p,
+				Duration: dur,
+				Mean:     time.Duration(e.mean),
+				StdDev:   time.Duration(std),
+				Score:    score,
+			}
+		}
+	}
+	e.add(float64(dur), alpha)
+	d.mu.Unlock()
+
+	if a != nil && d.OnAnomaly != nil {
+		d.OnAnomaly(r, *a)
+	}
+}
+
+// OnDNSDone implements Observer.
+func (d *AnomalyDetector) OnDNSDone(r *Result) {
+	d.observe(r, PhaseDNS)
+	if d.Next != nil {
+		d.Next.OnDNSDone(r)
+	}
+}
+
+// OnConnectDone implements Observer.
+func (d *AnomalyDetector) OnConnectDone(r *Result) {
+	d.observe(r, PhaseConnect)
+	if d.Next != nil {
+		d.Next.OnConnectDone(r)
+	}
+}
+
+// OnTLSDone implements Observer.
+func (d *AnomalyDetector) OnTLSDone(r *Result) {
+	d.observe(r, PhaseTLS)
+	if d.Next != nil {
+		d.Next.OnTLSDone(r)
+	}
+}
+
+// OnFirstByte implements Observer.
+func (d *AnomalyDetector) OnFirstByte(r *Result) {
+	d.observe(r, PhaseServer)
+	if d.Next != nil {
+		d.Next.OnFirstByte(r)
+	}
+}
+
+// OnComplete implements Observer.
+func (d *AnomalyDetector) OnComplete(r *Result) {
+	d.observe(r, PhaseTransfer)
+	if d.Next != nil {
+		d.Next.OnComplete(r)
+	}
+}
+
+// OnProgress implements ProgressObserver, forwarding the reports to Next.
+func (d *AnomalyDetector) OnProgress(r *Result, p Progress) {
+	if o, ok := d.Next.(ProgressObserver); ok {
+		o.OnProgress(r, p)
+	}
+}
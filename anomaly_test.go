@@ -0,0 +1,64 @@
+package httpstat
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAnomalyDetector(t *testing.T) {
+	var anomalies []Anomaly
+	var completed int
+	d := &AnomalyDetector{
+		OnAnomaly: func(_ *Result, a Anomaly) {
+			anomalies = append(anomalies, a)
+		},
+		Next: ObserverFuncs{Complete: func(*Result) { completed++ }},
+	}
+
+	feed := func(dns time.Duration) {
+		r := NewResultFromPhases(map[Phase]time.Duration{
+			PhaseDNS:    dns,
+			PhaseServer: 100 * time.Millisecond,
+		})
+		d.OnDNSDone(r)
+		d.OnFirstByte(r)
+		d.OnComplete(r)
+	}
+
+	// Jitter around 20ms builds up the history.
+	for i := 0; i < 50; i++ {
+		feed(time.Duration(18+i%5) * time.Millisecond)
+	}
+	if len(anomalies) != 0 {
+		t.Fatalf("expect no anomalies in regular traffic, got %+v", anomalies)
+	}
+
+	feed(200 * time.Millisecond)
+	if len(anomalies) != 1 {
+		t.Fatalf("expect 1 anomaly, got %+v", anomalies)
+	}
+	a := anomalies[0]
+	if a.Phase != PhaseDNS || a.Duration != 200*time.Millisecond || a.Score < 3 {
+		t.Fatalf("unexpected anomaly %+v", a)
+	}
+	if a.Mean < 18*time.Millisecond || a.Mean > 22*time.Millisecond {
+		t.Fatalf("expect mean around 20ms, got %v", a.Mean)
+	}
+	if completed != 51 {
+		t.Fatalf("expect Next to be notified, got %d completions", completed)
+	}
+}
+
+func TestAnomalyDetector_MinSamples(t *testing.T) {
+	var anomalies int
+	d := &AnomalyDetector{
+		MinSamples: 5,
+		OnAnomaly:  func(*Result, Anomaly) { anomalies++ },
+	}
+	for _, ms := range []int{10, 11, 10, 500} {
+		d.OnDNSDone(NewResultFromPhases(map[Phase]time.Duration{PhaseDNS: time.Duration(ms) * time.Millisecond}))
+	}
+	if anomalies != 0 {
+		t.Fatalf("expect no anomalies before MinSamples, got %d", anomalies)
+	}
+}
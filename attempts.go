@@ -0,0 +1,143 @@
+package httpstat
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// AttemptSet links the Results of the attempts of one request sent with
+// retries, so a retry does not overwrite the timings of the attempts
+// before it. Requests whose context carries an AttemptSet, see
+// WithAttempts, add an Attempt for each time they are sent through a
+// Transport. This works with retrying clients which reuse the context of
+// the request for each attempt, such as hashicorp/go-retryablehttp:
+//
+//	client := retryablehttp.NewClient()
+//	client.HTTPClient.Transport = &httpstat.Transport{Base: client.HTTPClient.Transport}
+//	var attempts httpstat.AttemptSet
+//	req, _ := retryablehttp.NewRequestWithContext(httpstat.WithAttempts(ctx, &attempts), "GET", url, nil)
+//
+// Hand-written retry loops can use Start and Finish instead.
+//
+// An AttemptSet is safe for concurrent use.
+type AttemptSet struct {
+	mu       sync.Mutex
+	attempts []Attempt
+}
+
+// Attempt is one attempt of a request.
+type Attempt struct {
+	// Number is the number of the attempt, starting with 1. It is also set
+	// as the label "attempt" of the Result.
+	Number int
+
+	// Start is the time the attempt started.
+	Start time.Time
+
+	// Result measures the attempt.
+	Result *Result
+
+	// StatusCode is the status code of the response, or 0 if the attempt
+	// failed or did not finish yet.
+	StatusCode int
+
+	// Err is the error of a failed attempt.
+	Err error
+
+	// Done is set once the attempt finished.
+	Done bool
+}
+
+type attemptsKey struct{}
+
+// WithAttempts returns a copy of ctx carrying s, so the attempts of the
+// requests sent with it are added to s.
+func WithAttempts(ctx context.Context, s *AttemptSet) context.Context {
+	return context.WithValue(ctx, attemptsKey{}, s)
+}
+
+// AttemptsFromContext returns the AttemptSet of ctx set with WithAttempts.
+func AttemptsFromContext(ctx context.Context) (*AttemptSet, bool) {
+	s, ok := ctx.Value(attemptsKey{}).(*AttemptSet)
+	return s, ok
+}
+
+// Start starts a new attempt measured by a fresh Result, and returns the
+// context to send it with and the Result. The Result must be passed to
+// Finish once the attempt finished.
+func (s *AttemptSet) Start(ctx context.Context) (context.Context, *Result) {
+	r := &Result{}
+	s.add(r, time.Now())
+	return WithHTTPStat(ctx, r), r
+}
+
+// Finish finishes the attempt measured by r with the status code of its
+// response, or the error it failed with. If err is not nil, r records the
+// failure like the Result of a Transport does.
+func (s *AttemptSet) Finish(r *Result, statusCode int, err error) {
+	if err != nil {
+		r.abort()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.attempts {
+		if a := &s.attempts[i]; a.Result == r {
+			a.StatusCode, a.Err, a.Done = statusCode, err, true
+			return
+		}
+	}
+}
+
+// Attempts returns the attempts so far, in the order they started.
+func (s *AttemptSet) Attempts() []Attempt {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Attempt(nil), s.attempts...)
+}
+
+// Len returns the number of attempts so far.
+func (s *AttemptSet) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.attempts)
+}
+
+// Last returns the latest attempt.
+func (s *AttemptSet) Last() (Attempt, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.attempts) == 0 {
+		return Attempt{}, false
+	}
+	return s.attempts[len(s.attempts)-1], true
+}
+
+// Total returns the time from the start of the first attempt to the end of
+// the latest one, or to when it failed, including the backoff between the
+// attempts.
+func (s *AttemptSet) Total() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.attempts) == 0 {
+		return 0
+	}
+	last := s.attempts[len(s.attempts)-1]
+	end := last.Result.EndAt()
+	if end.IsZero() {
+		end = last.Result.failTime()
+	}
+	if end.IsZero() {
+		end = last.Start
+	}
+	return end.Sub(s.attempts[0].Start)
+}
+
+func (s *AttemptSet) add(r *Result, start time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := len(s.attempts) + 1
+	r.SetLabel("attempt", strconv.Itoa(n))
+	s.attempts = append(s.attempts, Attempt{Number: n, Start: start, Result: r})
+}
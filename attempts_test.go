@@ -0,0 +1,121 @@
+package httpstat
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTransport_Attempts(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	defer ts.Close()
+
+	client := &http.Client{Transport: &Transport{Base: DefaultTransport()}}
+	var attempts AttemptSet
+	ctx := WithAttempts(context.Background(), &attempts)
+
+	// Retry like go-retryablehttp, reusing the context.
+	for {
+		req, _ := http.NewRequestWithContext(ctx, "GET", ts.URL, nil)
+		res, err := client.Do(req)
+		if err != nil {
+			t.Fatal("request failed:", err)
+		}
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+		if res.StatusCode == http.StatusOK {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	got := attempts.Attempts()
+	if len(got) != 3 {
+		t.Fatalf("expect 3 attempts, got %d", len(got))
+	}
+	for i, a := range got {
+		want := http.StatusServiceUnavailable
+		if i == 2 {
+			want = http.StatusOK
+		}
+		if a.Number != i+1 || a.StatusCode != want || !a.Done || a.Result.Total() <= 0 {
+			t.Errorf("unexpected attempt %d: %+v", i, a)
+		}
+		if v, _ := a.Result.Label("attempt"); v != strconv.Itoa(i+1) {
+			t.Errorf("expect the attempt label %d, got %q", i+1, v)
+		}
+	}
+	if got[0].Result == got[1].Result {
+		t.Fatal("expect a Result per attempt")
+	}
+	if attempts.Total() < 20*time.Millisecond {
+		t.Fatalf("expect the total to include the backoff, got %v", attempts.Total())
+	}
+}
+
+func TestAttemptSet_Start(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	var attempts AttemptSet
+	for i := 0; i < 2; i++ {
+		ctx, r := attempts.Start(context.Background())
+		req, _ := http.NewRequestWithContext(ctx, "GET", ts.URL, nil)
+		res, err := DefaultClient().Do(req)
+		if err != nil {
+			t.Fatal("request failed:", err)
+		}
+		res.Body.Close()
+		r.EndNow()
+		attempts.Finish(r, res.StatusCode, nil)
+	}
+
+	last, ok := attempts.Last()
+	if !ok || attempts.Len() != 2 || last.Number != 2 || last.StatusCode != http.StatusOK || last.Result.Total() <= 0 {
+		t.Fatalf("unexpected last attempt %+v", last)
+	}
+}
+
+func TestAttemptSet_TotalLastFailed(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	var attempts AttemptSet
+	for _, url := range []string{ts.URL, closed.URL} {
+		ctx, r := attempts.Start(context.Background())
+		req, _ := http.NewRequestWithContext(ctx, "GET", url, nil)
+		res, err := DefaultClient().Do(req)
+		if err != nil {
+			attempts.Finish(r, 0, err)
+			continue
+		}
+		res.Body.Close()
+		r.EndNow()
+		attempts.Finish(r, res.StatusCode, nil)
+		time.Sleep(20 * time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	// The failed last attempt counts until it failed, not until now.
+	got := attempts.Attempts()
+	if len(got) != 2 || got[1].Err == nil {
+		t.Fatalf("expect the last of 2 attempts to fail, got %+v", got)
+	}
+	if total, min := attempts.Total(), got[1].Start.Sub(got[0].Start); total <= min || total >= min+100*time.Millisecond {
+		t.Fatalf("Total = %v, want the time until the last attempt failed, after %v", total, min)
+	}
+}
@@ -0,0 +1,196 @@
+package httpstat
+
+import (
+	"context"
+	"hash"
+	"io"
+	"net/http"
+	"runtime/pprof"
+	"time"
+)
+
+// BodyOption configures the response body wrapper returned by Body.
+type BodyOption func(*body)
+
+// WithBodyHash makes the body wrapper feed the response body into h and
+// record the resulting digest in Result.BodyDigest once the body has been
+// read to the end or closed.
+func WithBodyHash(h hash.Hash) BodyOption {
+	return func(b *body) {
+		b.hash = h
+	}
+}
+
+// WithBodySampleLimit limits hashing to the first n bytes of the response
+// body. The remaining bytes are still counted in Result.BodyLength.
+func WithBodySampleLimit(n int64) BodyOption {
+	return func(b *body) {
+		b.limit = n
+	}
+}
+
+type body struct {
+	rc     io.ReadCloser
+	res    *http.Response
+	result *Result
+
+	hash  hash.Hash
+	limit int64
+	done  bool
+
+	// eof is the time the end of the body was read
+	eof time.Time
+
+	// progressInterval is the interval of the progress reports of the
+	// transfer started at progressStart, the previous one was made at
+	// lastReport with lastBytes read
+	progressInterval time.Duration
+	progressStart    time.Time
+	lastReport       time.Time
+	lastBytes        int64
+
+	// stall, if not nil, watches the reads for stalls
+	stall *stallWatchdog
+
+	// events, if not nil, finds the events of a streamed body
+	events *eventScanner
+
+	// transferLabels, if not nil, are set as the pprof labels of the
+	// goroutine during each Read, and labels restored after it
+	labels         context.Context
+	transferLabels context.Context
+
+	// task, if not nil, traces the reads as runtime/trace regions
+	task *requestTask
+
+	// onDone is called once the body was read to the end or closed and
+	// the Result was ended.
+	onDone func()
+}
+
+// Body wraps the body of res so that reading it records the body length
+// (and optionally its digest) on r. The Server-Timing metrics of res are
+// recorded on r as well, and after a 100 Continue the arrival of res, which
+// httptrace does not report. The returned io.ReadCloser must be used in place
+// of res.Body. Once it was read to the end or closed, End is called on r,
+// so it must not be called by the caller.
+func Body(res *http.Response, r *Result, opts ...BodyOption) io.ReadCloser {
+	b := newBody(res, r)
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+func newBody(res *http.Response, r *Result) *body {
+	r.gotResponse(time.Now())
+	r.ServerTiming = ParseServerTiming(res.Header)
+	return &body{
+		rc:     res.Body,
+		res:    res,
+		result: r,
+		limit:  -1,
+	}
+}
+
+func (b *body) Read(p []byte) (int, error) {
+	if b.transferLabels != nil {
+		pprof.SetGoroutineLabels(b.transferLabels)
+		defer pprof.SetGoroutineLabels(b.labels)
+	}
+	if b.task != nil {
+		defer b.task.transfer().End()
+	}
+	if b.stall != nil {
+		b.stall.arm()
+	}
+	n, err := b.rc.Read(p)
+	if b.stall != nil {
+		b.stall.disarm()
+	}
+	if n > 0 {
+		if b.hash != nil {
+			sample := p[:n]
+			if b.limit >= 0 {
+				if left := b.limit - b.result.BodyLength; left < int64(n) {
+					sample = p[:max64(left, 0)]
+				}
+			}
+			b.hash.Write(sample)
+		}
+		b.result.BodyLength += int64(n)
+		b.result.lastByte = time.Now()
+		if b.events != nil {
+			for i := b.events.scan(p[:n]); i > 0; i-- {
+				b.result.StreamEvents = append(b.result.StreamEvents, b.result.lastByte)
+			}
+		}
+		b.progress(false)
+	}
+	if err == io.EOF {
+		b.eof = time.Now()
+		b.finish()
+	} else if err != nil {
+		b.result.lock()
+		b.result.fail(PhaseTransfer)
+		b.result.unlock()
+	}
+	return n, err
+}
+
+func (b *body) Close() error {
+	err := b.rc.Close()
+	b.finish()
+	return err
+}
+
+func (b *body) finish() {
+	if b.done {
+		return
+	}
+	b.done = true
+	if b.stall != nil {
+		b.stall.stop()
+	}
+
+	if b.hash != nil {
+		b.result.BodyDigest = b.hash.Sum(nil)
+	}
+
+	// Server-Timing may also be sent as a trailer, which is only
+	// available once the body has been read.
+	if b.res.Trailer != nil {
+		b.result.ServerTiming = append(b.result.ServerTiming, ParseServerTiming(b.res.Trailer)...)
+	}
+	if hasTrailers(b.res.Trailer) && !b.eof.IsZero() {
+		b.result.trailersAt = b.eof
+		if !b.result.lastByte.IsZero() {
+			b.result.TrailerWait = b.eof.Sub(b.result.lastByte)
+		}
+	}
+
+	b.result.EndNow()
+	b.progress(true)
+
+	if b.onDone != nil {
+		b.onDone()
+	}
+}
+
+// hasTrailers reports whether trailer holds any values. The keys of
+// announced trailers are present before they are received.
+func hasTrailers(trailer http.Header) bool {
+	for _, v := range trailer {
+		if len(v) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func max64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
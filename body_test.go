@@ -0,0 +1,88 @@
+package httpstat
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBody(t *testing.T) {
+	content := strings.Repeat("httpstat", 1024)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, content)
+	}))
+	defer ts.Close()
+
+	cases := []struct {
+		opts []BodyOption
+		want []byte
+	}{
+		{nil, nil},
+		{[]BodyOption{WithBodyHash(sha256.New())}, sha(content)},
+		{[]BodyOption{WithBodyHash(sha256.New()), WithBodySampleLimit(100)}, sha(content[:100])},
+	}
+
+	for i, tc := range cases {
+		var result Result
+		req := NewRequest(t, ts.URL, &result)
+		res, err := DefaultClient().Do(req)
+		if err != nil {
+			t.Fatal("client.Do failed:", err)
+		}
+
+		body := Body(res, &result, tc.opts...)
+		if _, err := io.Copy(io.Discard, body); err != nil {
+			t.Fatal("io.Copy failed:", err)
+		}
+		body.Close()
+
+		if got, want := result.BodyLength, int64(len(content)); got != want {
+			t.Fatalf("#%d BodyLength = %d, want %d", i, got, want)
+		}
+		if got := result.BodyDigest; !bytes.Equal(got, tc.want) {
+			t.Fatalf("#%d BodyDigest = %x, want %x", i, got, tc.want)
+		}
+	}
+}
+
+func sha(s string) []byte {
+	sum := sha256.Sum256([]byte(s))
+	return sum[:]
+}
+
+func TestBody_End(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer ts.Close()
+
+	for _, read := range []bool{true, false} {
+		var result Result
+		res, err := DefaultClient().Do(NewRequest(t, ts.URL, &result))
+		if err != nil {
+			t.Fatal("client.Do failed:", err)
+		}
+
+		body := Body(res, &result)
+		if read {
+			io.Copy(io.Discard, body)
+		}
+		if result.IsComplete() != read {
+			t.Fatalf("read %t: IsComplete = %t before Close", read, result.IsComplete())
+		}
+		body.Close()
+
+		end := result.EndAt()
+		if !result.IsComplete() || end.IsZero() {
+			t.Fatalf("read %t: expect Close to end the Result", read)
+		}
+		body.Close()
+		if !result.EndAt().Equal(end) {
+			t.Fatalf("read %t: expect the Result to be ended once", read)
+		}
+	}
+}
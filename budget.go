@@ -0,0 +1,67 @@
+package httpstat
+
+import (
+	"fmt"
+	"time"
+)
+
+// Budget is the maximum duration each phase of a request may take. Zero
+// limits are not checked.
+type Budget struct {
+	DNS     time.Duration
+	Connect time.Duration
+	TLS     time.Duration
+
+	// TTFB limits the time to the first response byte, measured from the
+	// start of the request (Result.StartTransfer).
+	TTFB time.Duration
+
+	Total time.Duration
+}
+
+// Violation is a limit of a Budget which was exceeded.
+type Violation struct {
+	// Name is the name of the exceeded Budget field, e.g. "TTFB".
+	Name string
+
+	// Phase is the phase limited by the Budget field. For the TTFB and
+	// Total limits, which span several phases, it is the longest of them,
+	// which most likely caused the violation.
+	Phase Phase
+
+	Limit  time.Duration
+	Actual time.Duration
+}
+
+func (v Violation) Error() string {
+	return fmt.Sprintf("httpstat: %s took %v, budget is %v", v.Name, v.Actual, v.Limit)
+}
+
+// CheckBudget returns the limits of b which the request exceeded, in the
+// order of the Budget fields. It returns nil if the request is within the
+// budget.
+func (r *Result) CheckBudget(b Budget) []Violation {
+	var violations []Violation
+	check := func(name string, p Phase, limit, actual time.Duration) {
+		if limit > 0 && actual > limit {
+			violations = append(violations, Violation{Name: name, Phase: p, Limit: limit, Actual: actual})
+		}
+	}
+	check("DNS", PhaseDNS, b.DNS, r.DNSLookup)
+	check("Connect", PhaseConnect, b.Connect, r.TCPConnection)
+	check("TLS", PhaseTLS, b.TLS, r.TLSHandshake)
+	check("TTFB", r.longestPhase(PhaseServer), b.TTFB, r.StartTransfer)
+	check("Total", r.longestPhase(PhaseTransfer), b.Total, r.Total())
+	return violations
+}
+
+// longestPhase returns the longest of the phases up to and including last.
+func (r *Result) longestPhase(last Phase) Phase {
+	longest := PhaseDNS
+	for p := PhaseDNS; p <= last; p++ {
+		if r.Duration(p) > r.Duration(longest) {
+			longest = p
+		}
+	}
+	return longest
+}
@@ -0,0 +1,37 @@
+package httpstat
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestResult_CheckBudget(t *testing.T) {
+	r := NewResultFromPhases(map[Phase]time.Duration{
+		PhaseDNS:      120 * time.Millisecond,
+		PhaseConnect:  10 * time.Millisecond,
+		PhaseTLS:      30 * time.Millisecond,
+		PhaseServer:   200 * time.Millisecond,
+		PhaseTransfer: 40 * time.Millisecond,
+	})
+
+	got := r.CheckBudget(Budget{
+		DNS:     100 * time.Millisecond,
+		Connect: 50 * time.Millisecond,
+		TTFB:    300 * time.Millisecond,
+	})
+	want := []Violation{
+		{Name: "DNS", Phase: PhaseDNS, Limit: 100 * time.Millisecond, Actual: 120 * time.Millisecond},
+		{Name: "TTFB", Phase: PhaseServer, Limit: 300 * time.Millisecond, Actual: 360 * time.Millisecond},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("CheckBudget = %v, want %v", got, want)
+	}
+	if msg := got[0].Error(); msg != "httpstat: DNS took 120ms, budget is 100ms" {
+		t.Fatalf("unexpected error message %q", msg)
+	}
+
+	if v := r.CheckBudget(Budget{Total: time.Second}); v != nil {
+		t.Fatalf("expect no violations, got %v", v)
+	}
+}
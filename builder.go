@@ -0,0 +1,87 @@
+package httpstat
+
+import (
+	"time"
+)
+
+// NewResultFromPhases returns a completed Result of a request which spent
+// the given durations in each phase, e.g. to test code consuming Results
+// without sending requests. Missing phases are skipped.
+func NewResultFromPhases(phases map[Phase]time.Duration) *Result {
+	b := NewResultBuilder()
+	for p, d := range phases {
+		b.Phase(p, d)
+	}
+	return b.Build()
+}
+
+// ResultBuilder builds synthetic Results.
+type ResultBuilder struct {
+	start    time.Time
+	phases   map[Phase]time.Duration
+	protocol string
+}
+
+// NewResultBuilder returns a ResultBuilder of a request starting now with
+// all phases skipped.
+func NewResultBuilder() *ResultBuilder {
+	return &ResultBuilder{
+		start:  time.Now(),
+		phases: make(map[Phase]time.Duration),
+	}
+}
+
+// Start sets the time the request started.
+func (b *ResultBuilder) Start(t time.Time) *ResultBuilder {
+	b.start = t
+	return b
+}
+
+// Phase sets the duration of phase p.
+func (b *ResultBuilder) Phase(p Phase, d time.Duration) *ResultBuilder {
+	b.phases[p] = d
+	return b
+}
+
+// Protocol sets the negotiated protocol.
+func (b *ResultBuilder) Protocol(p string) *ResultBuilder {
+	b.protocol = p
+	return b
+}
+
+// Build returns the Result. Its durations, timeline and timestamps are
+// consistent with each other, as if End had been called.
+func (b *ResultBuilder) Build() *Result {
+	p := b.phases
+	r := &Result{
+		DNSLookup:        p[PhaseDNS],
+		TCPConnection:    p[PhaseConnect],
+		ProxyConnect:     p[PhaseProxyConnect],
+		TLSHandshake:     p[PhaseTLS],
+		ContinueWait:     p[PhaseContinueWait],
+		ServerProcessing: p[PhaseServer],
+		contentTransfer:  p[PhaseTransfer],
+
+		Protocol: b.protocol,
+		isTLS:    p[PhaseTLS] > 0,
+	}
+
+	r.NameLookup = r.DNSLookup
+	r.Connect = r.NameLookup + r.TCPConnection
+	r.Pretransfer = r.Connect + r.ProxyConnect + r.TLSHandshake
+	r.StartTransfer = r.Pretransfer + r.ContinueWait + r.ServerProcessing
+	r.total = r.StartTransfer + r.contentTransfer
+
+	r.dnsStart = b.start
+	r.tcpStart = r.dnsStart.Add(r.NameLookup)
+	r.tcpDone = r.dnsStart.Add(r.Connect)
+	r.tlsStart = r.tcpDone.Add(r.ProxyConnect)
+	if r.ContinueWait > 0 {
+		r.continueStart = r.dnsStart.Add(r.Pretransfer)
+	}
+	r.serverStart = r.dnsStart.Add(r.Pretransfer + r.ContinueWait)
+	r.serverDone = r.dnsStart.Add(r.StartTransfer)
+	r.transferStart = r.serverDone
+	r.t5 = r.dnsStart.Add(r.total)
+	return r
+}
@@ -0,0 +1,59 @@
+package httpstat
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewResultFromPhases(t *testing.T) {
+	ms := time.Millisecond
+	r := NewResultFromPhases(map[Phase]time.Duration{
+		PhaseDNS:      10 * ms,
+		PhaseConnect:  20 * ms,
+		PhaseTLS:      30 * ms,
+		PhaseServer:   40 * ms,
+		PhaseTransfer: 50 * ms,
+	})
+
+	cases := []struct {
+		name      string
+		got, want time.Duration
+	}{
+		{"NameLookup", r.NameLookup, 10 * ms},
+		{"Connect", r.Connect, 30 * ms},
+		{"Pretransfer", r.Pretransfer, 60 * ms},
+		{"StartTransfer", r.StartTransfer, 100 * ms},
+		{"ContentTransfer", r.ContentTransfer(), 50 * ms},
+		{"Total", r.Total(), 150 * ms},
+	}
+	for _, tc := range cases {
+		if tc.got != tc.want {
+			t.Fatalf("%s = %v, want %v", tc.name, tc.got, tc.want)
+		}
+	}
+}
+
+func TestResultBuilder(t *testing.T) {
+	t0 := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := NewResultBuilder().
+		Start(t0).
+		Phase(PhaseServer, 40*time.Millisecond).
+		Phase(PhaseTransfer, 10*time.Millisecond).
+		Protocol("h2").
+		Build()
+
+	if r.TLSHandshake != 0 || r.DNSLookup != 0 {
+		t.Fatal("expect missing phases to be skipped")
+	}
+	if got, want := r.Until(t0.Add(time.Second)), time.Second; got != want {
+		t.Fatalf("Until = %v, want %v", got, want)
+	}
+	if got, want := r.Protocol, "h2"; got != want {
+		t.Fatalf("Protocol = %q, want %q", got, want)
+	}
+
+	events := r.Events()
+	if last := events[len(events)-1]; !last.Time.Equal(t0.Add(50 * time.Millisecond)) {
+		t.Fatalf("last event at %v, want %v", last.Time, t0.Add(50*time.Millisecond))
+	}
+}
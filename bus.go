@@ -0,0 +1,317 @@
+package httpstat
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Publisher publishes messages to a message bus, such as NATS or Kafka. A
+// Publisher must be safe for concurrent use.
+type Publisher interface {
+	// Publish publishes value to topic. The key is used for partitioning by
+	// buses which support it and is ignored by the others.
+	Publish(ctx context.Context, topic string, key, value []byte) error
+}
+
+// PublisherFunc is an adapter to use an ordinary function as a Publisher.
+type PublisherFunc func(ctx context.Context, topic string, key, value []byte) error
+
+// Publish calls f(ctx, topic, key, value).
+func (f PublisherFunc) Publish(ctx context.Context, topic string, key, value []byte) error {
+	return f(ctx, topic, key, value)
+}
+
+// BusSink is a Sink which publishes each Record as an event to a message
+// bus, for the analysis of the client latencies of many services in one
+// place.
+type BusSink struct {
+	// Publisher publishes the events.
+	Publisher Publisher
+
+	// Topic is the topic (or NATS subject) the events are published to. If
+	// empty, "httpstat.results" is used.
+	Topic string
+
+	// Key, if not nil, returns the key of the event of rec. If nil, the
+	// host of the request URL is used, so the events of a host stay in
+	// order.
+	Key func(rec Record) []byte
+
+	// Marshal encodes the events. If nil, the Records are encoded as JSON.
+	Marshal func(rec Record) ([]byte, error)
+
+	// Timeout is the timeout of each Publish. If zero, 5 seconds is used.
+	Timeout time.Duration
+}
+
+// WriteRecord implements Sink.
+func (s *BusSink) WriteRecord(rec Record) error {
+	marshal := s.Marshal
+	if marshal == nil {
+		marshal = func(rec Record) ([]byte, error) { return json.Marshal(rec) }
+	}
+	value, err := marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	var key []byte
+	if s.Key != nil {
+		key = s.Key(rec)
+	} else if u, err := url.Parse(rec.URL); err == nil {
+		key = []byte(u.Host)
+	}
+	topic := s.Topic
+	if topic == "" {
+		topic = "httpstat.results"
+	}
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return s.Publisher.Publish(ctx, topic, key, value)
+}
+
+// NATSPublisher is a Publisher speaking the core NATS protocol to a NATS
+// server. The key of the messages is not used. The connection is opened on
+// the first Publish and opened again after errors.
+type NATSPublisher struct {
+	// Addr is the address of the NATS server, e.g. "localhost:4222".
+	Addr string
+
+	// Name is the name of the connection shown by the server. If empty,
+	// "httpstat" is used.
+	Name string
+
+	// User and Password, or Token, authenticate the connection if set.
+	User, Password, Token string
+
+	mu   sync.Mutex
+	conn net.Conn
+	w    *bufio.Writer
+	err  error
+}
+
+// Publish implements Publisher.
+func (p *NATSPublisher) Publish(ctx context.Context, topic string, key, value []byte) error {
+	if topic == "" || strings.ContainsAny(topic, " \t\r\n") {
+		return fmt.Errorf("httpstat: invalid NATS subject %q", topic)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err != nil {
+		p.closeLocked()
+	}
+	if p.conn == nil {
+		if err := p.connect(ctx); err != nil {
+			return err
+		}
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		p.conn.SetWriteDeadline(deadline)
+	} else {
+		p.conn.SetWriteDeadline(time.Time{})
+	}
+
+	p.w.WriteString("PUB ")
+	p.w.WriteString(topic)
+	p.w.WriteByte(' ')
+	p.w.WriteString(strconv.Itoa(len(value)))
+	p.w.WriteString("\r\n")
+	p.w.Write(value)
+	p.w.WriteString("\r\n")
+	if err := p.w.Flush(); err != nil {
+		p.closeLocked()
+		return err
+	}
+	return nil
+}
+
+// Close closes the connection.
+func (p *NATSPublisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.closeLocked()
+}
+
+func (p *NATSPublisher) closeLocked() error {
+	if p.conn == nil {
+		return nil
+	}
+	err := p.conn.Close()
+	p.conn, p.w, p.err = nil, nil, nil
+	return err
+}
+
+// connect opens the connection and sends the CONNECT message. It must be
+// called with p.mu held.
+func (p *NATSPublisher) connect(ctx context.Context) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", p.Addr)
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	// The server greets with an INFO message.
+	r := bufio.NewReader(conn)
+	line, err := r.ReadString('\n')
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return fmt.Errorf("httpstat: unexpected NATS greeting %q", strings.TrimSpace(line))
+	}
+
+	name := p.Name
+	if name == "" {
+		name = "httpstat"
+	}
+	opts, err := json.Marshal(struct {
+		Verbose  bool   `json:"verbose"`
+		Pedantic bool   `json:"pedantic"`
+		Name     string `json:"name"`
+		Lang     string `json:"lang"`
+		User     string `json:"user,omitempty"`
+		Password string `json:"pass,omitempty"`
+		Token    string `json:"auth_token,omitempty"`
+	}{Name: name, Lang: "go", User: p.User, Password: p.Password, Token: p.Token})
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	w := bufio.NewWriter(conn)
+	w.WriteString("CONNECT ")
+	w.Write(opts)
+	w.WriteString("\r\n")
+	if err := w.Flush(); err != nil {
+		conn.Close()
+		return err
+	}
+	conn.SetDeadline(time.Time{})
+
+	p.conn, p.w = conn, w
+	go p.read(conn, r)
+	return nil
+}
+
+// read answers the PINGs of the server and records the errors it reports,
+// until conn is closed.
+func (p *NATSPublisher) read(conn net.Conn, r *bufio.Reader) {
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			p.fail(conn, err)
+			return
+		}
+		switch {
+		case strings.HasPrefix(line, "PING"):
+			p.mu.Lock()
+			if p.conn == conn {
+				p.w.WriteString("PONG\r\n")
+				p.w.Flush()
+			}
+			p.mu.Unlock()
+		case strings.HasPrefix(line, "-ERR"):
+			p.fail(conn, fmt.Errorf("httpstat: NATS server error: %s", strings.TrimSpace(line[4:])))
+		}
+	}
+}
+
+// fail marks conn as broken, so it is replaced on the next Publish.
+func (p *NATSPublisher) fail(conn net.Conn, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.conn == conn && p.err == nil {
+		p.err = err
+	}
+}
+
+// KafkaRESTPublisher is a Publisher producing to Kafka through a Kafka REST
+// Proxy (API v2), so no Kafka client is needed.
+type KafkaRESTPublisher struct {
+	// URL is the base URL of the REST Proxy, e.g. "http://localhost:8082".
+	URL string
+
+	// Client is used to send the requests. If nil, http.DefaultClient is
+	// used. It must not publish its own requests to the KafkaRESTPublisher.
+	Client *http.Client
+}
+
+// Publish implements Publisher. The key and value are sent as binary data,
+// so any encoding of the events can be used.
+func (p *KafkaRESTPublisher) Publish(ctx context.Context, topic string, key, value []byte) error {
+	type record struct {
+		Key   *string `json:"key,omitempty"`
+		Value string  `json:"value"`
+	}
+	rec := record{Value: base64.StdEncoding.EncodeToString(value)}
+	if key != nil {
+		k := base64.StdEncoding.EncodeToString(key)
+		rec.Key = &k
+	}
+	body, err := json.Marshal(struct {
+		Records []record `json:"records"`
+	}{[]record{rec}})
+	if err != nil {
+		return err
+	}
+
+	u := strings.TrimSuffix(p.URL, "/") + "/topics/" + url.PathEscape(topic)
+	req, err := http.NewRequestWithContext(ctx, "POST", u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.binary.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+
+	client := p.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	b, _ := io.ReadAll(io.LimitReader(res.Body, 1<<16))
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("httpstat: Kafka REST Proxy responded with %s", res.Status)
+	}
+
+	// Records which could not be produced are reported per offset.
+	var reply struct {
+		Offsets []struct {
+			ErrorCode *int   `json:"error_code"`
+			Error     string `json:"error"`
+		} `json:"offsets"`
+	}
+	if json.Unmarshal(b, &reply) == nil {
+		for _, o := range reply.Offsets {
+			if o.ErrorCode != nil || o.Error != "" {
+				return errors.New("httpstat: Kafka REST Proxy: " + o.Error)
+			}
+		}
+	}
+	return nil
+}
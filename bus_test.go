@@ -0,0 +1,118 @@
+package httpstat
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBusSink(t *testing.T) {
+	var topic, key string
+	var value []byte
+	s := &BusSink{Publisher: PublisherFunc(func(ctx context.Context, tp string, k, v []byte) error {
+		topic, key, value = tp, string(k), v
+		return nil
+	})}
+	if err := s.WriteRecord(Record{Method: "GET", URL: "https://example.com/x", Result: &Result{}}); err != nil {
+		t.Fatal("WriteRecord failed:", err)
+	}
+	if topic != "httpstat.results" || key != "example.com" {
+		t.Fatalf("unexpected topic %q and key %q", topic, key)
+	}
+	var rec map[string]interface{}
+	if err := json.Unmarshal(value, &rec); err != nil || rec["method"] != "GET" {
+		t.Fatalf("expect the Record as JSON, got %s (%v)", value, err)
+	}
+}
+
+func TestNATSPublisher(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Listen failed:", err)
+	}
+	defer ln.Close()
+	lines := make(chan string, 8)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		io.WriteString(conn, "INFO {\"server_id\":\"test\"}\r\nPING\r\n")
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				close(lines)
+				return
+			}
+			lines <- strings.TrimSpace(line)
+		}
+	}()
+
+	p := &NATSPublisher{Addr: ln.Addr().String(), Token: "secret"}
+	if err := p.Publish(context.Background(), "results", nil, []byte("hello")); err != nil {
+		t.Fatal("Publish failed:", err)
+	}
+	if err := p.Publish(context.Background(), "bad subject", nil, nil); err == nil {
+		t.Fatal("expect invalid subject to fail")
+	}
+
+	var got []string
+	for len(got) < 4 {
+		got = append(got, <-lines)
+	}
+	p.Close()
+	if !strings.HasPrefix(got[0], "CONNECT {") || !strings.Contains(got[0], `"auth_token":"secret"`) {
+		t.Fatalf("unexpected CONNECT %q", got[0])
+	}
+	// The PONG may be written before or after the PUB.
+	rest := strings.Join(got[1:], "|")
+	if !strings.Contains(rest, "PUB results 5|hello") || !strings.Contains(rest, "PONG") {
+		t.Fatalf("unexpected messages %q", got[1:])
+	}
+}
+
+func TestKafkaRESTPublisher(t *testing.T) {
+	var path, contentType string
+	var body struct {
+		Records []struct {
+			Key   string `json:"key"`
+			Value string `json:"value"`
+		} `json:"records"`
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, contentType = r.URL.Path, r.Header.Get("Content-Type")
+		json.NewDecoder(r.Body).Decode(&body)
+		io.WriteString(w, `{"offsets":[{"partition":0,"offset":1}]}`)
+	}))
+	defer ts.Close()
+
+	p := &KafkaRESTPublisher{URL: ts.URL}
+	if err := p.Publish(context.Background(), "results", []byte("k"), []byte("v")); err != nil {
+		t.Fatal("Publish failed:", err)
+	}
+	if path != "/topics/results" || contentType != "application/vnd.kafka.binary.v2+json" {
+		t.Fatalf("unexpected request to %s with %s", path, contentType)
+	}
+	if len(body.Records) != 1 || body.Records[0].Key != base64.StdEncoding.EncodeToString([]byte("k")) ||
+		body.Records[0].Value != base64.StdEncoding.EncodeToString([]byte("v")) {
+		t.Fatalf("unexpected records %+v", body.Records)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"offsets":[{"error_code":40403,"error":"Topic not found"}]}`)
+	}))
+	defer failing.Close()
+	p.URL = failing.URL
+	if err := p.Publish(context.Background(), "results", nil, []byte("v")); err == nil || !strings.Contains(err.Error(), "Topic not found") {
+		t.Fatalf("expect the error of the offset, got %v", err)
+	}
+}
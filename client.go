@@ -0,0 +1,98 @@
+package httpstat
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Client sends requests like an http.Client and returns the Result of each
+// one along with the response, for quick scripts which should not have to
+// set up contexts and call End. The zero Client is ready to use.
+//
+// The Result is ended once the response body was read to the end or
+// closed, and must only be read then, or once Do returned an error: until
+// then the transport may still record into it. If redirects are followed,
+// the Result is that of the last request.
+type Client struct {
+	// HTTPClient sends the requests. If nil, http.DefaultClient is used.
+	HTTPClient *http.Client
+}
+
+// Do sends req and returns the response and its Result. If sending req
+// failed, the partial Result is returned with the error.
+func (c *Client) Do(req *http.Request) (*http.Response, *Result, error) {
+	hc := http.DefaultClient
+	if c.HTTPClient != nil {
+		hc = c.HTTPClient
+	}
+	rt := &lastResult{base: hc.Transport}
+	if rt.base == nil {
+		rt.base = http.DefaultTransport
+	}
+	client := *hc
+	client.Transport = rt
+
+	res, err := client.Do(req)
+	r := rt.last()
+	if err != nil {
+		r.abort()
+		return nil, r, err
+	}
+	res.Body = Body(res, r)
+	return res, r, nil
+}
+
+// Get sends a GET request to url, see Do.
+func (c *Client) Get(url string) (*http.Response, *Result, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	return c.Do(req)
+}
+
+// Post sends a POST request with body of the given content type to url,
+// see Do.
+func (c *Client) Post(url, contentType string, body io.Reader) (*http.Response, *Result, error) {
+	req, err := http.NewRequest(http.MethodPost, url, body)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return c.Do(req)
+}
+
+// lastResult is an http.RoundTripper measuring each request with a fresh
+// Result, so the redirects followed by an http.Client are measured apart,
+// and keeping the last one.
+type lastResult struct {
+	base http.RoundTripper
+
+	mu     sync.Mutex
+	result *Result
+}
+
+func (t *lastResult) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := &Result{}
+	t.mu.Lock()
+	t.result = r
+	t.mu.Unlock()
+	res, err := t.base.RoundTrip(req.WithContext(WithHTTPStat(req.Context(), r)))
+	if err == nil {
+		r.gotResponse(time.Now())
+	}
+	return res, err
+}
+
+// last returns the Result of the last request, or an empty one if no
+// request was sent.
+func (t *lastResult) last() *Result {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.result == nil {
+		t.result = &Result{}
+	}
+	return t.result
+}
@@ -0,0 +1,64 @@
+package httpstat
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClient(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, "/slow", http.StatusFound)
+		case "/slow":
+			time.Sleep(20 * time.Millisecond)
+			io.WriteString(w, "ok")
+		case "/echo":
+			io.Copy(w, r.Body)
+		}
+	}))
+	defer ts.Close()
+
+	var c Client
+	res, r, err := c.Get(ts.URL + "/redirect")
+	if err != nil {
+		t.Fatal("Get failed:", err)
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+	if !r.IsComplete() || r.ServerProcessing < 20*time.Millisecond {
+		t.Fatalf("expect the ended Result of the redirected request, got %+v", r)
+	}
+	// The redirected request reuses the connection of the first one.
+	if r.TCPConnection != 0 {
+		t.Fatalf("expect the Result of the last request only, got TCPConnection %v", r.TCPConnection)
+	}
+
+	res, r, err = c.Post(ts.URL+"/echo", "text/plain", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatal("Post failed:", err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if string(body) != "hello" || !r.IsComplete() || r.BodyLength != 5 {
+		t.Fatalf("unexpected response %q and Result %+v", body, r)
+	}
+}
+
+func TestClient_Error(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	ts.Close()
+
+	c := Client{HTTPClient: &http.Client{}}
+	_, r, err := c.Get(ts.URL)
+	if err == nil {
+		t.Fatal("expect the request to fail")
+	}
+	if p, ok := r.FailedPhase(); !ok || p != PhaseConnect {
+		t.Fatalf("FailedPhase = %v, %v, want the TCP connection", p, ok)
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// newClient returns the client sending the requests of cfg. It does not
+// follow redirects, which is left to follow, so each hop gets its own
+// Result.
+func newClient(cfg *config) *http.Client {
+	if cfg.httpVersion == "3" {
+		return newHTTP3Client(cfg)
+	}
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	if cfg.local != nil {
+		dialer.LocalAddr = &net.TCPAddr{IP: cfg.local}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if to, ok := cfg.resolve[addr]; ok {
+			addr = to
+		}
+		return dialer.DialContext(ctx, network, addr)
+	}
+	transport.TLSClientConfig = cfg.tls
+	setProtocol(transport, cfg.httpVersion)
+	return &http.Client{
+		Transport:     transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+}
+
+// resolveFlag is the value of --resolve, mapping host:port to the address
+// to connect to instead, like curl. The URL is left alone, so the Host
+// header and the TLS server name stay those of the host.
+type resolveFlag map[string]string
+
+func (f resolveFlag) String() string {
+	var s []string
+	for from, to := range f {
+		s = append(s, from+"="+to)
+	}
+	return strings.Join(s, ",")
+}
+
+// Set adds an override of the form host:port:addr. addr may be an IPv6
+// address in brackets.
+func (f resolveFlag) Set(v string) error {
+	parts := strings.SplitN(v, ":", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return fmt.Errorf("want host:port:addr, got %q", v)
+	}
+	addr := strings.TrimSuffix(strings.TrimPrefix(parts[2], "["), "]")
+	if net.ParseIP(addr) == nil {
+		return fmt.Errorf("invalid address %q", parts[2])
+	}
+	f[net.JoinHostPort(parts[0], parts[1])] = net.JoinHostPort(addr, parts[1])
+	return nil
+}
+
+// localAddr returns the local address of --interface, which is an IP
+// address or the name of a network interface. Of the addresses of an
+// interface, IPv4 ones are preferred. The dialer only connects to the
+// addresses of the host of the same family.
+func localAddr(s string) (net.IP, error) {
+	if ip := net.ParseIP(s); ip != nil {
+		return ip, nil
+	}
+	ifi, err := net.InterfaceByName(s)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := ifi.Addrs()
+	if err != nil {
+		return nil, err
+	}
+	var local net.IP
+	for _, a := range addrs {
+		ipnet, ok := a.(*net.IPNet)
+		if !ok || ipnet.IP.IsLinkLocalUnicast() {
+			continue
+		}
+		if ipnet.IP.To4() != nil {
+			return ipnet.IP, nil
+		}
+		if local == nil {
+			local = ipnet.IP
+		}
+	}
+	if local == nil {
+		return nil, fmt.Errorf("interface %s has no usable address", s)
+	}
+	return local, nil
+}
+
+// tlsFlags are the TLS options of the command.
+type tlsFlags struct {
+	insecure   bool
+	caCert     string
+	cert, key  string
+	serverName string
+	minVersion string
+}
+
+// tlsVersions are the values of --tls-min.
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// config returns the TLS config of the flags, or nil if none were set.
+func (f *tlsFlags) config() (*tls.Config, error) {
+	if *f == (tlsFlags{}) {
+		return nil, nil
+	}
+	c := &tls.Config{InsecureSkipVerify: f.insecure, ServerName: f.serverName}
+	if f.caCert != "" {
+		pem, err := os.ReadFile(f.caCert)
+		if err != nil {
+			return nil, err
+		}
+		c.RootCAs = x509.NewCertPool()
+		if !c.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates in %s", f.caCert)
+		}
+	}
+	if f.cert != "" || f.key != "" {
+		key := f.key
+		if key == "" {
+			// Like curl, the key may be in the certificate file.
+			key = f.cert
+		}
+		cert, err := tls.LoadX509KeyPair(f.cert, key)
+		if err != nil {
+			return nil, err
+		}
+		c.Certificates = []tls.Certificate{cert}
+	}
+	if f.minVersion != "" {
+		v, ok := tlsVersions[f.minVersion]
+		if !ok {
+			return nil, fmt.Errorf("unknown TLS version %q", f.minVersion)
+		}
+		c.MinVersion = v
+	}
+	return c, nil
+}
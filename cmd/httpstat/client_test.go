@@ -0,0 +1,95 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRun_TLS(t *testing.T) {
+	var serverName string
+	var clientCerts int
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serverName, clientCerts = r.TLS.ServerName, len(r.TLS.PeerCertificates)
+		io.WriteString(w, "ok")
+	}))
+	ts.TLS = &tls.Config{ClientAuth: tls.RequestClientCert, MaxVersion: tls.VersionTLS12}
+	ts.StartTLS()
+	defer ts.Close()
+
+	dir := t.TempDir()
+	caCert := filepath.Join(dir, "ca.pem")
+	writePEM(t, caCert, "CERTIFICATE", ts.Certificate().Raw)
+	cert, key := writeClientCert(t, dir)
+
+	for _, tt := range []struct {
+		args []string
+		code int
+	}{
+		{[]string{}, 1},
+		{[]string{"-k"}, 0},
+		{[]string{"--cacert", caCert}, 0},
+		{[]string{"--cacert", filepath.Join(dir, "missing.pem")}, 2},
+		{[]string{"-k", "--tls-min", "1.3"}, 1},
+		{[]string{"-k", "--tls-min", "2.0"}, 2},
+	} {
+		var stdout, stderr bytes.Buffer
+		if code := run(context.Background(), append(tt.args, ts.URL), &stdout, &stderr); code != tt.code {
+			t.Errorf("%v: exit code %d, want %d, stderr: %s", tt.args, code, tt.code, stderr.String())
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run(context.Background(), []string{"-k", "--sni", "staging.example", "--cert", cert, "--key", key, ts.URL}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d, stderr: %s", code, stderr.String())
+	}
+	if serverName != "staging.example" || clientCerts != 1 {
+		t.Fatalf("server got name %q and %d client certificates, want staging.example and 1", serverName, clientCerts)
+	}
+}
+
+// writeClientCert writes a self-signed client certificate and its key to
+// dir and returns their paths.
+func writeClientCert(t *testing.T, dir string) (cert, key string) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &priv.PublicKey, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, key = filepath.Join(dir, "client.pem"), filepath.Join(dir, "client.key")
+	writePEM(t, cert, "CERTIFICATE", der)
+	writePEM(t, key, "PRIVATE KEY", keyDER)
+	return cert, key
+}
+
+func writePEM(t *testing.T, path, typ string, der []byte) {
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
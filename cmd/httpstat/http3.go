@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+
+	"github.com/jakobilobi/go-httpstat/http3stat"
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+)
+
+// newHTTP3Client is like newClient for --http3. The QUIC connections are
+// dialed by http3stat.Dial, which reports their handshake.
+func newHTTP3Client(cfg *config) *http.Client {
+	transport := &http3.Transport{
+		TLSClientConfig: cfg.tls,
+		Dial: func(ctx context.Context, addr string, tlsCfg *tls.Config, quicCfg *quic.Config) (*quic.Conn, error) {
+			if to, ok := cfg.resolve[addr]; ok {
+				addr = to
+			}
+			return http3stat.Dial(ctx, addr, tlsCfg, quicCfg)
+		},
+	}
+	return &http.Client{
+		Transport:     transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+}
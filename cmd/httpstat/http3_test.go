@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/quic-go/quic-go/http3"
+)
+
+func TestRun_HTTP3(t *testing.T) {
+	// The QUIC server uses the certificate of an httptest server.
+	ts := httptest.NewTLSServer(http.NotFoundHandler())
+	defer ts.Close()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("ListenPacket failed:", err)
+	}
+	srv := &http3.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "ok")
+		}),
+		TLSConfig: http3.ConfigureTLSConfig(&tls.Config{Certificates: ts.TLS.Certificates}),
+	}
+	go srv.Serve(conn)
+	defer srv.Close()
+
+	caCert := filepath.Join(t.TempDir(), "ca.pem")
+	writePEM(t, caCert, "CERTIFICATE", ts.Certificate().Raw)
+
+	var stdout, stderr bytes.Buffer
+	if code := run(context.Background(), []string{"--http3", "--cacert", caCert, "https://" + conn.LocalAddr().String()}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d, stderr: %s", code, stderr.String())
+	}
+	if out := stdout.String(); !strings.HasPrefix(out, "HTTP/3.0 200 OK") || !strings.Contains(out, "TLS handshake") {
+		t.Fatalf("expect an HTTP/3 response with its QUIC handshake, got:\n%s", out)
+	}
+
+	if code := run(context.Background(), []string{"--http3", "--interface", "127.0.0.1", ts.URL}, &stdout, &stderr); code != 2 {
+		t.Fatalf("exit code %d for --interface with --http3, want 2", code)
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jakobilobi/go-httpstat"
+)
+
+// summary is the outcome of load.
+type summary struct {
+	agg      httpstat.Aggregator
+	elapsed  time.Duration
+	ok       int
+	over     int // successful requests exceeding the thresholds
+	errors   map[string]int
+	statuses map[int]int
+}
+
+// load sends cfg.requests requests, cfg.concurrency at once, and
+// aggregates their Results, writing each one to cfg.out if set. Failed requests are counted by the phase they
+// failed in, and responses by their status code.
+func load(ctx context.Context, client *http.Client, cfg *config) *summary {
+	s := &summary{errors: make(map[string]int), statuses: make(map[int]int)}
+	jobs := make(chan struct{})
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	start := time.Now()
+	for i := 0; i < cfg.concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range jobs {
+				rec := measure(ctx, client, cfg)
+				mu.Lock()
+				s.add(rec, cfg.budget)
+				if cfg.out != nil {
+					cfg.out.write(rec)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+loop:
+	for i := 0; i < cfg.requests; i++ {
+		select {
+		case jobs <- struct{}{}:
+		case <-ctx.Done():
+			break loop
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	s.elapsed = time.Since(start)
+	return s
+}
+
+func (s *summary) add(rec result, budget httpstat.Budget) {
+	if rec.Err != nil {
+		kind := "error"
+		if p, ok := rec.Result.FailedPhase(); ok {
+			kind = "failed in " + p.String()
+		}
+		s.errors[kind]++
+		return
+	}
+	s.statuses[rec.StatusCode]++
+	if rec.StatusCode >= 400 {
+		return
+	}
+	s.ok++
+	s.agg.Add(rec.Result)
+	if len(rec.Result.CheckBudget(budget)) > 0 {
+		s.over++
+	}
+}
+
+// WriteTo writes the summary to w: the durations of the phases of the
+// successful requests, the status codes and the errors.
+func (s *summary) WriteTo(w io.Writer) (int64, error) {
+	cw := &countWriter{w: w}
+	requests := 0
+	for _, n := range s.statuses {
+		requests += n
+	}
+	for _, n := range s.errors {
+		requests += n
+	}
+	fmt.Fprintf(cw, "Requests: %d in %v (%.1f/s), %d successful", requests, s.elapsed.Round(time.Millisecond), float64(requests)/s.elapsed.Seconds(), s.ok)
+	if s.over > 0 {
+		fmt.Fprintf(cw, ", %d over the thresholds", s.over)
+	}
+	fmt.Fprint(cw, "\n\n")
+
+	tw := tabwriter.NewWriter(cw, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "Phase\tMin\tMean\tp95\tp99\t")
+	row := func(name string, st httpstat.Stats) {
+		fmt.Fprintf(tw, "%s\t%v\t%v\t%v\t%v\t\n", name, ms(st.Min), ms(st.Mean), ms(st.P95), ms(st.P99))
+	}
+	for p := httpstat.PhaseDNS; p <= httpstat.PhaseTransfer; p++ {
+		if st := s.agg.Phase(p); st.Count > 0 {
+			row(p.String(), st)
+		}
+	}
+	row("Total", s.agg.Total())
+	tw.Flush()
+
+	if len(s.statuses) > 0 {
+		fmt.Fprintln(cw, "\nStatus codes:")
+		codes := make([]int, 0, len(s.statuses))
+		for code := range s.statuses {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		for _, code := range codes {
+			fmt.Fprintf(cw, "  %s  %d\n", strconv.Itoa(code), s.statuses[code])
+		}
+	}
+	if len(s.errors) > 0 {
+		fmt.Fprintln(cw, "\nErrors:")
+		kinds := make([]string, 0, len(s.errors))
+		for kind := range s.errors {
+			kinds = append(kinds, kind)
+		}
+		sort.Strings(kinds)
+		for _, kind := range kinds {
+			fmt.Fprintf(cw, "  %s  %d\n", kind, s.errors[kind])
+		}
+	}
+	return cw.n, cw.err
+}
+
+// ms formats d in milliseconds with microsecond precision.
+func ms(d time.Duration) string {
+	return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64) + " ms"
+}
+
+// countWriter counts the bytes written to w and keeps the first error.
+type countWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
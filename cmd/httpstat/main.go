@@ -0,0 +1,318 @@
+// Command httpstat sends HTTP requests and shows the time spent in each
+// phase of them.
+//
+// Usage:
+//
+//	httpstat [flags] URL
+//
+// With -n, the request is sent several times, -c at once, and a summary of
+// the phase durations and errors is shown instead, which makes httpstat a
+// lightweight load and latency tester:
+//
+//	httpstat -n 100 -c 10 https://example.com
+//
+// Redirects are not followed unless -L is given, which shows the phases of
+// each hop of the redirect chain and their combined total.
+//
+// With -o, each request is written for scripts instead: as a line of JSON
+// with -o json, as CSV with -o csv, or with a text/template, e.g.
+// -o 'template={{.StatusCode}} {{.Result.Total}}'.
+//
+// With --resolve host:port:addr, connections to host:port go to addr, e.g.
+// to measure one backend behind a load balancer, while the Host header and
+// the TLS server name stay those of the URL.
+//
+// With --interface, the connections are bound to a local address or to
+// the address of a network interface, e.g. to compare the uplinks of a
+// multi-homed host.
+//
+// --http1.1, --http2 and --http3 send the requests with that HTTP version
+// only, to compare the latencies of the protocols. With HTTP/3, the QUIC
+// handshake is shown as the TLS handshake.
+//
+// The TLS flags -k, --cacert, --cert and --key, --sni and --tls-min
+// configure the TLS handshake, e.g. to measure staging endpoints with
+// self-signed certificates and endpoints requiring client certificates.
+//
+// -X sets the method, -H adds headers, and -d or --data-binary send a body,
+// read from a file with @file, and default the method to POST. A header
+// "Expect: 100-continue" measures the wait for the server to accept the
+// body.
+//
+// The thresholds --max-dns, --max-connect, --max-tls, --max-ttfb and
+// --max-time make httpstat exit with status 3 if a request exceeded them,
+// for use in scripts and health checks. Failed requests exit with status 1.
+//
+// With -watch, the request is sent again at the interval until httpstat is
+// interrupted, and a refreshing table of the recent phase durations is
+// shown, e.g. to watch the latency while changing DNS or load balancer
+// settings.
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/jakobilobi/go-httpstat"
+)
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// config holds the flags.
+type config struct {
+	url         string
+	requests    int
+	concurrency int
+	timeout     time.Duration
+	watch       time.Duration
+	follow      bool
+	out         output
+	resolve     resolveFlag
+	local       net.IP
+	httpVersion string
+	tls         *tls.Config
+	method      string
+	header      headerFlag
+	body        []byte
+	budget      httpstat.Budget
+}
+
+// errUsage is returned by parseFlags if the usage was printed.
+var errUsage = errors.New("usage")
+
+func parseFlags(args []string, stdout, stderr io.Writer) (*config, error) {
+	cfg := &config{resolve: make(resolveFlag), header: make(headerFlag)}
+	fs := flag.NewFlagSet("httpstat", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: httpstat [flags] URL")
+		fs.PrintDefaults()
+	}
+	fs.IntVar(&cfg.requests, "n", 1, "number of requests to send")
+	fs.IntVar(&cfg.concurrency, "c", 1, "number of requests to send at once")
+	fs.DurationVar(&cfg.timeout, "timeout", 30*time.Second, "timeout of each request")
+	fs.Var(cfg.resolve, "resolve", "connect to `host:port:addr` at addr, can be repeated")
+	iface := fs.String("interface", "", "bind the connections to the local `addr` or the address of the interface")
+	http11 := fs.Bool("http1.1", false, "use HTTP/1.1 only")
+	http2 := fs.Bool("http2", false, "use HTTP/2 only, h2c for http:// URLs")
+	http3 := fs.Bool("http3", false, "use HTTP/3 over QUIC")
+	var tf tlsFlags
+	fs.BoolVar(&tf.insecure, "k", false, "do not verify the certificate of the server")
+	fs.BoolVar(&tf.insecure, "insecure", false, "same as -k")
+	fs.StringVar(&tf.caCert, "cacert", "", "verify the server with the CA certificates in `file`")
+	fs.StringVar(&tf.cert, "cert", "", "client certificate `file` for mutual TLS")
+	fs.StringVar(&tf.key, "key", "", "private key `file` of the client certificate, if not in -cert")
+	fs.StringVar(&tf.serverName, "sni", "", "server `name` sent in the TLS handshake instead of the host")
+	fs.StringVar(&tf.minVersion, "tls-min", "", "minimum TLS `version`: 1.0, 1.1, 1.2 or 1.3")
+	fs.StringVar(&cfg.method, "X", "", "request `method`, GET or POST with a body by default")
+	fs.Var(cfg.header, "H", "add the `header` \"Name: value\", can be repeated")
+	data := fs.String("d", "", "send `data` as body, @file to read it from a file without newlines")
+	dataBinary := fs.String("data-binary", "", "send `data` as body, @file to read it from a file as it is")
+	fs.DurationVar(&cfg.budget.DNS, "max-dns", 0, "exit with status 3 if the DNS lookup takes longer than `duration`")
+	fs.DurationVar(&cfg.budget.Connect, "max-connect", 0, "exit with status 3 if the TCP connection takes longer than `duration`")
+	fs.DurationVar(&cfg.budget.TLS, "max-tls", 0, "exit with status 3 if the TLS handshake takes longer than `duration`")
+	fs.DurationVar(&cfg.budget.TTFB, "max-ttfb", 0, "exit with status 3 if the first response byte takes longer than `duration`")
+	fs.DurationVar(&cfg.budget.Total, "max-time", 0, "exit with status 3 if the request takes longer than `duration`")
+	fs.BoolVar(&cfg.follow, "L", false, "follow redirects and show each hop")
+	format := fs.String("o", "text", "output `format`: text, json, csv or template=TMPL")
+	fs.DurationVar(&cfg.watch, "watch", 0, "send the request again at this `interval` and show the recent durations")
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	if fs.NArg() != 1 || cfg.requests < 1 || cfg.concurrency < 1 || cfg.watch < 0 {
+		fs.Usage()
+		return nil, errUsage
+	}
+	switch {
+	case btoi(*http11)+btoi(*http2)+btoi(*http3) > 1:
+		fmt.Fprintln(stderr, "httpstat: --http1.1, --http2 and --http3 are exclusive")
+		return nil, errUsage
+	case *http11:
+		cfg.httpVersion = "1.1"
+	case *http2:
+		cfg.httpVersion = "2"
+	case *http3:
+		if *iface != "" {
+			fmt.Fprintln(stderr, "httpstat: --interface is not supported with --http3")
+			return nil, errUsage
+		}
+		cfg.httpVersion = "3"
+	}
+	var err error
+	switch {
+	case *dataBinary != "":
+		cfg.body, err = readData(*dataBinary, true)
+	case *data != "":
+		cfg.body, err = readData(*data, false)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "httpstat:", err)
+		return nil, errUsage
+	}
+	if cfg.method == "" {
+		cfg.method = "GET"
+		if cfg.body != nil {
+			cfg.method = "POST"
+		}
+	}
+	tlsConfig, err := tf.config()
+	if err != nil {
+		fmt.Fprintln(stderr, "httpstat:", err)
+		return nil, errUsage
+	}
+	cfg.tls = tlsConfig
+	if *iface != "" {
+		ip, err := localAddr(*iface)
+		if err != nil {
+			fmt.Fprintln(stderr, "httpstat:", err)
+			return nil, errUsage
+		}
+		cfg.local = ip
+	}
+	out, err := parseOutput(*format, stdout)
+	if err != nil {
+		fmt.Fprintln(stderr, "httpstat:", err)
+		return nil, errUsage
+	}
+	cfg.url, cfg.out = fs.Arg(0), out
+	return cfg, nil
+}
+
+func btoi(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// The exit codes of the command.
+const (
+	exitOK        = 0
+	exitFailed    = 1 // a request failed, or all did with -n
+	exitUsage     = 2
+	exitThreshold = 3 // a request exceeded a --max-* threshold
+)
+
+// run runs the command with args and returns its exit code.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	cfg, err := parseFlags(args, stdout, stderr)
+	if err != nil {
+		return exitUsage
+	}
+	client := newClient(cfg)
+
+	if cfg.watch > 0 {
+		watch(ctx, client, cfg, stdout)
+		return exitOK
+	}
+	if cfg.requests > 1 {
+		s := load(ctx, client, cfg)
+		if cfg.out == nil {
+			s.WriteTo(stdout)
+		}
+		switch {
+		case s.ok == 0:
+			return exitFailed
+		case s.over > 0:
+			fmt.Fprintf(stderr, "httpstat: %d requests exceeded the thresholds\n", s.over)
+			return exitThreshold
+		}
+		return exitOK
+	}
+
+	var hops []result
+	if cfg.follow {
+		hops = follow(ctx, client, cfg)
+	} else {
+		hops = append(hops, measure(ctx, client, cfg))
+	}
+	switch {
+	case cfg.out != nil:
+		for _, hop := range hops {
+			if err := cfg.out.write(hop); err != nil {
+				fmt.Fprintln(stderr, "httpstat:", err)
+				return exitFailed
+			}
+		}
+	case cfg.follow:
+		writeHops(stdout, hops)
+	case hops[0].Err == nil:
+		fmt.Fprintf(stdout, "%s %s\n\n%+v", hops[0].Proto, hops[0].Status, hops[0].Result)
+	}
+	if err := hops[len(hops)-1].Err; err != nil {
+		fmt.Fprintln(stderr, "httpstat:", err)
+		return exitFailed
+	}
+	code := exitOK
+	for _, hop := range hops {
+		for _, v := range hop.Result.CheckBudget(cfg.budget) {
+			fmt.Fprintf(stderr, "httpstat: %s %s: %s took %v, threshold is %v\n", hop.Method, hop.URL, v.Name, v.Actual, v.Limit)
+			code = exitThreshold
+		}
+	}
+	return code
+}
+
+// result is the outcome of a request.
+type result struct {
+	httpstat.Record
+	Proto, Status string
+
+	// Location is the absolute URL a redirect points to.
+	Location string
+}
+
+// measure sends the request of cfg and reads the response body.
+func measure(ctx context.Context, client *http.Client, cfg *config) result {
+	return measureURL(ctx, client, cfg, cfg.method, cfg.url, cfg.body)
+}
+
+// measureURL is like measure for the request to rawURL with method and
+// body, which may be nil. The Result is only read once it was delivered,
+// when the body was read or sending the request failed, as the hooks of
+// the transport may still record into it before.
+func measureURL(ctx context.Context, client *http.Client, cfg *config, method, rawURL string, body []byte) result {
+	rec := result{Record: httpstat.Record{Time: time.Now(), Method: method, URL: rawURL, Result: &httpstat.Result{}}}
+	ctx, cancel := context.WithTimeout(ctx, cfg.timeout)
+	defer cancel()
+	var rd io.Reader
+	if body != nil {
+		rd = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, rd)
+	if err != nil {
+		rec.Err = err
+		return rec
+	}
+	setHeaders(req, cfg)
+
+	res, r, err := (&httpstat.Client{HTTPClient: client}).Do(req)
+	rec.Result = r
+	if err != nil {
+		rec.Err = err
+		return rec
+	}
+	// Reading the body to the end ends the Result.
+	_, err = io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+	rec.StatusCode, rec.Proto, rec.Status = res.StatusCode, res.Proto, res.Status
+	if loc, lerr := res.Location(); lerr == nil {
+		rec.Location = loc.String()
+	}
+	rec.Err = err
+	return rec
+}
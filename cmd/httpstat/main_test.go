@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jakobilobi/go-httpstat/httpstattest"
+)
+
+func TestRun(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer ts.Close()
+
+	var stdout, stderr bytes.Buffer
+	if code := run(context.Background(), []string{ts.URL}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d, stderr: %s", code, stderr.String())
+	}
+	out := stdout.String()
+	if !strings.HasPrefix(out, "HTTP/1.1 200 OK\n") || !strings.Contains(out, "Server processing:") {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+}
+
+func TestRun_Load(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1)%5 == 0 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	defer ts.Close()
+
+	var stdout, stderr bytes.Buffer
+	if code := run(context.Background(), []string{"-n", "20", "-c", "4", ts.URL}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d, stderr: %s", code, stderr.String())
+	}
+	out := stdout.String()
+	for _, want := range []string{"Requests: 20 in ", "16 successful", "Server processing", "p99", "  200  16\n", "  503  4\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expect %q in output:\n%s", want, out)
+		}
+	}
+	if calls != 20 {
+		t.Fatalf("server got %d requests, want 20", calls)
+	}
+}
+
+func TestRun_Errors(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	ts.Close()
+
+	var stdout, stderr bytes.Buffer
+	if code := run(context.Background(), []string{"-n", "3", ts.URL}, &stdout, &stderr); code != 1 {
+		t.Fatalf("exit code %d, want 1", code)
+	}
+	if out := stdout.String(); !strings.Contains(out, "Errors:\n  failed in TCP connection  3\n") {
+		t.Fatalf("expect the errors by phase in output:\n%s", out)
+	}
+
+	if code := run(context.Background(), []string{"-n", "0", ts.URL}, &stdout, &stderr); code != 2 {
+		t.Fatalf("exit code %d for invalid flags, want 2", code)
+	}
+}
+
+func TestRun_Watch(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer ts.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	var stdout, stderr bytes.Buffer
+	if code := run(ctx, []string{"-watch", "10ms", ts.URL}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d, stderr: %s", code, stderr.String())
+	}
+	screens := strings.Split(stdout.String(), "\x1b[H\x1b[2J")
+	if len(screens) < 4 {
+		t.Fatalf("expect the table to be redrawn after each request, got %d screens", len(screens)-1)
+	}
+	last := screens[len(screens)-1]
+	for _, want := range []string{ts.URL, "200 OK", "Server processing", "Total"} {
+		if !strings.Contains(last, want) {
+			t.Errorf("expect %q in screen:\n%s", want, last)
+		}
+	}
+}
+
+func TestRun_Follow(t *testing.T) {
+	ts := httpstattest.NewHTTP1(t)
+
+	var stdout, stderr bytes.Buffer
+	if code := run(context.Background(), []string{"-L", ts.URL + "/redirect/2"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d, stderr: %s", code, stderr.String())
+	}
+	out := stdout.String()
+	for _, want := range []string{
+		"[1] GET " + ts.URL + "/redirect/2\nHTTP/1.1 302 Found -> " + ts.URL + "/redirect/1\n",
+		"[2] GET " + ts.URL + "/redirect/1\nHTTP/1.1 302 Found -> " + ts.URL + "/\n",
+		"[3] GET " + ts.URL + "/\nHTTP/1.1 200 OK\n",
+		"Redirects: 2, combined total: ",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expect %q in output:\n%s", want, out)
+		}
+	}
+
+	// Without -L, the redirect is shown as it is.
+	stdout.Reset()
+	if code := run(context.Background(), []string{ts.URL + "/redirect/2"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d, stderr: %s", code, stderr.String())
+	}
+	if out := stdout.String(); !strings.HasPrefix(out, "HTTP/1.1 302 Found\n") {
+		t.Fatalf("expect the redirect not to be followed:\n%s", out)
+	}
+}
+
+func TestRun_Output(t *testing.T) {
+	ts := httpstattest.NewHTTP1(t)
+
+	for _, tt := range []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"-o", "json"}, []string{`"statusCode":200`, `"url":"` + ts.URL + `"`}},
+		{[]string{"-o", "csv", "-n", "2"}, []string{"time,method,url,status_code", ",GET," + ts.URL + ",200,"}},
+		{[]string{"-o", "template={{.Method}} {{.StatusCode}} {{.Proto}}"}, []string{"GET 200 HTTP/1.1\n"}},
+		{[]string{"-o", "json", "-L"}, []string{`"statusCode":302`, `"statusCode":200`}},
+	} {
+		var stdout, stderr bytes.Buffer
+		url := ts.URL
+		if tt.args[len(tt.args)-1] == "-L" {
+			url += "/redirect/1"
+		}
+		if code := run(context.Background(), append(tt.args, url), &stdout, &stderr); code != 0 {
+			t.Fatalf("%v: exit code %d, stderr: %s", tt.args, code, stderr.String())
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(stdout.String(), want) {
+				t.Errorf("%v: expect %q in output:\n%s", tt.args, want, stdout.String())
+			}
+		}
+		if strings.Contains(stdout.String(), "Server processing") {
+			t.Errorf("%v: expect no text output:\n%s", tt.args, stdout.String())
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run(context.Background(), []string{"-o", "yaml", ts.URL}, &stdout, &stderr); code != 2 {
+		t.Fatalf("exit code %d for an unknown output, want 2", code)
+	}
+}
+
+func TestRun_Resolve(t *testing.T) {
+	var host string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host = r.Host
+		io.WriteString(w, "ok")
+	}))
+	defer ts.Close()
+	_, port, _ := net.SplitHostPort(ts.Listener.Addr().String())
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"--resolve", "backend.invalid:" + port + ":127.0.0.1", "http://backend.invalid:" + port + "/"}
+	if code := run(context.Background(), args, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d, stderr: %s", code, stderr.String())
+	}
+	if host != "backend.invalid:"+port {
+		t.Fatalf("Host = %q, want the host of the URL", host)
+	}
+
+	if code := run(context.Background(), []string{"--resolve", "backend.invalid:80", ts.URL}, &stdout, &stderr); code != 2 {
+		t.Fatalf("exit code %d for an invalid --resolve, want 2", code)
+	}
+}
+
+func TestRun_Interface(t *testing.T) {
+	var remote string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remote = r.RemoteAddr
+		io.WriteString(w, "ok")
+	}))
+	defer ts.Close()
+
+	var stdout, stderr bytes.Buffer
+	if code := run(context.Background(), []string{"--interface", "127.0.0.1", ts.URL}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit code %d, stderr: %s", code, stderr.String())
+	}
+	if host, _, _ := net.SplitHostPort(remote); host != "127.0.0.1" {
+		t.Fatalf("request came from %s, want 127.0.0.1", remote)
+	}
+
+	if code := run(context.Background(), []string{"--interface", "no-such-interface0", ts.URL}, &stdout, &stderr); code != 2 {
+		t.Fatalf("exit code %d for an unknown interface, want 2", code)
+	}
+}
+
+func TestRun_HTTPVersion(t *testing.T) {
+	ts := httpstattest.NewHTTP2(t)
+	for _, tt := range []struct {
+		url, flag, proto string
+	}{
+		{ts.URL, "--http1.1", "HTTP/1.1"},
+		{ts.URL, "--http2", "HTTP/2.0"},
+		{httpstattest.NewH2C(t).URL, "--http2", "HTTP/2.0"},
+	} {
+		var stderr bytes.Buffer
+		cfg, err := parseFlags([]string{tt.flag, tt.url}, io.Discard, &stderr)
+		if err != nil {
+			t.Fatalf("%s: %v: %s", tt.flag, err, stderr.String())
+		}
+		client := newClient(cfg)
+		// Trust the certificate of the test server.
+		client.Transport.(*http.Transport).TLSClientConfig = ts.Client().Transport.(*http.Transport).TLSClientConfig.Clone()
+		rec := measure(context.Background(), client, cfg)
+		if rec.Err != nil || rec.Proto != tt.proto {
+			t.Errorf("%s %s: got %s, %v, want %s", tt.flag, tt.url, rec.Proto, rec.Err, tt.proto)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := run(context.Background(), []string{"--http1.1", "--http2", ts.URL}, &stdout, &stderr); code != 2 {
+		t.Fatalf("exit code %d for exclusive versions, want 2", code)
+	}
+	if code := run(context.Background(), []string{"--http2", "--http3", ts.URL}, &stdout, &stderr); code != 2 {
+		t.Fatalf("exit code %d for exclusive versions, want 2", code)
+	}
+}
+
+func TestRun_Thresholds(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		io.WriteString(w, "ok")
+	}))
+	defer ts.Close()
+
+	for _, tt := range []struct {
+		args []string
+		code int
+	}{
+		{[]string{"--max-ttfb", "1s"}, exitOK},
+		{[]string{"--max-ttfb", "5ms"}, exitThreshold},
+		{[]string{"--max-time", "5ms", "-o", "json"}, exitThreshold},
+		{[]string{"--max-time", "5ms", "-n", "2"}, exitThreshold},
+		{[]string{"--max-time", "1s", "-n", "2"}, exitOK},
+	} {
+		var stdout, stderr bytes.Buffer
+		if code := run(context.Background(), append(tt.args, ts.URL), &stdout, &stderr); code != tt.code {
+			t.Errorf("%v: exit code %d, want %d, stderr: %s", tt.args, code, tt.code, stderr.String())
+		}
+		if tt.code == exitThreshold && !strings.Contains(stderr.String(), "threshold") {
+			t.Errorf("%v: expect the exceeded threshold in stderr %q", tt.args, stderr.String())
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/jakobilobi/go-httpstat"
+)
+
+// output writes each measured request for scripts, as selected by -o.
+type output interface {
+	write(rec result) error
+}
+
+// parseOutput returns the output to w selected by the value of -o: "json"
+// for a line of JSON per request, "csv" for CSV with a header, or
+// "template=TMPL" for the text/template TMPL executed with each request.
+// It returns nil for "text", the human-readable default.
+func parseOutput(s string, w io.Writer) (output, error) {
+	switch {
+	case s == "" || s == "text":
+		return nil, nil
+	case s == "json":
+		return &jsonOutput{enc: json.NewEncoder(w)}, nil
+	case s == "csv":
+		return &csvOutput{w: httpstat.NewCSVWriter(w)}, nil
+	case strings.HasPrefix(s, "template="):
+		tmpl, err := template.New("output").Parse(strings.TrimPrefix(s, "template="))
+		if err != nil {
+			return nil, err
+		}
+		return &templateOutput{tmpl: tmpl, w: w}, nil
+	}
+	return nil, fmt.Errorf("unknown output %q", s)
+}
+
+// jsonOutput writes the Record of each request as a line of JSON.
+type jsonOutput struct {
+	enc *json.Encoder
+}
+
+func (o *jsonOutput) write(rec result) error {
+	return o.enc.Encode(rec.Record)
+}
+
+// csvOutput writes the Record of each request as a row of CSV.
+type csvOutput struct {
+	w *httpstat.CSVWriter
+}
+
+func (o *csvOutput) write(rec result) error {
+	return o.w.WriteRecord(rec.Record)
+}
+
+// templateOutput executes a template with each request, followed by a
+// newline. The fields of the template are those of result, e.g.
+// {{.StatusCode}} or {{.Result.Total}}.
+type templateOutput struct {
+	tmpl *template.Template
+	w    io.Writer
+}
+
+func (o *templateOutput) write(rec result) error {
+	if err := o.tmpl.Execute(o.w, rec); err != nil {
+		return err
+	}
+	_, err := io.WriteString(o.w, "\n")
+	return err
+}
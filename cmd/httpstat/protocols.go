@@ -0,0 +1,21 @@
+//go:build go1.24
+// +build go1.24
+
+package main
+
+import "net/http"
+
+// setProtocol restricts transport to the HTTP version of --http1.1 or
+// --http2. With --http2, plain http:// URLs are sent as h2c with prior
+// knowledge.
+func setProtocol(transport *http.Transport, version string) {
+	switch version {
+	case "1.1":
+		transport.Protocols = new(http.Protocols)
+		transport.Protocols.SetHTTP1(true)
+	case "2":
+		transport.Protocols = new(http.Protocols)
+		transport.Protocols.SetHTTP2(true)
+		transport.Protocols.SetUnencryptedHTTP2(true)
+	}
+}
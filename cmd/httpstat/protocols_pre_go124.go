@@ -0,0 +1,22 @@
+//go:build !go1.24
+// +build !go1.24
+
+package main
+
+import (
+	"crypto/tls"
+	"net/http"
+)
+
+// setProtocol restricts transport to the HTTP version of --http1.1 or
+// --http2. Before Go 1.24, plain http:// URLs are always sent with
+// HTTP/1.1.
+func setProtocol(transport *http.Transport, version string) {
+	switch version {
+	case "1.1":
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	case "2":
+		transport.ForceAttemptHTTP2 = true
+	}
+}
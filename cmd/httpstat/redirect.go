@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// maxRedirects is the number of redirects follow follows, like
+// http.Client.
+const maxRedirects = 10
+
+// follow sends the request of cfg and follows the redirects of the
+// responses, measuring each hop on its own. It returns the hops in order;
+// the last one failed or was not a redirect.
+func follow(ctx context.Context, client *http.Client, cfg *config) []result {
+	method, url, body := cfg.method, cfg.url, cfg.body
+	var hops []result
+	for {
+		rec := measureURL(ctx, client, cfg, method, url, body)
+		hops = append(hops, rec)
+		if rec.Err != nil || !isRedirect(rec.StatusCode) || rec.Location == "" {
+			return hops
+		}
+		if len(hops) > maxRedirects {
+			hops[len(hops)-1].Err = errors.New("stopped after 10 redirects")
+			return hops
+		}
+		switch rec.StatusCode {
+		case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther:
+			// Like browsers, follow with GET without the body, except
+			// for HEAD requests.
+			if method != "HEAD" {
+				method, body = "GET", nil
+			}
+		}
+		url = rec.Location
+	}
+}
+
+func isRedirect(code int) bool {
+	switch code {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// writeHops writes the phases of each hop and the combined total to w.
+func writeHops(w io.Writer, hops []result) {
+	var total time.Duration
+	for i, hop := range hops {
+		fmt.Fprintf(w, "[%d] %s %s\n", i+1, hop.Method, hop.URL)
+		if hop.Err != nil {
+			fmt.Fprintf(w, "    %v\n\n", hop.Err)
+			continue
+		}
+		fmt.Fprintf(w, "%s %s", hop.Proto, hop.Status)
+		if isRedirect(hop.StatusCode) {
+			fmt.Fprintf(w, " -> %s", hop.Location)
+		}
+		fmt.Fprintf(w, "\n\n%+v\n", hop.Result)
+		total += hop.Result.Total()
+	}
+	fmt.Fprintf(w, "Redirects: %d, combined total: %s\n", len(hops)-1, ms(total))
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// headerFlag is the value of -H, the headers added to the requests.
+type headerFlag http.Header
+
+func (f headerFlag) String() string {
+	var s []string
+	for name, values := range f {
+		for _, v := range values {
+			s = append(s, name+": "+v)
+		}
+	}
+	return strings.Join(s, ", ")
+}
+
+// Set adds a header of the form "Name: value".
+func (f headerFlag) Set(v string) error {
+	name, value, ok := strings.Cut(v, ":")
+	if !ok || strings.TrimSpace(name) == "" {
+		return fmt.Errorf("want Name: value, got %q", v)
+	}
+	http.Header(f).Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	return nil
+}
+
+// readData returns the request body of -d or --data-binary. Like curl,
+// a value starting with @ names a file to read, "-" for stdin, and -d
+// strips the newlines of files.
+func readData(v string, binary bool) ([]byte, error) {
+	if !strings.HasPrefix(v, "@") {
+		return []byte(v), nil
+	}
+	var data []byte
+	var err error
+	if name := v[1:]; name == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !binary {
+		data = bytes.ReplaceAll(data, []byte("\r"), nil)
+		data = bytes.ReplaceAll(data, []byte("\n"), nil)
+	}
+	return data, nil
+}
+
+// setHeaders sets the headers of -H on req.
+func setHeaders(req *http.Request, cfg *config) {
+	for name, values := range cfg.header {
+		if http.CanonicalHeaderKey(name) == "Host" {
+			req.Host = values[len(values)-1]
+			continue
+		}
+		req.Header[name] = append([]string(nil), values...)
+	}
+	if req.Body != nil && req.Header.Get("Content-Type") == "" {
+		// Like curl, the data is sent as form data by default.
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+}
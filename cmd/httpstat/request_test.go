@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun_Request(t *testing.T) {
+	var method, contentType, token, body string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Expect") == "100-continue" {
+			time.Sleep(20 * time.Millisecond)
+		}
+		b, _ := io.ReadAll(r.Body)
+		method, contentType, token, body = r.Method, r.Header.Get("Content-Type"), r.Header.Get("X-Token"), string(b)
+		io.WriteString(w, "ok")
+	}))
+	defer ts.Close()
+
+	file := filepath.Join(t.TempDir(), "body")
+	if err := os.WriteFile(file, []byte("a=1\n&b=2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		args                      []string
+		method, contentType, body string
+	}{
+		{[]string{"-X", "DELETE"}, "DELETE", "", ""},
+		{[]string{"-d", "@" + file}, "POST", "application/x-www-form-urlencoded", "a=1&b=2"},
+		{[]string{"--data-binary", "@" + file, "-X", "PUT", "-H", "Content-Type: text/plain"}, "PUT", "text/plain", "a=1\n&b=2\n"},
+	} {
+		var stdout, stderr bytes.Buffer
+		args := append(tt.args, "-H", "X-Token: secret", ts.URL)
+		if code := run(context.Background(), args, &stdout, &stderr); code != 0 {
+			t.Fatalf("%v: exit code %d, stderr: %s", tt.args, code, stderr.String())
+		}
+		if method != tt.method || contentType != tt.contentType || body != tt.body || token != "secret" {
+			t.Errorf("%v: server got %s, %q, %q, token %q", tt.args, method, contentType, body, token)
+		}
+	}
+
+	var stderr bytes.Buffer
+	cfg, err := parseFlags([]string{"-d", "x", "-H", "Expect: 100-continue", ts.URL}, io.Discard, &stderr)
+	if err != nil {
+		t.Fatal(err, stderr.String())
+	}
+	rec := measure(context.Background(), newClient(cfg), cfg)
+	if rec.Err != nil || rec.Result.ContinueWait < 20*time.Millisecond {
+		t.Fatalf("expect the 100-continue wait to be measured, got %v, %v", rec.Result.ContinueWait, rec.Err)
+	}
+
+	if code := run(context.Background(), []string{"-H", "invalid", ts.URL}, io.Discard, &stderr); code != 2 {
+		t.Fatalf("exit code %d for an invalid header, want 2", code)
+	}
+	if !strings.Contains(stderr.String(), "Name: value") {
+		t.Fatalf("expect the header format in %q", stderr.String())
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jakobilobi/go-httpstat"
+)
+
+// watchWidth is the number of recent requests shown by the sparklines.
+const watchWidth = 40
+
+// sparks are the bars of the sparklines, from lowest to highest.
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// watcher keeps the recent requests of watch.
+type watcher struct {
+	agg     httpstat.Aggregator
+	history [httpstat.PhaseTransfer + 2][]time.Duration // phases and total
+	errors  int
+	last    result
+}
+
+// watch sends the request of cfg at cfg.watch intervals until ctx is done,
+// redrawing the durations of the recent requests after each one, or
+// writing each request to cfg.out if set.
+func watch(ctx context.Context, client *http.Client, cfg *config, w io.Writer) {
+	wt := &watcher{agg: httpstat.Aggregator{Window: 1000}}
+	ticker := time.NewTicker(cfg.watch)
+	defer ticker.Stop()
+	for {
+		rec := measure(ctx, client, cfg)
+		if ctx.Err() != nil {
+			return
+		}
+		if cfg.out != nil {
+			cfg.out.write(rec)
+		} else {
+			wt.add(rec)
+			// Move the cursor home and clear the screen before redrawing.
+			io.WriteString(w, "\x1b[H\x1b[2J")
+			wt.render(w, cfg.url)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (wt *watcher) add(rec result) {
+	wt.last = rec
+	if rec.Err != nil || rec.StatusCode >= 400 {
+		wt.errors++
+		return
+	}
+	wt.agg.Add(rec.Result)
+	for i := range wt.history {
+		d := rec.Result.Total()
+		if p := httpstat.Phase(i); p <= httpstat.PhaseTransfer {
+			d = rec.Result.Duration(p)
+		}
+		h := append(wt.history[i], d)
+		if len(h) > watchWidth {
+			h = h[len(h)-watchWidth:]
+		}
+		wt.history[i] = h
+	}
+}
+
+func (wt *watcher) render(w io.Writer, url string) {
+	fmt.Fprintf(w, "%s  %d ok, %d errors\n", url, wt.agg.Count(), wt.errors)
+	switch {
+	case wt.last.Err != nil:
+		fmt.Fprintf(w, "Last: %s  %v\n\n", wt.last.Time.Format("15:04:05"), wt.last.Err)
+	default:
+		fmt.Fprintf(w, "Last: %s  %s\n\n", wt.last.Time.Format("15:04:05"), wt.last.Status)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Phase\tRecent\tLast\tp50\tp95\t")
+	row := func(name string, last time.Duration, st httpstat.Stats, history []time.Duration) {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t\n", name, sparkline(history), ms(last), ms(st.P50), ms(st.P95))
+	}
+	for p := httpstat.PhaseDNS; p <= httpstat.PhaseTransfer; p++ {
+		if st := wt.agg.Phase(p); st.Count > 0 {
+			row(p.String(), wt.history[p][len(wt.history[p])-1], st, wt.history[p])
+		}
+	}
+	if h := wt.history[len(wt.history)-1]; len(h) > 0 {
+		row("Total", h[len(h)-1], wt.agg.Total(), h)
+	}
+	tw.Flush()
+}
+
+// sparkline returns a bar per duration, scaled to the longest one.
+func sparkline(durations []time.Duration) string {
+	var max time.Duration
+	for _, d := range durations {
+		if d > max {
+			max = d
+		}
+	}
+	var b strings.Builder
+	for _, d := range durations {
+		n := 0
+		if max > 0 {
+			n = int(int64(len(sparks)-1) * int64(d) / int64(max))
+		}
+		b.WriteRune(sparks[n])
+	}
+	return b.String()
+}
@@ -0,0 +1,84 @@
+package httpstat
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"text/tabwriter"
+	"time"
+)
+
+// ColdWarm holds the Results of a request on a fresh connection and of a
+// second request reusing the connection, as measured by MeasureColdWarm.
+type ColdWarm struct {
+	Cold *Result
+	Warm *Result
+
+	// Reused reports whether the warm request actually reused the
+	// connection of the cold one. It is false if the server closed it.
+	Reused bool
+}
+
+// MeasureColdWarm sends two GET requests to url, the first on a new
+// connection and the second on the kept-alive connection, so the Results
+// show how much connection reuse saves. The requests are sent through a
+// clone of base, or of http.DefaultTransport if base is nil, which is
+// closed afterwards.
+func MeasureColdWarm(ctx context.Context, url string, base *http.Transport) (*ColdWarm, error) {
+	if base == nil {
+		base = http.DefaultTransport.(*http.Transport)
+	}
+	t := base.Clone()
+	defer t.CloseIdleConnections()
+	client := &http.Client{Transport: t}
+
+	cold := probe(ctx, client, url)
+	if cold.Err != nil {
+		return nil, cold.Err
+	}
+	warm := probe(ctx, client, url)
+	if warm.Err != nil {
+		return nil, warm.Err
+	}
+	return &ColdWarm{
+		Cold:   cold.Result,
+		Warm:   warm.Result,
+		Reused: warm.Result.isReused,
+	}, nil
+}
+
+// Saved returns how much shorter the warm request was than the cold one.
+func (c *ColdWarm) Saved() time.Duration {
+	return c.Cold.Total() - c.Warm.Total()
+}
+
+// PhaseSaved returns how much shorter phase p of the warm request was than
+// that of the cold one.
+func (c *ColdWarm) PhaseSaved(p Phase) time.Duration {
+	return c.Cold.Duration(p) - c.Warm.Duration(p)
+}
+
+// WriteTo writes a table of the phase durations of both requests and their
+// difference to w.
+func (c *ColdWarm) WriteTo(w io.Writer) (int64, error) {
+	cw := &countWriter{w: w}
+	tw := tabwriter.NewWriter(cw, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Phase\tCold\tWarm\tSaved\t")
+	for p := range phaseNames {
+		p := Phase(p)
+		cold, warm := c.Cold.Duration(p), c.Warm.Duration(p)
+		if cold == 0 && warm == 0 {
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%d ms\t%d ms\t%d ms\t\n", p,
+			cold/time.Millisecond, warm/time.Millisecond, c.PhaseSaved(p)/time.Millisecond)
+	}
+	fmt.Fprintf(tw, "Total\t%d ms\t%d ms\t%d ms\t\n",
+		c.Cold.Total()/time.Millisecond, c.Warm.Total()/time.Millisecond, c.Saved()/time.Millisecond)
+	if !c.Reused {
+		fmt.Fprintln(tw, "(the connection was not reused)")
+	}
+	err := tw.Flush()
+	return cw.n, err
+}
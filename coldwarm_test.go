@@ -0,0 +1,55 @@
+package httpstat
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMeasureColdWarm(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer ts.Close()
+
+	cw, err := MeasureColdWarm(context.Background(), ts.URL, ts.Client().Transport.(*http.Transport))
+	if err != nil {
+		t.Fatal("MeasureColdWarm failed:", err)
+	}
+	if !cw.Reused {
+		t.Fatal("expect the warm request to reuse the connection")
+	}
+	if cw.Cold.TLSHandshake <= 0 || cw.Warm.TLSHandshake != 0 {
+		t.Fatalf("expect only the cold request to do a handshake, got %v and %v",
+			cw.Cold.TLSHandshake, cw.Warm.TLSHandshake)
+	}
+	if cw.PhaseSaved(PhaseTLS) != cw.Cold.TLSHandshake {
+		t.Fatalf("expect the handshake to be saved, got %v", cw.PhaseSaved(PhaseTLS))
+	}
+
+	var buf bytes.Buffer
+	cw.WriteTo(&buf)
+	out := buf.String()
+	if !strings.HasPrefix(out, "Phase") || !strings.Contains(out, "TLS handshake") || strings.Contains(out, "not reused") {
+		t.Fatalf("unexpected table:\n%s", out)
+	}
+}
+
+func TestMeasureColdWarm_NotReused(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Connection", "close")
+	}))
+	defer ts.Close()
+
+	cw, err := MeasureColdWarm(context.Background(), ts.URL, nil)
+	if err != nil {
+		t.Fatal("MeasureColdWarm failed:", err)
+	}
+	if cw.Reused {
+		t.Fatal("expect the connection not to be reused")
+	}
+}
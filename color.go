@@ -0,0 +1,117 @@
+package httpstat
+
+import (
+	"os"
+	"time"
+)
+
+// ANSI escape sequences used by ColorFormatter.
+const (
+	ansiReset  = "\x1b[0m"
+	ansiYellow = "\x1b[33m"
+	ansiRed    = "\x1b[31m"
+	ansiGrey   = "\x1b[90m"
+)
+
+// Threshold sets the durations from which ColorFormatter highlights a
+// phase. Zero durations are not checked.
+type Threshold struct {
+	// Warn is the duration from which the phase is shown in yellow.
+	Warn time.Duration
+
+	// Critical is the duration from which the phase is shown in red.
+	Critical time.Duration
+}
+
+// ColorFormatter formats Results like AppendFormat, highlighting phases
+// which took longer than their Threshold in yellow or red, and showing
+// skipped phases in grey, for reading many Results in a terminal.
+type ColorFormatter struct {
+	// Default is the Threshold of the phases which are not in Phases.
+	Default Threshold
+
+	// Phases are the Thresholds of single phases, e.g. a lower one for
+	// the DNS lookup than for the server processing.
+	Phases map[Phase]Threshold
+
+	// Total is the Threshold of the total duration.
+	Total Threshold
+
+	// Color enables the colors. If it is false, the output is the same as
+	// that of AppendFormat.
+	Color bool
+}
+
+// NewColorFormatter returns a ColorFormatter for output to f, with colors
+// enabled if f is a terminal and the NO_COLOR environment variable is not
+// set (see https://no-color.org).
+func NewColorFormatter(f *os.File) *ColorFormatter {
+	return &ColorFormatter{Color: colorTerminal(f)}
+}
+
+// colorTerminal reports whether f is a terminal which should show colors.
+func colorTerminal(f *os.File) bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	if f == nil {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// AppendFormat appends the formatted Result to dst, like r.AppendFormat,
+// and returns the extended buffer.
+func (c *ColorFormatter) AppendFormat(dst []byte, r *Result, layout Layout) []byte {
+	if !c.Color {
+		c = nil
+	}
+	share := layout&LayoutPercent != 0 && r.total > 0
+	if layout&LayoutMultiline != 0 {
+		return r.appendMultiline(dst, share, c)
+	}
+	return r.appendLine(dst, share, c)
+}
+
+// phaseColor returns the escape sequence starting the output of phase p
+// which took d, or "" for the default color. c may be nil.
+func (c *ColorFormatter) phaseColor(p Phase, d time.Duration, skipped bool) string {
+	if c == nil {
+		return ""
+	}
+	if skipped {
+		return ansiGrey
+	}
+	t, ok := c.Phases[p]
+	if !ok {
+		t = c.Default
+	}
+	return t.color(d)
+}
+
+// totalColor is like phaseColor for the total duration.
+func (c *ColorFormatter) totalColor(d time.Duration) string {
+	if c == nil || d <= 0 {
+		return ""
+	}
+	return c.Total.color(d)
+}
+
+func (t Threshold) color(d time.Duration) string {
+	switch {
+	case t.Critical > 0 && d >= t.Critical:
+		return ansiRed
+	case t.Warn > 0 && d >= t.Warn:
+		return ansiYellow
+	}
+	return ""
+}
+
+// appendReset appends the escape sequence ending color, if any.
+func appendReset(dst []byte, color string) []byte {
+	if color == "" {
+		return dst
+	}
+	return append(dst, ansiReset...)
+}
@@ -0,0 +1,63 @@
+package httpstat
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestColorFormatter(t *testing.T) {
+	r := NewResultFromPhases(map[Phase]time.Duration{
+		PhaseDNS:      5 * time.Millisecond,
+		PhaseConnect:  60 * time.Millisecond,
+		PhaseServer:   200 * time.Millisecond,
+		PhaseTransfer: 10 * time.Millisecond,
+	})
+	c := &ColorFormatter{
+		Default: Threshold{Warn: 50 * time.Millisecond, Critical: 100 * time.Millisecond},
+		Phases:  map[Phase]Threshold{PhaseDNS: {Warn: time.Millisecond}},
+		Total:   Threshold{Critical: 250 * time.Millisecond},
+		Color:   true,
+	}
+
+	line := string(c.AppendFormat(nil, r, LayoutLine))
+	for _, want := range []string{
+		ansiYellow + "DNSLookup: 5 ms" + ansiReset,
+		ansiYellow + "TCPConnection: 60 ms" + ansiReset,
+		ansiGrey + "TLSHandshake: 0 ms" + ansiReset,
+		ansiRed + "ServerProcessing: 200 ms" + ansiReset,
+		", ContentTransfer: 10 ms,",
+		ansiRed + "Total: 275 ms" + ansiReset,
+	} {
+		if !strings.Contains(line, want) {
+			t.Errorf("expect %q in %q", want, line)
+		}
+	}
+
+	multi := string(c.AppendFormat(nil, r, LayoutMultiline))
+	if !strings.Contains(multi, ansiRed+"Server processing:") {
+		t.Errorf("expect the server processing in red in:\n%s", multi)
+	}
+
+	c.Color = false
+	if got, want := string(c.AppendFormat(nil, r, LayoutMultiline)), string(r.AppendFormat(nil, LayoutMultiline)); got != want {
+		t.Fatalf("expect the plain output without colors, got\n%s", got)
+	}
+}
+
+func TestNewColorFormatter(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if NewColorFormatter(f).Color {
+		t.Fatal("expect no colors for a regular file")
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if colorTerminal(os.Stdout) {
+		t.Fatal("expect no colors with NO_COLOR set")
+	}
+}
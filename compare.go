@@ -0,0 +1,154 @@
+package httpstat
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// Delta is the change of a duration from A, the baseline, to B, e.g. from
+// before to after switching the CDN.
+type Delta struct {
+	A, B time.Duration
+}
+
+// Diff returns B - A, negative if B is faster.
+func (d Delta) Diff() time.Duration {
+	return d.B - d.A
+}
+
+// Percent returns the change from A to B in percent of A, or 0 if A is
+// zero.
+func (d Delta) Percent() float64 {
+	if d.A == 0 {
+		return 0
+	}
+	return 100 * float64(d.B-d.A) / float64(d.A)
+}
+
+// String formats the change like "+12ms (+25.0%)".
+func (d Delta) String() string {
+	diff := d.Diff().String()
+	if d.Diff() >= 0 {
+		diff = "+" + diff
+	}
+	if d.A == 0 {
+		return diff
+	}
+	return fmt.Sprintf("%s (%+.1f%%)", diff, d.Percent())
+}
+
+// PhaseDelta is the change of the duration of a phase.
+type PhaseDelta struct {
+	Phase Phase
+	Delta
+}
+
+// Comparison is the outcome of Compare.
+type Comparison struct {
+	// Phases are the changes of the phases in the order they happen.
+	// Phases which took no time in both requests are left out.
+	Phases []PhaseDelta
+
+	// Total is the change of the total duration.
+	Total Delta
+}
+
+// Compare returns the per-phase changes from a to b, e.g. to see which
+// phases a TLS config change or a region move sped up.
+func Compare(a, b *Result) *Comparison {
+	c := &Comparison{Total: Delta{A: a.Total(), B: b.Total()}}
+	for i := range phaseNames {
+		p := Phase(i)
+		if a.Duration(p) <= 0 && b.Duration(p) <= 0 {
+			continue
+		}
+		c.Phases = append(c.Phases, PhaseDelta{Phase: p, Delta: Delta{A: a.Duration(p), B: b.Duration(p)}})
+	}
+	return c
+}
+
+// WriteTo writes a table of the phase durations of both requests and their
+// changes to w.
+func (c *Comparison) WriteTo(w io.Writer) (int64, error) {
+	cw := &countWriter{w: w}
+	tw := tabwriter.NewWriter(cw, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "Phase\tA\tB\tChange\t")
+	for _, d := range c.Phases {
+		fmt.Fprintf(tw, "%s\t%v\t%v\t%v\t\n", d.Phase, d.A, d.B, d.Delta)
+	}
+	fmt.Fprintf(tw, "Total\t%v\t%v\t%v\t\n", c.Total.A, c.Total.B, c.Total)
+	err := tw.Flush()
+	return cw.n, err
+}
+
+// StatsDelta is the change of aggregated durations.
+type StatsDelta struct {
+	Count                         [2]int
+	Min, Max, Mean, P50, P90, P95 Delta
+	P99                           Delta
+}
+
+// CompareStats returns the changes from a to b, e.g. of the Stats of a
+// phase before and after a change.
+func CompareStats(a, b Stats) StatsDelta {
+	return StatsDelta{
+		Count: [2]int{a.Count, b.Count},
+		Min:   Delta{a.Min, b.Min},
+		Max:   Delta{a.Max, b.Max},
+		Mean:  Delta{a.Mean, b.Mean},
+		P50:   Delta{a.P50, b.P50},
+		P90:   Delta{a.P90, b.P90},
+		P95:   Delta{a.P95, b.P95},
+		P99:   Delta{a.P99, b.P99},
+	}
+}
+
+// PhaseStatsDelta is the change of the Stats of a phase.
+type PhaseStatsDelta struct {
+	Phase Phase
+	StatsDelta
+}
+
+// AggregateComparison is the outcome of CompareAggregators.
+type AggregateComparison struct {
+	// Phases are the changes of the phases in the order they happen.
+	// Phases without durations in both Aggregators are left out.
+	Phases []PhaseStatsDelta
+
+	// Total is the change of the total durations.
+	Total StatsDelta
+}
+
+// CompareAggregators returns the per-phase changes of the Stats from a to
+// b, for before/after comparisons of many requests.
+func CompareAggregators(a, b *Aggregator) *AggregateComparison {
+	c := &AggregateComparison{Total: CompareStats(a.Total(), b.Total())}
+	for i := range phaseNames {
+		p := Phase(i)
+		sa, sb := a.Phase(p), b.Phase(p)
+		if sa.Count == 0 && sb.Count == 0 {
+			continue
+		}
+		c.Phases = append(c.Phases, PhaseStatsDelta{Phase: p, StatsDelta: CompareStats(sa, sb)})
+	}
+	return c
+}
+
+// WriteTo writes a table of the median and 95th percentile of each phase
+// and their changes to w.
+func (c *AggregateComparison) WriteTo(w io.Writer) (int64, error) {
+	cw := &countWriter{w: w}
+	tw := tabwriter.NewWriter(cw, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "Phase\tA p50\tB p50\tChange\tA p95\tB p95\tChange\t")
+	row := func(name string, d StatsDelta) {
+		fmt.Fprintf(tw, "%s\t%v\t%v\t%v\t%v\t%v\t%v\t\n", name, d.P50.A, d.P50.B, d.P50, d.P95.A, d.P95.B, d.P95)
+	}
+	for _, d := range c.Phases {
+		row(d.Phase.String(), d.StatsDelta)
+	}
+	row("Total", c.Total)
+	err := tw.Flush()
+	return cw.n, err
+}
@@ -0,0 +1,67 @@
+package httpstat
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompare(t *testing.T) {
+	a := NewResultFromPhases(map[Phase]time.Duration{
+		PhaseDNS:    10 * time.Millisecond,
+		PhaseServer: 40 * time.Millisecond,
+	})
+	b := NewResultFromPhases(map[Phase]time.Duration{
+		PhaseDNS:    5 * time.Millisecond,
+		PhaseServer: 50 * time.Millisecond,
+	})
+
+	c := Compare(a, b)
+	if len(c.Phases) != 2 || c.Phases[0].Phase != PhaseDNS || c.Phases[1].Phase != PhaseServer {
+		t.Fatalf("expect the DNS and server phases, got %+v", c.Phases)
+	}
+	if d := c.Phases[0]; d.Diff() != -5*time.Millisecond || d.Percent() != -50 {
+		t.Errorf("DNS: got %v, %v%%", d.Diff(), d.Percent())
+	}
+	if got, want := c.Total.String(), "+5ms (+10.0%)"; got != want {
+		t.Errorf("Total = %q, want %q", got, want)
+	}
+
+	var buf bytes.Buffer
+	if _, err := c.WriteTo(&buf); err != nil {
+		t.Fatal("WriteTo failed:", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 || !strings.Contains(lines[1], "-5ms (-50.0%)") || !strings.Contains(lines[3], "+5ms (+10.0%)") {
+		t.Fatalf("unexpected report:\n%s", buf.String())
+	}
+}
+
+func TestCompareAggregators(t *testing.T) {
+	var a, b Aggregator
+	for i := 1; i <= 4; i++ {
+		d := time.Duration(i) * time.Millisecond
+		a.Add(NewResultFromPhases(map[Phase]time.Duration{PhaseServer: 10 * d}))
+		b.Add(NewResultFromPhases(map[Phase]time.Duration{PhaseServer: 20 * d, PhaseTLS: d}))
+	}
+
+	c := CompareAggregators(&a, &b)
+	if len(c.Phases) != 2 || c.Phases[0].Phase != PhaseTLS {
+		t.Fatalf("expect the TLS and server phases, got %+v", c.Phases)
+	}
+	if got := c.Phases[1].P50.Percent(); got != 100 {
+		t.Errorf("expect the server median to double, got %v%%", got)
+	}
+	if got := c.Phases[0].Count; got != [2]int{0, 4} {
+		t.Errorf("TLS counts = %v", got)
+	}
+
+	var buf bytes.Buffer
+	if _, err := c.WriteTo(&buf); err != nil {
+		t.Fatal("WriteTo failed:", err)
+	}
+	if !strings.Contains(buf.String(), "+100.0%") {
+		t.Fatalf("expect the change of the median in:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,115 @@
+package httpstat
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http/httptrace"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// MeasureConnect establishes a connection to host like an HTTP client
+// would, but does not send a request. The returned Result holds the DNS
+// lookup, TCP connection and, with TLS, TLS handshake durations; Total is
+// the duration of the whole connection setup. host is a host with optional
+// port, which defaults to 443 with TLS and 80 without, or a URL like
+// "https://example.com", whose scheme tells whether TLS is used. Otherwise
+// TLS is used if tlsConfig is not nil. Without NextProtos in tlsConfig, the
+// handshake offers HTTP/2 and HTTP/1.1 like http.Transport does, so
+// Result.Protocol is set. The connection is closed before MeasureConnect
+// returns, so it does not speed up later requests.
+func MeasureConnect(ctx context.Context, host string, tlsConfig *tls.Config) (*Result, error) {
+	if strings.Contains(host, "://") {
+		u, err := url.Parse(host)
+		if err != nil {
+			return nil, err
+		}
+		host = u.Host
+		if u.Scheme == "http" {
+			tlsConfig = nil
+		} else if tlsConfig == nil {
+			tlsConfig = &tls.Config{}
+		}
+	}
+	if tlsConfig != nil && len(tlsConfig.NextProtos) == 0 {
+		tlsConfig = tlsConfig.Clone()
+		tlsConfig.NextProtos = []string{"h2", "http/1.1"}
+	}
+
+	r := &Result{}
+	conn, err := connect(WithHTTPStat(ctx, r), r, host, tlsConfig)
+	if err != nil {
+		return r, err
+	}
+	conn.Close()
+	if tlsConfig == nil {
+		r.Protocol = "http/1.1"
+	}
+	return r, nil
+}
+
+// Preconnect performs the connection setup of a request to host, i.e. the
+// DNS lookup, TCP connection and TLS handshake, without sending a request,
+// and returns the partial Result, like MeasureConnect. host is either a URL
+// like "https://example.com" or a host with optional port; TLS is used
+// unless the scheme is http or the port is 80. Preconnect only measures the
+// connection setup: the connection is closed, so it does not warm up the
+// connection pool of any http.Transport.
+func Preconnect(ctx context.Context, host string) (*Result, error) {
+	if !strings.Contains(host, "://") {
+		scheme := "https"
+		if _, port, err := net.SplitHostPort(host); err == nil && port == "80" {
+			scheme = "http"
+		}
+		host = scheme + "://" + host
+	}
+	return MeasureConnect(ctx, host, nil)
+}
+
+// connect dials host and performs the TLS handshake, reporting each phase to
+// the httptrace.ClientTrace of ctx.
+func connect(ctx context.Context, r *Result, host string, tlsConfig *tls.Config) (net.Conn, error) {
+	hostname, port, err := net.SplitHostPort(host)
+	if err != nil {
+		hostname, port = host, "80"
+		if tlsConfig != nil {
+			port = "443"
+		}
+	}
+	addr := net.JoinHostPort(hostname, port)
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if tlsConfig != nil {
+		if tlsConfig.ServerName == "" {
+			tlsConfig = tlsConfig.Clone()
+			tlsConfig.ServerName = hostname
+		}
+
+		trace := httptrace.ContextClientTrace(ctx)
+		if trace != nil && trace.TLSHandshakeStart != nil {
+			trace.TLSHandshakeStart()
+		}
+		tlsConn := tls.Client(conn, tlsConfig)
+		err := tlsConn.HandshakeContext(ctx)
+		if trace != nil && trace.TLSHandshakeDone != nil {
+			trace.TLSHandshakeDone(tlsConn.ConnectionState(), err)
+		}
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tlsConn
+	} else {
+		r.Pretransfer = r.Connect
+	}
+
+	r.total = time.Since(r.dnsStart)
+	return conn, nil
+}
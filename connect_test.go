@@ -0,0 +1,121 @@
+package httpstat
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMeasureConnect(t *testing.T) {
+	var requests int
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer ts.Close()
+
+	host := strings.TrimPrefix(ts.URL, "https://")
+	tlsConfig := ts.Client().Transport.(*http.Transport).TLSClientConfig
+
+	result, err := MeasureConnect(context.Background(), host, tlsConfig)
+	if err != nil {
+		t.Fatal("MeasureConnect failed:", err)
+	}
+
+	if result.TCPConnection <= 0 || result.TLSHandshake <= 0 {
+		t.Fatalf("expect TCPConnection and TLSHandshake to be non-zero: %+v", result)
+	}
+	if result.Pretransfer < result.Connect {
+		t.Fatalf("Pretransfer = %v, want at least Connect %v", result.Pretransfer, result.Connect)
+	}
+	if got, want := result.Total(), result.Pretransfer; got < want {
+		t.Fatalf("Total = %v, want at least %v", got, want)
+	}
+	if result.ServerProcessing != 0 {
+		t.Fatal("expect ServerProcessing to be zero")
+	}
+	if requests != 0 {
+		t.Fatalf("expect no request to be sent, got %d", requests)
+	}
+}
+
+func TestMeasureConnect_NoTLS(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+
+	result, err := MeasureConnect(context.Background(), strings.TrimPrefix(ts.URL, "http://"), nil)
+	if err != nil {
+		t.Fatal("MeasureConnect failed:", err)
+	}
+	if result.TLSHandshake != 0 {
+		t.Fatal("expect TLSHandshake to be zero")
+	}
+	if result.Pretransfer != result.Connect {
+		t.Fatalf("Pretransfer = %v, want %v", result.Pretransfer, result.Connect)
+	}
+}
+
+func TestMeasureConnect_Error(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	host := strings.TrimPrefix(ts.URL, "http://")
+	ts.Close()
+
+	if _, err := MeasureConnect(context.Background(), host, &tls.Config{}); err == nil {
+		t.Fatal("expect error when connecting to a closed server")
+	}
+}
+
+func TestMeasureConnect_URL(t *testing.T) {
+	ts := httptest.NewUnstartedServer(http.NotFoundHandler())
+	ts.EnableHTTP2 = true
+	ts.StartTLS()
+	defer ts.Close()
+
+	// The certificate of the test server is not trusted.
+	if _, err := MeasureConnect(context.Background(), ts.URL, nil); err == nil {
+		t.Fatal("expect the handshake to fail verification")
+	}
+
+	certs := x509.NewCertPool()
+	certs.AddCert(ts.Certificate())
+	result, err := MeasureConnect(context.Background(), ts.URL, &tls.Config{RootCAs: certs})
+	if err != nil {
+		t.Fatal("MeasureConnect failed:", err)
+	}
+	if result.TLSHandshake <= 0 || result.Protocol != "h2" {
+		t.Fatalf("expect HTTP/2 to be negotiated, got %+v", result)
+	}
+
+	result, err = MeasureConnect(context.Background(), strings.Replace(ts.URL, "https", "http", 1), &tls.Config{})
+	if err != nil {
+		t.Fatal("MeasureConnect failed:", err)
+	}
+	if result.TLSHandshake != 0 || result.TCPConnection <= 0 || result.Protocol != "http/1.1" {
+		t.Fatalf("expect a plain TCP connection, got %+v", result)
+	}
+}
+
+func TestPreconnect(t *testing.T) {
+	ts := httptest.NewTLSServer(http.NotFoundHandler())
+	defer ts.Close()
+
+	// TLS is used for https URLs and hosts without a scheme, and the
+	// certificate of the test server is not trusted.
+	for _, host := range []string{ts.URL, strings.TrimPrefix(ts.URL, "https://")} {
+		result, err := Preconnect(context.Background(), host)
+		if err == nil || result.TCPConnection <= 0 {
+			t.Fatalf("expect the handshake with %s to fail verification, got %v", host, err)
+		}
+	}
+
+	result, err := Preconnect(context.Background(), strings.Replace(ts.URL, "https", "http", 1))
+	if err != nil {
+		t.Fatal("Preconnect failed:", err)
+	}
+	if result.TLSHandshake != 0 || result.TCPConnection <= 0 || result.Protocol != "http/1.1" {
+		t.Fatalf("expect a plain TCP connection, got %+v", result)
+	}
+}
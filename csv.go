@@ -0,0 +1,120 @@
+package httpstat
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// csvHeader is the header of the CSV output. Durations are in milliseconds.
+var csvHeader = []string{
+	"time", "method", "url", "status_code", "error", "protocol",
+	"dns_lookup_ms", "tcp_connection_ms", "proxy_connect_ms", "tls_handshake_ms",
+	"continue_wait_ms", "server_processing_ms", "content_transfer_ms", "total_ms",
+	"body_length", "labels",
+}
+
+// CSVWriter is a Sink writing Records as CSV with a stable header, for
+// importing them into spreadsheets or data frames. The header is written
+// before the first row. Durations are written in milliseconds with
+// microsecond precision, and the labels of the Results as semicolon
+// separated key=value pairs.
+type CSVWriter struct {
+	mu     sync.Mutex
+	w      *csv.Writer
+	header bool
+}
+
+// NewCSVWriter returns a CSVWriter writing to w.
+func NewCSVWriter(w io.Writer) *CSVWriter {
+	return &CSVWriter{w: csv.NewWriter(w)}
+}
+
+// WriteRecord implements Sink. The row is flushed to the underlying writer
+// right away.
+func (c *CSVWriter) WriteRecord(rec Record) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.header {
+		c.header = true
+		c.w.Write(csvHeader)
+	}
+	c.w.Write(csvRow(rec))
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// WriteCSV writes results to w as CSV, like CSVWriter. The request columns
+// are filled from the Metadata of the Results, if recorded.
+func WriteCSV(w io.Writer, results []*Result) error {
+	c := NewCSVWriter(w)
+	for _, r := range results {
+		if err := c.WriteRecord(Record{Time: r.DNSStartAt(), Result: r}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func csvRow(rec Record) []string {
+	r := rec.Result
+	if r == nil {
+		r = &Result{}
+	}
+	method, url, status := rec.Method, rec.URL, rec.StatusCode
+	if m := r.Metadata; m != nil {
+		if method == "" {
+			method = m.Method
+		}
+		if url == "" {
+			url = m.URL
+		}
+		if status == 0 {
+			status = m.StatusCode
+		}
+	}
+
+	row := make([]string, 0, len(csvHeader))
+	var t, errMsg, statusCode string
+	if !rec.Time.IsZero() {
+		t = rec.Time.Format(time.RFC3339Nano)
+	}
+	if rec.Err != nil {
+		errMsg = rec.Err.Error()
+	}
+	if status != 0 {
+		statusCode = strconv.Itoa(status)
+	}
+	row = append(row, t, method, url, statusCode, errMsg, r.Protocol)
+
+	for p := range phaseNames {
+		row = append(row, csvDuration(r.Duration(Phase(p))))
+	}
+	// The transfer and total of a Result which was not ended are unknown.
+	if r.total == 0 {
+		row[len(row)-1] = ""
+		row = append(row, "")
+	} else {
+		row = append(row, csvDuration(r.total))
+	}
+	row = append(row, strconv.FormatInt(r.BodyLength, 10), csvLabels(r.labels))
+	return row
+}
+
+func csvDuration(d time.Duration) string {
+	return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64)
+}
+
+// csvLabels formats labels as key=value pairs, sorted by key.
+func csvLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ";")
+}
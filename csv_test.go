@@ -0,0 +1,65 @@
+package httpstat
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteCSV(t *testing.T) {
+	start := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	r := NewResultBuilder().
+		Start(start).
+		Phase(PhaseDNS, 1500*time.Microsecond).
+		Phase(PhaseServer, 20*time.Millisecond).
+		Phase(PhaseTransfer, 5*time.Millisecond).
+		Protocol("h2").
+		Build()
+	r.Metadata = &Metadata{Method: "GET", URL: "https://a.example/", StatusCode: 200}
+	r.SetLabel("tenant", "acme")
+	r.SetLabel("attempt", "1")
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, []*Result{r, {}}); err != nil {
+		t.Fatal("WriteCSV failed:", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal("reading CSV failed:", err)
+	}
+	if len(rows) != 3 {
+		t.Fatalf("expect a header and 2 rows, got %d rows", len(rows))
+	}
+	if got := strings.Join(rows[0], ","); got != strings.Join(csvHeader, ",") {
+		t.Fatalf("unexpected header %s", got)
+	}
+	want := "2026-10-01T12:00:00Z,GET,https://a.example/,200,,h2," +
+		"1.500,0.000,0.000,0.000,0.000,20.000,5.000,26.500,0,attempt=1;tenant=acme"
+	if got := strings.Join(rows[1], ","); got != want {
+		t.Fatalf("unexpected row\nwant: %s\ngot:  %s", want, got)
+	}
+	if got := rows[2][12] + rows[2][13]; got != "" {
+		t.Fatalf("expect no transfer and total for a Result which was not ended, got %q", got)
+	}
+}
+
+func TestCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+	c := NewCSVWriter(&buf)
+	for _, err := range []error{nil, errors.New("refused")} {
+		rec := Record{Time: time.Now(), Method: "POST", URL: "http://b.example/", Err: err, Result: &Result{}}
+		if err := c.WriteRecord(rec); err != nil {
+			t.Fatal("WriteRecord failed:", err)
+		}
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal("reading CSV failed:", err)
+	}
+	if len(rows) != 3 || rows[1][1] != "POST" || rows[2][4] != "refused" {
+		t.Fatalf("unexpected rows %q", rows)
+	}
+}
@@ -0,0 +1,149 @@
+package httpstat
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// phaseKeys are the keys of the phases in JSON output, matching the keys
+// of Result.MarshalJSON.
+var phaseKeys = [...]string{
+	PhaseDNS:          "dnsLookup",
+	PhaseConnect:      "tcpConnection",
+	PhaseProxyConnect: "proxyConnect",
+	PhaseTLS:          "tlsHandshake",
+	PhaseContinueWait: "continueWait",
+	PhaseServer:       "serverProcessing",
+	PhaseTransfer:     "contentTransfer",
+}
+
+// DebugHandler returns an http.Handler which renders the Records of rc and
+// the percentiles of their phases, similar to /debug/requests of
+// golang.org/x/net/trace. It renders HTML, or JSON if the request has the
+// query parameter format=json or accepts application/json. It is usually
+// mounted at /debug/httpstat.
+func DebugHandler(rc *Recorder) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		p := newDebugPage(rc)
+		if req.URL.Query().Get("format") == "json" || strings.Contains(req.Header.Get("Accept"), "application/json") {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(p.json())
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		debugTemplate.Execute(w, p)
+	})
+}
+
+type debugPage struct {
+	Count   uint64
+	Phases  []debugPhase
+	Total   Stats
+	Records []Record
+}
+
+type debugPhase struct {
+	Phase Phase
+	Stats
+}
+
+func newDebugPage(rc *Recorder) debugPage {
+	records := rc.Records()
+	var a Aggregator
+	for _, rec := range records {
+		if rec.Err == nil && rec.Result != nil {
+			a.Add(rec.Result)
+		}
+	}
+
+	p := debugPage{Count: rc.Count(), Total: a.Total()}
+	for i := range phaseNames {
+		if s := a.Phase(Phase(i)); s.Count > 0 {
+			p.Phases = append(p.Phases, debugPhase{Phase: Phase(i), Stats: s})
+		}
+	}
+	// Show the most recent requests first.
+	for i := len(records) - 1; i >= 0; i-- {
+		p.Records = append(p.Records, records[i])
+	}
+	return p
+}
+
+type jsonDebugPage struct {
+	Count   uint64           `json:"count"`
+	Phases  map[string]Stats `json:"phases"`
+	Total   Stats            `json:"total"`
+	Records []Record         `json:"records"`
+}
+
+func (p debugPage) json() jsonDebugPage {
+	j := jsonDebugPage{
+		Count:   p.Count,
+		Phases:  make(map[string]Stats),
+		Total:   p.Total,
+		Records: p.Records,
+	}
+	if j.Records == nil {
+		j.Records = []Record{}
+	}
+	for _, ph := range p.Phases {
+		j.Phases[phaseKeys[ph.Phase]] = ph.Stats
+	}
+	return j
+}
+
+var debugTemplate = template.Must(template.New("debug").Funcs(template.FuncMap{
+	"ms": func(d time.Duration) int64 {
+		return int64(d / time.Millisecond)
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<title>/debug/httpstat</title>
+<style>
+body { font-family: sans-serif; }
+table { border-collapse: collapse; }
+th, td { padding: 2px 8px; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+.err { color: #c00; }
+</style>
+</head>
+<body>
+<h1>/debug/httpstat</h1>
+<p>{{len .Records}} of {{.Count}} requests recorded.</p>
+
+<h2>Percentiles (ms)</h2>
+<table>
+<tr><th>Phase</th><th>Count</th><th>Min</th><th>p50</th><th>p90</th><th>p95</th><th>p99</th><th>Max</th></tr>
+{{- range .Phases}}
+<tr><td>{{.Phase}}</td><td>{{.Count}}</td><td>{{ms .Min}}</td><td>{{ms .P50}}</td><td>{{ms .P90}}</td><td>{{ms .P95}}</td><td>{{ms .P99}}</td><td>{{ms .Max}}</td></tr>
+{{- end}}
+{{- with .Total}}
+<tr><th>Total</th><th>{{.Count}}</th><th>{{ms .Min}}</th><th>{{ms .P50}}</th><th>{{ms .P90}}</th><th>{{ms .P95}}</th><th>{{ms .P99}}</th><th>{{ms .Max}}</th></tr>
+{{- end}}
+</table>
+
+<h2>Recent requests (ms)</h2>
+<table>
+<tr><th>Time</th><th>Request</th><th>Status</th><th>DNS</th><th>Connect</th><th>TLS</th><th>Server</th><th>Transfer</th><th>Total</th></tr>
+{{- range .Records}}
+<tr>
+<td>{{.Time.Format "2006-01-02 15:04:05.000"}}</td>
+<td>{{.Method}} {{.URL}}</td>
+{{- if .Err}}
+<td class="err" colspan="7">{{.Err}}</td>
+{{- else}}
+<td>{{.StatusCode}}</td>
+{{- with .Result}}
+<td>{{ms .DNSLookup}}</td><td>{{ms .TCPConnection}}</td><td>{{ms .TLSHandshake}}</td><td>{{ms .ServerProcessing}}</td><td>{{ms .ContentTransfer}}</td><td>{{ms .Total}}</td>
+{{- end}}
+{{- end}}
+</tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
@@ -0,0 +1,95 @@
+package httpstat
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newDebugRecorder() *Recorder {
+	rc := NewRecorder(10)
+	rc.Add(Record{
+		Time:       time.Now(),
+		Method:     "GET",
+		URL:        "https://example.com/slow",
+		StatusCode: http.StatusOK,
+		Result: NewResultFromPhases(map[Phase]time.Duration{
+			PhaseDNS:    20 * time.Millisecond,
+			PhaseServer: 300 * time.Millisecond,
+		}),
+	})
+	rc.Add(Record{
+		Time:   time.Now(),
+		Method: "POST",
+		URL:    "https://example.com/<fail>",
+		Err:    errors.New("connection refused"),
+		Result: &Result{},
+	})
+	return rc
+}
+
+func TestDebugHandler_HTML(t *testing.T) {
+	w := httptest.NewRecorder()
+	DebugHandler(newDebugRecorder()).ServeHTTP(w, httptest.NewRequest("GET", "/debug/httpstat", nil))
+
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/html") {
+		t.Fatalf("Content-Type = %q, want text/html", ct)
+	}
+	body := w.Body.String()
+	for _, want := range []string{
+		"GET https://example.com/slow",
+		"https://example.com/&lt;fail&gt;",
+		"connection refused",
+		"<td>Server processing</td><td>1</td><td>300</td>",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("expect page to contain %q", want)
+		}
+	}
+	if strings.Index(body, "fail&gt;") > strings.Index(body, "/slow</td>") {
+		t.Error("expect most recent request first")
+	}
+}
+
+func TestDebugHandler_JSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	DebugHandler(newDebugRecorder()).ServeHTTP(w, httptest.NewRequest("GET", "/debug/httpstat?format=json", nil))
+
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("Content-Type = %q, want application/json", ct)
+	}
+
+	var page struct {
+		Count   int
+		Phases  map[string]Stats
+		Total   Stats
+		Records []struct {
+			URL        string
+			StatusCode int
+			Error      string
+			Result     map[string]interface{}
+		}
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+		t.Fatal("Unmarshal failed:", err)
+	}
+	if page.Count != 2 || len(page.Records) != 2 {
+		t.Fatalf("unexpected page %+v", page)
+	}
+	if s := page.Phases["serverProcessing"]; s.Count != 1 || s.P99 != 300*time.Millisecond {
+		t.Fatalf("unexpected serverProcessing stats %+v", s)
+	}
+	if _, ok := page.Phases["tlsHandshake"]; ok {
+		t.Fatal("expect skipped phase to be omitted")
+	}
+	if page.Records[0].Error != "connection refused" {
+		t.Fatalf("expect failed request first, got %+v", page.Records[0])
+	}
+	if got := page.Records[1].Result["serverProcessing"]; got != float64(300*time.Millisecond) {
+		t.Fatalf("expect result of the request, got serverProcessing %v", got)
+	}
+}
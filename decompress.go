@@ -0,0 +1,63 @@
+package httpstat
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"time"
+)
+
+// gzipBody decompresses a gzip encoded response body, recording the time
+// spent decompressing and the compressed length on the Result.
+type gzipBody struct {
+	rc     io.ReadCloser
+	result *Result
+
+	zr  *gzip.Reader
+	err error
+
+	// net is the time spent reading rc during the current Read
+	net time.Duration
+}
+
+// decompress replaces the gzip encoded body of res with its decompressed
+// content, as http.Transport does when it asked for the encoding itself.
+func decompress(res *http.Response, r *Result) {
+	res.Body = &gzipBody{rc: res.Body, result: r}
+	res.Header.Del("Content-Encoding")
+	res.Header.Del("Content-Length")
+	res.ContentLength = -1
+	res.Uncompressed = true
+}
+
+func (b *gzipBody) Read(p []byte) (int, error) {
+	start := time.Now()
+	b.net = 0
+	if b.zr == nil && b.err == nil {
+		b.zr, b.err = gzip.NewReader(networkReader{b})
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	n, err := b.zr.Read(p)
+	b.result.Decompression += time.Since(start) - b.net
+	return n, err
+}
+
+func (b *gzipBody) Close() error {
+	return b.rc.Close()
+}
+
+// networkReader reads the compressed body, counting its length and the
+// time spent waiting for it.
+type networkReader struct {
+	b *gzipBody
+}
+
+func (nr networkReader) Read(p []byte) (int, error) {
+	start := time.Now()
+	n, err := nr.b.rc.Read(p)
+	nr.b.net += time.Since(start)
+	nr.b.result.CompressedLength += int64(n)
+	return n, err
+}
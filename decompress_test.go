@@ -0,0 +1,68 @@
+package httpstat
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTransport_Decompress(t *testing.T) {
+	content := strings.Repeat("httpstat ", 10000)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept-Encoding") != "gzip" {
+			io.WriteString(w, content)
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		io.WriteString(zw, content)
+		zw.Close()
+	}))
+	defer ts.Close()
+
+	var result *Result
+	client := &http.Client{Transport: &Transport{
+		Base:       DefaultTransport(),
+		Decompress: true,
+		OnResult: func(req *http.Request, r *Result, err error) {
+			result = r
+		},
+	}}
+	get := func(header string) string {
+		req, _ := http.NewRequest("GET", ts.URL, nil)
+		if header != "" {
+			req.Header.Set("Accept-Encoding", header)
+		}
+		res, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.Header.Get("Content-Encoding") != "" || req.Header.Get("Accept-Encoding") != header {
+			t.Fatalf("unexpected headers %v, %v", res.Header, req.Header)
+		}
+		return string(b)
+	}
+
+	if got := get(""); got != content {
+		t.Fatalf("expect the decompressed content, got %d bytes", len(got))
+	}
+	if result.BodyLength != int64(len(content)) || result.CompressedLength <= 0 || result.CompressedLength >= result.BodyLength {
+		t.Fatalf("unexpected lengths %d (compressed %d)", result.BodyLength, result.CompressedLength)
+	}
+	if result.Decompression <= 0 || result.Decompression > result.ContentTransfer() {
+		t.Fatalf("unexpected decompression time %v of %v", result.Decompression, result.ContentTransfer())
+	}
+
+	// Requests asking for an encoding themselves are left alone.
+	if got := get("identity"); got != content || result.Decompression != 0 || result.CompressedLength != 0 {
+		t.Fatalf("expect no decompression, got %v", result.Decompression)
+	}
+}
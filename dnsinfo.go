@@ -0,0 +1,359 @@
+package httpstat
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DNSInfo details the DNS lookup of a request resolved with a Resolver of
+// NewResolver, beyond the single duration httptrace reports.
+type DNSInfo struct {
+	// Queries are the queries sent for the lookup, usually one for A and
+	// one for AAAA records, in the order they were sent. Retries with other
+	// nameservers or search domains are separate queries.
+	Queries []DNSQuery
+}
+
+// DNSQuery is one DNS query and its response.
+type DNSQuery struct {
+	// Name and Type are the queried name and record type, e.g. "A" or
+	// "AAAA".
+	Name string
+	Type string
+
+	// Server is the address of the nameserver the query was sent to, and
+	// Network "udp" or "tcp".
+	Server  string
+	Network string
+
+	// Start is the time from the start of the request to sending the query,
+	// and Duration the time until the response arrived. Duration is zero if
+	// there was no response.
+	Start    time.Duration
+	Duration time.Duration
+
+	// RCode is the response code, e.g. 0 for NOERROR or 3 for NXDOMAIN.
+	RCode int
+
+	// Answers are the records in the answer section of the response.
+	Answers []DNSRecord
+}
+
+// DNSRecord is a resource record of a DNS response.
+type DNSRecord struct {
+	Name string
+	Type string
+	TTL  time.Duration
+
+	// Value is the address of A and AAAA records, the target of CNAME
+	// records and empty for other types.
+	Value string
+}
+
+// CNAMEs returns the chain of CNAME targets the queried name resolved
+// through, in order.
+func (i *DNSInfo) CNAMEs() []string {
+	var chain []string
+	seen := make(map[string]bool)
+	for _, q := range i.Queries {
+		for _, a := range q.Answers {
+			if a.Type == "CNAME" && !seen[a.Value] {
+				seen[a.Value] = true
+				chain = append(chain, a.Value)
+			}
+		}
+	}
+	return chain
+}
+
+// TTL returns the lowest TTL of the answers, for how long the lookup may
+// be cached, or zero if there were none.
+func (i *DNSInfo) TTL() time.Duration {
+	var ttl time.Duration
+	first := true
+	for _, q := range i.Queries {
+		for _, a := range q.Answers {
+			if first || a.TTL < ttl {
+				ttl, first = a.TTL, false
+			}
+		}
+	}
+	return ttl
+}
+
+// NewResolver returns a net.Resolver which looks up names like base, or
+// net.DefaultResolver if nil, and records the queries it sends and their
+// responses into the Result.DNSInfo of the context of each lookup. Set it
+// as the Resolver of the net.Dialer of the Transport sending the requests.
+// It always uses the pure Go resolver, which sends the DNS messages itself;
+// names found in the hosts file are not queried.
+func NewResolver(base *net.Resolver) *net.Resolver {
+	if base == nil {
+		base = net.DefaultResolver
+	}
+	dial := base.Dial
+	if dial == nil {
+		var d net.Dialer
+		dial = d.DialContext
+	}
+	return &net.Resolver{
+		PreferGo:     true,
+		StrictErrors: base.StrictErrors,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			conn, err := dial(ctx, network, address)
+			if err != nil {
+				return nil, err
+			}
+			r, ok := FromContext(ctx)
+			if !ok {
+				return conn, nil
+			}
+			// The Go resolver frames the messages by whether conn is a
+			// PacketConn.
+			if pc, ok := conn.(net.PacketConn); ok {
+				return &dnsPacketConn{dnsConn: &dnsConn{Conn: conn, r: r}, pc: pc}, nil
+			}
+			return &dnsConn{Conn: conn, r: r, stream: true}, nil
+		},
+	}
+}
+
+// dnsConn records the DNS messages exchanged over a connection to a
+// nameserver. On stream connections, each message is prefixed with its
+// length.
+type dnsConn struct {
+	net.Conn
+	r      *Result
+	stream bool
+
+	mu      sync.Mutex
+	queries map[uint16]int
+	sent    map[uint16]time.Time
+	wbuf    []byte
+	rbuf    []byte
+}
+
+// dnsPacketConn is a dnsConn over a PacketConn, which the Go resolver
+// uses with Read and Write.
+type dnsPacketConn struct {
+	*dnsConn
+	pc net.PacketConn
+}
+
+func (c *dnsPacketConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	return c.pc.ReadFrom(b)
+}
+
+func (c *dnsPacketConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	return c.pc.WriteTo(b, addr)
+}
+
+func (c *dnsConn) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	c.wbuf = c.messages(append(c.wbuf, b...), c.query)
+	c.mu.Unlock()
+	return c.Conn.Write(b)
+}
+
+func (c *dnsConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	if n > 0 {
+		c.mu.Lock()
+		c.rbuf = c.messages(append(c.rbuf, b[:n]...), c.response)
+		c.mu.Unlock()
+	}
+	return n, err
+}
+
+// messages calls f for each complete message at the start of buf and
+// returns the rest.
+func (c *dnsConn) messages(buf []byte, f func(msg []byte)) []byte {
+	if !c.stream {
+		f(buf)
+		return buf[:0]
+	}
+	for len(buf) >= 2 {
+		n := int(binary.BigEndian.Uint16(buf))
+		if len(buf) < 2+n {
+			break
+		}
+		f(buf[2 : 2+n])
+		buf = buf[2+n:]
+	}
+	return buf
+}
+
+func (c *dnsConn) query(msg []byte) {
+	m, err := parseDNSMessage(msg)
+	if err != nil || m.response {
+		return
+	}
+	q := DNSQuery{
+		Name:    m.name,
+		Type:    m.qtype,
+		Server:  c.RemoteAddr().String(),
+		Network: c.RemoteAddr().Network(),
+	}
+	now := time.Now()
+
+	// The A and AAAA queries are sent concurrently.
+	if !c.r.lockHook() {
+		return
+	}
+	defer c.r.unlock()
+	if c.r.DNSInfo == nil {
+		c.r.DNSInfo = &DNSInfo{}
+	}
+	if !c.r.dnsStart.IsZero() {
+		q.Start = now.Sub(c.r.dnsStart)
+	}
+	if c.queries == nil {
+		c.queries = make(map[uint16]int)
+		c.sent = make(map[uint16]time.Time)
+	}
+	c.queries[m.id] = len(c.r.DNSInfo.Queries)
+	c.sent[m.id] = now
+	c.r.DNSInfo.Queries = append(c.r.DNSInfo.Queries, q)
+}
+
+func (c *dnsConn) response(msg []byte) {
+	m, err := parseDNSMessage(msg)
+	if err != nil || !m.response {
+		return
+	}
+	i, ok := c.queries[m.id]
+	if !ok {
+		return
+	}
+	delete(c.queries, m.id)
+
+	if !c.r.lockHook() {
+		return
+	}
+	defer c.r.unlock()
+	q := &c.r.DNSInfo.Queries[i]
+	q.Duration = time.Since(c.sent[m.id])
+	q.RCode = m.rcode
+	q.Answers = m.answers
+}
+
+// dnsMessage is the part of a DNS message recorded in a DNSQuery.
+type dnsMessage struct {
+	id       uint16
+	response bool
+	rcode    int
+	name     string
+	qtype    string
+	answers  []DNSRecord
+}
+
+var errDNSMessage = errors.New("httpstat: malformed DNS message")
+
+// parseDNSMessage parses the header, the first question and the answers of
+// the DNS message msg.
+func parseDNSMessage(msg []byte) (dnsMessage, error) {
+	var m dnsMessage
+	if len(msg) < 12 {
+		return m, errDNSMessage
+	}
+	m.id = binary.BigEndian.Uint16(msg)
+	flags := binary.BigEndian.Uint16(msg[2:])
+	m.response = flags&0x8000 != 0
+	m.rcode = int(flags & 0xf)
+	qdcount := int(binary.BigEndian.Uint16(msg[4:]))
+	ancount := int(binary.BigEndian.Uint16(msg[6:]))
+
+	off := 12
+	for i := 0; i < qdcount; i++ {
+		name, n, err := parseDNSName(msg, off)
+		if err != nil || n+4 > len(msg) {
+			return m, errDNSMessage
+		}
+		if i == 0 {
+			m.name = name
+			m.qtype = dnsType(binary.BigEndian.Uint16(msg[n:]))
+		}
+		off = n + 4
+	}
+	for i := 0; i < ancount; i++ {
+		name, n, err := parseDNSName(msg, off)
+		if err != nil || n+10 > len(msg) {
+			return m, errDNSMessage
+		}
+		typ := binary.BigEndian.Uint16(msg[n:])
+		ttl := binary.BigEndian.Uint32(msg[n+4:])
+		rdlen := int(binary.BigEndian.Uint16(msg[n+8:]))
+		rdata := n + 10
+		if rdata+rdlen > len(msg) {
+			return m, errDNSMessage
+		}
+		rec := DNSRecord{Name: name, Type: dnsType(typ), TTL: time.Duration(ttl) * time.Second}
+		switch typ {
+		case 1, 28:
+			rec.Value = net.IP(msg[rdata : rdata+rdlen]).String()
+		case 5:
+			if rec.Value, _, err = parseDNSName(msg, rdata); err != nil {
+				return m, errDNSMessage
+			}
+		}
+		m.answers = append(m.answers, rec)
+		off = rdata + rdlen
+	}
+	return m, nil
+}
+
+// parseDNSName parses the possibly compressed name at off in msg, and
+// returns it and the offset after it.
+func parseDNSName(msg []byte, off int) (string, int, error) {
+	var labels []string
+	end := -1
+	// The number of pointers is limited, so loops end.
+	for ptrs := 0; ; {
+		if off >= len(msg) {
+			return "", 0, errDNSMessage
+		}
+		l := int(msg[off])
+		switch {
+		case l == 0:
+			if end < 0 {
+				end = off + 1
+			}
+			return strings.Join(labels, ".") + ".", end, nil
+		case l&0xc0 == 0xc0:
+			if off+1 >= len(msg) || ptrs > 16 {
+				return "", 0, errDNSMessage
+			}
+			if end < 0 {
+				end = off + 2
+			}
+			off = int(binary.BigEndian.Uint16(msg[off:]) & 0x3fff)
+			ptrs++
+		default:
+			if off+1+l > len(msg) {
+				return "", 0, errDNSMessage
+			}
+			labels = append(labels, string(msg[off+1:off+1+l]))
+			off += 1 + l
+		}
+	}
+}
+
+func dnsType(t uint16) string {
+	switch t {
+	case 1:
+		return "A"
+	case 5:
+		return "CNAME"
+	case 28:
+		return "AAAA"
+	case 65:
+		return "HTTPS"
+	}
+	return "TYPE" + strconv.Itoa(int(t))
+}
@@ -0,0 +1,121 @@
+package httpstat
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serveDNS answers the queries read from conn with dnsAnswer.
+func serveDNS(conn net.PacketConn) {
+	buf := make([]byte, 512)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		conn.WriteTo(dnsAnswer(buf[:n]), addr)
+	}
+}
+
+// dnsAnswer answers the A query q for any name with a CNAME to
+// backend.test. and its address 127.0.0.1, and other queries with no
+// records.
+func dnsAnswer(q []byte) []byte {
+	// The question ends with the type and class after the name.
+	end := 12
+	for q[end] != 0 {
+		end += int(q[end]) + 1
+	}
+	qtype := binary.BigEndian.Uint16(q[end+1:])
+	end += 5
+
+	res := append([]byte(nil), q[:end]...)
+	res[2] |= 0x80 // response
+	res[3] = 0x80  // recursion available, NOERROR
+	binary.BigEndian.PutUint16(res[6:], 0)
+	binary.BigEndian.PutUint16(res[8:], 0)
+	binary.BigEndian.PutUint16(res[10:], 0)
+	if qtype == 1 {
+		binary.BigEndian.PutUint16(res[6:], 2)
+		// CNAME with a pointer to the question name.
+		target := []byte("\x07backend\x04test\x00")
+		res = append(res, 0xc0, 12, 0, 5, 0, 1, 0, 0, 0, 60)
+		res = binary.BigEndian.AppendUint16(res, uint16(len(target)))
+		aName := len(res)
+		res = append(res, target...)
+		res = append(res, 0xc0|byte(aName>>8), byte(aName), 0, 1, 0, 1, 0, 0, 0, 30, 0, 4, 127, 0, 0, 1)
+	}
+	return res
+}
+
+func TestNewResolver(t *testing.T) {
+	dns, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("ListenPacket failed:", err)
+	}
+	defer dns.Close()
+	go serveDNS(dns)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer ts.Close()
+	_, port, _ := net.SplitHostPort(ts.Listener.Addr().String())
+
+	resolver := NewResolver(&net.Resolver{
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "udp", dns.LocalAddr().String())
+		},
+	})
+	transport := DefaultTransport()
+	transport.DialContext = (&net.Dialer{Resolver: resolver}).DialContext
+
+	var result Result
+	req := NewRequest(t, "http://api.test:"+port+"/", &result)
+	res, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		t.Fatal("request failed:", err)
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+
+	info := result.DNSInfo
+	if info == nil || len(info.Queries) != 2 {
+		t.Fatalf("expect an A and an AAAA query, got %+v", info)
+	}
+	var types []string
+	for _, q := range info.Queries {
+		types = append(types, q.Type)
+		if q.Name != "api.test." || q.Server != dns.LocalAddr().String() || q.Network != "udp" || q.Duration <= 0 {
+			t.Errorf("unexpected query %+v", q)
+		}
+	}
+	if got := strings.Join(types, ","); got != "A,AAAA" && got != "AAAA,A" {
+		t.Fatalf("unexpected query types %s", got)
+	}
+	if chain := info.CNAMEs(); len(chain) != 1 || chain[0] != "backend.test." {
+		t.Fatalf("unexpected CNAME chain %v", chain)
+	}
+	if info.TTL() != 30*time.Second {
+		t.Fatalf("expect the lowest TTL of 30s, got %v", info.TTL())
+	}
+}
+
+func TestParseDNSMessage_Malformed(t *testing.T) {
+	// A name pointing at itself.
+	msg := []byte{0, 1, 0x81, 0x80, 0, 1, 0, 0, 0, 0, 0, 0, 0xc0, 12, 0, 1, 0, 1}
+	if _, err := parseDNSMessage(msg); err == nil {
+		t.Fatal("expect a pointer loop to fail")
+	}
+	if _, err := parseDNSMessage(msg[:8]); err == nil {
+		t.Fatal("expect a short message to fail")
+	}
+}
@@ -0,0 +1,158 @@
+package httpstat
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// NewDoHResolver returns a net.Resolver which resolves names with DNS over
+// HTTPS (RFC 8484) by POSTing the queries to url, e.g.
+// "https://dns.google/dns-query", with client, or http.DefaultClient if
+// nil. The HTTPS exchange of each query is measured with its own Result,
+// which is added to the Result.DoHResults of the context of the lookup, so
+// the phases of the DoH queries can be told from the ones of the request
+// they resolved for. Wrap it with NewResolver to record the DNSInfo too.
+func NewDoHResolver(url string, client *http.Client) *net.Resolver {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			r, _ := FromContext(ctx)
+			return &dohConn{ctx: ctx, url: url, client: client, r: r}, nil
+		},
+	}
+}
+
+// dohAddr is the address of a DoH server.
+type dohAddr string
+
+func (a dohAddr) Network() string { return "https" }
+func (a dohAddr) String() string  { return string(a) }
+
+// dohConn sends each DNS message written to it as a DoH query and returns
+// the response on the following read. It is a PacketConn, so the Go
+// resolver does not prefix the messages with their length.
+type dohConn struct {
+	ctx    context.Context
+	url    string
+	client *http.Client
+	r      *Result
+
+	mu       sync.Mutex
+	deadline time.Time
+	response []byte
+	err      error
+	closed   bool
+}
+
+func (c *dohConn) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	deadline, closed := c.deadline, c.closed
+	c.mu.Unlock()
+	if closed {
+		return 0, net.ErrClosed
+	}
+
+	// The hooks of the lookup, such as the ones of the Result resolving
+	// the name, must not see the DoH request.
+	ctx := context.Context(untraced{c.ctx})
+	if !deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+	res, err := c.exchange(ctx, b)
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = os.ErrDeadlineExceeded
+	}
+
+	c.mu.Lock()
+	c.response, c.err = res, err
+	c.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// exchange POSTs the DNS message msg and returns the response.
+func (c *dohConn) exchange(ctx context.Context, msg []byte) ([]byte, error) {
+	result := &Result{}
+	if c.r != nil {
+		if c.r.lockHook() {
+			c.r.DoHResults = append(c.r.DoHResults, result)
+			c.r.unlock()
+		}
+	}
+
+	req, err := http.NewRequestWithContext(WithHTTPStat(ctx, result), "POST", c.url, bytes.NewReader(msg))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+	res, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	body := Body(res, result)
+	defer body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("httpstat: DoH server responded with %s", res.Status)
+	}
+	// DNS messages are at most 64 KiB.
+	return io.ReadAll(io.LimitReader(body, 1<<16))
+}
+
+func (c *dohConn) Read(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.err != nil {
+		return 0, c.err
+	}
+	if c.response == nil {
+		return 0, io.EOF
+	}
+	n := copy(b, c.response)
+	c.response = nil
+	return n, nil
+}
+
+func (c *dohConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	n, err := c.Read(b)
+	return n, c.RemoteAddr(), err
+}
+
+func (c *dohConn) WriteTo(b []byte, _ net.Addr) (int, error) {
+	return c.Write(b)
+}
+
+func (c *dohConn) Close() error {
+	c.mu.Lock()
+	c.closed = true
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *dohConn) LocalAddr() net.Addr  { return dohAddr("") }
+func (c *dohConn) RemoteAddr() net.Addr { return dohAddr(c.url) }
+
+func (c *dohConn) SetDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.deadline = t
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *dohConn) SetReadDeadline(t time.Time) error  { return nil }
+func (c *dohConn) SetWriteDeadline(t time.Time) error { return c.SetDeadline(t) }
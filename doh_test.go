@@ -0,0 +1,59 @@
+package httpstat
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewDoHResolver(t *testing.T) {
+	doh := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.Header.Get("Content-Type") != "application/dns-message" {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		q, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/dns-message")
+		w.Write(dnsAnswer(q))
+	}))
+	defer doh.Close()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer ts.Close()
+	_, port, _ := net.SplitHostPort(ts.Listener.Addr().String())
+
+	resolver := NewResolver(NewDoHResolver(doh.URL+"/dns-query", doh.Client()))
+	transport := DefaultTransport()
+	transport.DialContext = (&net.Dialer{Resolver: resolver}).DialContext
+
+	var result Result
+	req := NewRequest(t, "http://api.test:"+port+"/", &result)
+	res, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		t.Fatal("request failed:", err)
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+	result.EndNow()
+
+	if len(result.DoHResults) != 2 {
+		t.Fatalf("expect a DoH Result per query, got %d", len(result.DoHResults))
+	}
+	for i, r := range result.DoHResults {
+		if !r.IsComplete() || r.Total() <= 0 {
+			t.Errorf("expect DoH Result %d to be complete, got %+v", i, r)
+		}
+	}
+	if result.DNSInfo == nil || len(result.DNSInfo.Queries) != 2 || result.DNSInfo.Queries[0].Network != "https" {
+		t.Fatalf("expect the DoH queries in the DNSInfo, got %+v", result.DNSInfo)
+	}
+	// The DoH exchanges happen during the DNS lookup of the request, and
+	// are not mistaken for its own phases.
+	if result.TLSHandshake != 0 || result.DNSLookup <= 0 {
+		t.Fatalf("unexpected phases of the request %+v", result)
+	}
+}
@@ -0,0 +1,39 @@
+package httpstat
+
+// SetOfferedProtocols records the application protocols offered in the TLS
+// handshake, as in the NextProtos of the tls.Config, so the Result is
+// flagged as Downgraded if HTTP/2 was offered but not negotiated. It may be
+// called before or after the request.
+func (r *Result) SetOfferedProtocols(protos []string) {
+	// The hooks detect the downgrade as well, e.g. on the read loop of an
+	// HTTP/2 connection.
+	r.lock()
+	defer r.unlock()
+	r.offered = append(r.offered[:0], protos...)
+	r.detectDowngrade()
+}
+
+// detectDowngrade sets Downgraded if h2 was offered on a TLS connection,
+// and the server chose HTTP/1.1 or did not take part in ALPN, which means
+// HTTP/1.1 too. It must be called with r locked.
+func (r *Result) detectDowngrade() {
+	r.Downgraded = false
+	if !r.alpn || r.Protocol != "" && r.Protocol != "http/1.1" {
+		return
+	}
+	for _, p := range r.offered {
+		if p == "h2" {
+			r.Downgraded = true
+			return
+		}
+	}
+}
+
+// protocol returns the Protocol, which is HTTP/1.1 without one on
+// downgraded connections.
+func (r *Result) protocol() string {
+	if r.Protocol == "" && r.Downgraded {
+		return "http/1.1"
+	}
+	return r.Protocol
+}
@@ -0,0 +1,63 @@
+package httpstat
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTransport_Downgraded(t *testing.T) {
+	for _, h2 := range []bool{false, true} {
+		ts := httptest.NewUnstartedServer(http.NotFoundHandler())
+		ts.EnableHTTP2 = h2
+		ts.StartTLS()
+
+		base := ts.Client().Transport.(*http.Transport).Clone()
+		base.ForceAttemptHTTP2 = true
+		var result *Result
+		client := &http.Client{Transport: &Transport{
+			Base:     base,
+			OnResult: func(_ *http.Request, r *Result, _ error) { result = r },
+		}}
+		res, err := client.Get(ts.URL)
+		if err != nil {
+			t.Fatal("request failed:", err)
+		}
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+		ts.Close()
+
+		if result.Downgraded == h2 {
+			t.Errorf("server with HTTP/2 %v: Downgraded = %v, Protocol %q", h2, result.Downgraded, result.Protocol)
+		}
+		if got := fmt.Sprintf("%s", result); strings.Contains(got, "(downgraded)") == h2 {
+			t.Errorf("server with HTTP/2 %v: unexpected %q", h2, got)
+		}
+	}
+}
+
+func TestResult_SetOfferedProtocols(t *testing.T) {
+	cases := []struct {
+		protocol string
+		alpn     bool
+		offered  []string
+		want     bool
+	}{
+		{"http/1.1", true, []string{"h2", "http/1.1"}, true},
+		{"", true, []string{"h2", "http/1.1"}, true},
+		{"h2", true, []string{"h2", "http/1.1"}, false},
+		{"http/1.1", true, []string{"http/1.1"}, false},
+		// Plain HTTP/1.1 without TLS is not a downgrade.
+		{"http/1.1", false, []string{"h2", "http/1.1"}, false},
+	}
+	for _, c := range cases {
+		r := Result{Protocol: c.protocol, alpn: c.alpn}
+		r.SetOfferedProtocols(c.offered)
+		if r.Downgraded != c.want {
+			t.Errorf("%q offering %q: Downgraded = %v, want %v", c.protocol, c.offered, r.Downgraded, c.want)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package httpstat
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// binaryVersion is the first byte of the encoding of MarshalBinary, so the
+// format can be changed later.
+const binaryVersion = 1
+
+// MarshalText implements encoding.TextMarshaler. It encodes the protocol
+// and the durations of the phases which happened as comma-separated
+// key=value pairs with the keys of MarshalJSON, e.g.
+//
+//	protocol=h2,dnsLookup=2ms,tlsHandshake=5ms,serverProcessing=20ms,contentTransfer=3ms
+//
+// which is handy for fixtures and flags. The timestamps and other details
+// are left out; use MarshalBinary to keep them.
+func (r Result) MarshalText() ([]byte, error) {
+	var b []byte
+	if r.Protocol != "" {
+		b = append(b, "protocol="...)
+		b = append(b, r.Protocol...)
+	}
+	for i := range phaseKeys {
+		d := r.Duration(Phase(i))
+		if d <= 0 {
+			continue
+		}
+		if len(b) > 0 {
+			b = append(b, ',')
+		}
+		b = append(b, phaseKeys[i]...)
+		b = append(b, '=')
+		b = append(b, d.String()...)
+	}
+	return b, nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler. It parses the format
+// of MarshalText into a completed Result, as built by ResultBuilder, e.g. to
+// set a Result with flag.TextVar. Durations are parsed with
+// time.ParseDuration and missing phases are skipped.
+func (r *Result) UnmarshalText(text []byte) error {
+	b := NewResultBuilder()
+	s := strings.TrimSpace(string(text))
+	for s != "" {
+		var pair string
+		pair, s, _ = strings.Cut(s, ",")
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return fmt.Errorf("httpstat: invalid Result text %q: missing '='", pair)
+		}
+		if k == "protocol" {
+			b.Protocol(v)
+			continue
+		}
+		p, ok := phaseForKey(k)
+		if !ok {
+			return fmt.Errorf("httpstat: invalid Result text: unknown phase %q", k)
+		}
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("httpstat: invalid Result text: %w", err)
+		}
+		b.Phase(p, d)
+	}
+	*r = *b.Build()
+	return nil
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler, e.g. for gob. The
+// encoding is a version byte followed by the encoding of MarshalProto.
+func (r Result) MarshalBinary() ([]byte, error) {
+	return r.appendProto([]byte{binaryVersion}), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+func (r *Result) UnmarshalBinary(data []byte) error {
+	if len(data) == 0 {
+		return errors.New("httpstat: empty binary Result")
+	}
+	if data[0] != binaryVersion {
+		return fmt.Errorf("httpstat: unsupported binary Result version %d", data[0])
+	}
+	return r.UnmarshalProto(data[1:])
+}
+
+// phaseForKey returns the Phase with the JSON key k.
+func phaseForKey(k string) (Phase, bool) {
+	for i, key := range phaseKeys {
+		if key == k {
+			return Phase(i), true
+		}
+	}
+	return 0, false
+}
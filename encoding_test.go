@@ -0,0 +1,78 @@
+package httpstat
+
+import (
+	"bytes"
+	"encoding/gob"
+	"flag"
+	"testing"
+	"time"
+)
+
+func TestResult_MarshalText(t *testing.T) {
+	result := NewResultBuilder().
+		Phase(PhaseDNS, 2*time.Millisecond).
+		Phase(PhaseServer, 20*time.Millisecond).
+		Phase(PhaseTransfer, 1500*time.Microsecond).
+		Protocol("h2").
+		Build()
+	text, err := result.MarshalText()
+	if err != nil {
+		t.Fatal("MarshalText failed:", err)
+	}
+	want := "protocol=h2,dnsLookup=2ms,serverProcessing=20ms,contentTransfer=1.5ms"
+	if string(text) != want {
+		t.Fatalf("expect %q, got %q", want, text)
+	}
+
+	var got Result
+	if err := got.UnmarshalText(text); err != nil {
+		t.Fatal("UnmarshalText failed:", err)
+	}
+	if got.Protocol != "h2" || got.DNSLookup != 2*time.Millisecond || got.total != 23500*time.Microsecond {
+		t.Fatalf("unexpected Result %s", &got)
+	}
+
+	for _, bad := range []string{"dnsLookup", "nope=1ms", "dnsLookup=fast"} {
+		if err := got.UnmarshalText([]byte(bad)); err == nil {
+			t.Errorf("expect %q to fail", bad)
+		}
+	}
+}
+
+func TestResult_TextVar(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	var result Result
+	fs.TextVar(&result, "result", &Result{}, "")
+	if err := fs.Parse([]string{"-result", "tcpConnection=3ms, tlsHandshake=4ms"}); err != nil {
+		t.Fatal("Parse failed:", err)
+	}
+	if result.Pretransfer != 7*time.Millisecond {
+		t.Fatalf("unexpected Pretransfer %v", result.Pretransfer)
+	}
+}
+
+func TestResult_Gob(t *testing.T) {
+	result := NewResultBuilder().Start(time.Unix(1700000000, 0)).
+		Phase(PhaseTLS, 5*time.Millisecond).
+		Build()
+	result.SetLabel("env", "prod")
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(result); err != nil {
+		t.Fatal("Encode failed:", err)
+	}
+	var got Result
+	if err := gob.NewDecoder(&buf).Decode(&got); err != nil {
+		t.Fatal("Decode failed:", err)
+	}
+	if got.TLSHandshake != 5*time.Millisecond || !got.DNSStartAt().Equal(result.DNSStartAt()) {
+		t.Fatalf("unexpected Result %s", &got)
+	}
+	if v, _ := got.Label("env"); v != "prod" {
+		t.Fatalf("expect label to be kept, got %q", v)
+	}
+
+	if err := got.UnmarshalBinary([]byte{2}); err == nil {
+		t.Fatal("expect unknown version to fail")
+	}
+}
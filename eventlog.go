@@ -0,0 +1,140 @@
+package httpstat
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"time"
+)
+
+// Event is a point in the timeline of a request.
+type Event struct {
+	Time time.Time
+	Name string
+
+	// Duration is the duration of the phase ending with the event, or zero
+	// for events starting a phase.
+	Duration time.Duration
+}
+
+// Events returns the events of the request in chronological order. Phases
+// which were skipped (e.g. DNS lookup and connect of a reused connection)
+// have no events.
+func (r *Result) Events() []Event {
+	r = r.snapshot()
+	var events []Event
+	add := func(t time.Time, name string, d time.Duration) {
+		events = append(events, Event{Time: t, Name: name, Duration: d})
+	}
+	phase := func(start time.Time, d time.Duration, name string) {
+		if start.IsZero() || d <= 0 {
+			return
+		}
+		add(start, name+" start", 0)
+		add(start.Add(d), name+" done", d)
+	}
+
+	phase(r.dnsStart, r.DNSLookup, "DNS")
+	phase(r.tcpStart, r.TCPConnection, "Connect")
+	phase(r.tcpDone, r.ProxyConnect, "Proxy CONNECT")
+	phase(r.tlsStart, r.TLSHandshake, "TLS handshake")
+	phase(r.continueStart, r.ContinueWait, "100-continue wait")
+
+	for _, p := range r.customPhases {
+		phase(p.Start, p.Duration, p.Name)
+	}
+
+	if !r.serverStart.IsZero() {
+		add(r.serverStart, "Request written", 0)
+	}
+	if !r.serverDone.IsZero() {
+		add(r.serverDone, "First response byte", r.ServerProcessing)
+	}
+	if t := r.TrailersAt(); !t.IsZero() {
+		add(r.lastByte, "Last body byte", 0)
+		add(t, "Trailers received", r.TrailerWait)
+	}
+	if r.total > 0 {
+		end := r.dnsStart.Add(r.total)
+		add(end, "Content transfer done", r.contentTransfer)
+		add(end, "Request done", r.total)
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+	return events
+}
+
+// EventLog is a human-readable chronological log of the events of one or
+// more requests, formatted for pasting into incident timelines. Durations
+// are printed in the unit set on the Result with SetUnit, like Format:
+//
+//	12:00:01.123 DNS start
+//	12:00:01.146 DNS done (23 ms)
+type EventLog struct {
+	// Layout is the time.Format layout of the event times. If empty,
+	// "15:04:05.000" is used.
+	Layout string
+
+	events []logEvent
+}
+
+type logEvent struct {
+	Event
+	label string
+	unit  Unit
+}
+
+// Add adds the events of r to the log. If label is not empty, it prefixes
+// each of the events, which tells requests apart in a scenario.
+func (l *EventLog) Add(label string, r *Result) {
+	r = r.snapshot()
+	for _, e := range r.Events() {
+		l.events = append(l.events, logEvent{Event: e, label: label, unit: r.unit})
+	}
+}
+
+// WriteTo writes the events of all added requests to w in chronological
+// order.
+func (l *EventLog) WriteTo(w io.Writer) (int64, error) {
+	layout := l.Layout
+	if layout == "" {
+		layout = "15:04:05.000"
+	}
+
+	events := make([]logEvent, len(l.events))
+	copy(events, l.events)
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+
+	cw := &countWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	var line []byte
+	for _, e := range events {
+		line = e.Time.AppendFormat(line[:0], layout)
+		if e.label != "" {
+			line = append(append(append(line, " ["...), e.label...), ']')
+		}
+		line = append(append(line, ' '), e.Name...)
+		if e.Duration > 0 {
+			line = appendDuration(append(line, " ("...), e.unit, e.Duration, true, 0)
+			line = append(line, ')')
+		}
+		bw.Write(append(line, '\n'))
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,127 @@
+package httpstat
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func testResult(t0 time.Time) *Result {
+	ms := time.Millisecond
+	return &Result{
+		DNSLookup:        23 * ms,
+		TCPConnection:    10 * ms,
+		TLSHandshake:     30 * ms,
+		ServerProcessing: 40 * ms,
+		contentTransfer:  5 * ms,
+
+		NameLookup:    23 * ms,
+		Connect:       33 * ms,
+		Pretransfer:   63 * ms,
+		StartTransfer: 103 * ms,
+		total:         108 * ms,
+
+		dnsStart:      t0,
+		tcpStart:      t0.Add(23 * ms),
+		tlsStart:      t0.Add(33 * ms),
+		serverStart:   t0.Add(63 * ms),
+		serverDone:    t0.Add(103 * ms),
+		transferStart: t0.Add(103 * ms),
+	}
+}
+
+func TestEventLog(t *testing.T) {
+	t0 := time.Date(2017, 1, 1, 12, 0, 1, 123*int(time.Millisecond), time.UTC)
+
+	var l EventLog
+	l.Add("", testResult(t0))
+
+	want := `12:00:01.123 DNS start
+12:00:01.146 DNS done (23 ms)
+12:00:01.146 Connect start
+12:00:01.156 Connect done (10 ms)
+12:00:01.156 TLS handshake start
+12:00:01.186 TLS handshake done (30 ms)
+12:00:01.186 Request written
+12:00:01.226 First response byte (40 ms)
+12:00:01.231 Content transfer done (5 ms)
+12:00:01.231 Request done (108 ms)
+`
+	var buf bytes.Buffer
+	n, err := l.WriteTo(&buf)
+	if err != nil {
+		t.Fatal("WriteTo failed:", err)
+	}
+	if got := buf.String(); got != want {
+		t.Fatalf("expect to be eq:\n\nwant:\n\n%s\ngot:\n\n%s\n", want, got)
+	}
+	if n != int64(buf.Len()) {
+		t.Fatalf("WriteTo = %d, want %d", n, buf.Len())
+	}
+}
+
+func TestEventLog_Scenario(t *testing.T) {
+	t0 := time.Date(2017, 1, 1, 12, 0, 1, 0, time.UTC)
+
+	reused := &Result{
+		ServerProcessing: 20 * time.Millisecond,
+		total:            25 * time.Millisecond,
+		dnsStart:         t0.Add(50 * time.Millisecond),
+		serverStart:      t0.Add(50 * time.Millisecond),
+		serverDone:       t0.Add(70 * time.Millisecond),
+	}
+
+	l := EventLog{Layout: "05.000"}
+	l.Add("login", testResult(t0))
+	l.Add("profile", reused)
+
+	want := `01.000 [login] DNS start
+01.023 [login] DNS done (23 ms)
+01.023 [login] Connect start
+01.033 [login] Connect done (10 ms)
+01.033 [login] TLS handshake start
+01.050 [profile] Request written
+01.063 [login] TLS handshake done (30 ms)
+01.063 [login] Request written
+01.070 [profile] First response byte (20 ms)
+01.075 [profile] Content transfer done
+01.075 [profile] Request done (25 ms)
+01.103 [login] First response byte (40 ms)
+01.108 [login] Content transfer done (5 ms)
+01.108 [login] Request done (108 ms)
+`
+	var buf bytes.Buffer
+	if _, err := l.WriteTo(&buf); err != nil {
+		t.Fatal("WriteTo failed:", err)
+	}
+	if got := buf.String(); got != want {
+		t.Fatalf("expect to be eq:\n\nwant:\n\n%s\ngot:\n\n%s\n", want, got)
+	}
+}
+
+func TestEventLog_Unit(t *testing.T) {
+	t0 := time.Date(2017, 1, 1, 12, 0, 1, 0, time.UTC)
+	r := &Result{
+		DNSLookup:        800 * time.Microsecond,
+		ServerProcessing: 20 * time.Millisecond,
+		dnsStart:         t0,
+		serverStart:      t0.Add(time.Millisecond),
+		serverDone:       t0.Add(21 * time.Millisecond),
+	}
+	r.SetUnit(UnitAuto)
+
+	l := EventLog{Layout: "05.000000"}
+	l.Add("", r)
+	want := `01.000000 DNS start
+01.000800 DNS done (800µs)
+01.001000 Request written
+01.021000 First response byte (20ms)
+`
+	var buf bytes.Buffer
+	if _, err := l.WriteTo(&buf); err != nil {
+		t.Fatal("WriteTo failed:", err)
+	}
+	if got := buf.String(); got != want {
+		t.Fatalf("expect to be eq:\n\nwant:\n\n%s\ngot:\n\n%s\n", want, got)
+	}
+}
@@ -0,0 +1,106 @@
+package httpstat
+
+import (
+	"time"
+)
+
+// IsComplete reports whether the request completed, i.e. End was called and
+// the request did not fail.
+func (r *Result) IsComplete() bool {
+	r.lock()
+	defer r.unlock()
+	return r.complete()
+}
+
+func (r *Result) complete() bool {
+	return r.total > 0 && !r.failed
+}
+
+// FailedPhase returns the phase in which the request failed, was canceled
+// or timed out, e.g. PhaseTLS if the TLS handshake did not complete. It
+// returns false if the request completed. It must be called once the
+// request returned: a Result which was not ended counts as failed in the
+// phase which was in progress.
+func (r *Result) FailedPhase() (Phase, bool) {
+	r.lock()
+	defer r.unlock()
+	if r.complete() {
+		return 0, false
+	}
+	if r.failed {
+		return r.failedPhase, true
+	}
+	return r.inProgress(), true
+}
+
+// elapsed returns the duration of the request once it ended, or until it
+// failed if it was aborted. Unlike Total it never measures until now, so it
+// returns zero while the request is in progress or if it failed before any
+// phase started.
+func (r *Result) elapsed() time.Duration {
+	r.lock()
+	defer r.unlock()
+	switch {
+	case r.total > 0:
+		return r.total
+	case r.failed && !r.failedAt.IsZero() && !r.dnsStart.IsZero():
+		return r.failedAt.Sub(r.dnsStart)
+	}
+	return 0
+}
+
+// failTime returns the time the request failed, or the zero time if it did
+// not fail.
+func (r *Result) failTime() time.Time {
+	r.lock()
+	defer r.unlock()
+	if !r.failed {
+		return time.Time{}
+	}
+	return r.failedAt
+}
+
+// abort records that the round trip of the request returned an error, so
+// the phase in progress failed unless the request ended already, and stops
+// recording: the hooks of dials which are still running are ignored.
+func (r *Result) abort() {
+	r.lock()
+	defer r.unlock()
+	if !r.complete() {
+		r.fail(r.inProgress())
+	}
+	r.done = true
+}
+
+// fail records that the request failed in phase p, unless it already failed
+// in an earlier phase. It must be called with r locked.
+func (r *Result) fail(p Phase) {
+	if r.failed {
+		return
+	}
+	r.failed = true
+	r.failedPhase = p
+	r.failedAt = time.Now()
+	r.stream.release()
+}
+
+// inProgress returns the phase which started last and did not complete. It
+// must be called with r locked.
+func (r *Result) inProgress() Phase {
+	switch {
+	case !r.serverDone.IsZero():
+		return PhaseTransfer
+	case !r.continueStart.IsZero() && r.ContinueWait == 0:
+		return PhaseContinueWait
+	case !r.serverStart.IsZero():
+		return PhaseServer
+	case !r.tlsStart.IsZero() && r.TLSHandshake == 0:
+		return PhaseTLS
+	case !r.tlsStart.IsZero() || (!r.tcpDone.IsZero() && !r.isTLS):
+		// The connection is set up, the request is being written.
+		return PhaseServer
+	case !r.tcpStart.IsZero():
+		return PhaseConnect
+	}
+	return PhaseDNS
+}
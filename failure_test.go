@@ -0,0 +1,146 @@
+package httpstat
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// failedPhase sends a request to url through a Transport based on base and
+// returns the failed phase of its Result.
+func failedPhase(t *testing.T, ctx context.Context, base *http.Transport, url string) (*Result, Phase, bool) {
+	t.Helper()
+	var result *Result
+	client := &http.Client{Transport: &Transport{
+		Base: base,
+		OnResult: func(_ *http.Request, r *Result, _ error) {
+			result = r
+		},
+	}}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		t.Fatal("NewRequest failed:", err)
+	}
+	if res, err := client.Do(req); err == nil {
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+	}
+	p, failed := result.FailedPhase()
+	return result, p, failed
+}
+
+func TestResult_FailedPhase(t *testing.T) {
+	// A server which accepts connections but never completes a handshake.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Listen failed:", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer slow.Close()
+
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	cases := []struct {
+		name string
+		base *http.Transport
+		url  string
+		want Phase
+	}{
+		{"connect", DefaultTransport(), closed.URL, PhaseConnect},
+		{"tls", &http.Transport{TLSHandshakeTimeout: 50 * time.Millisecond}, "https://" + ln.Addr().String(), PhaseTLS},
+		{"server", DefaultTransport(), slow.URL, PhaseServer},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+			defer cancel()
+
+			r, p, failed := failedPhase(t, ctx, c.base, c.url)
+			if !failed || p != c.want {
+				t.Fatalf("FailedPhase = %v, %v, want %v", p, failed, c.want)
+			}
+			if r.IsComplete() {
+				t.Fatal("expect failed request not to be complete")
+			}
+		})
+	}
+}
+
+func TestResult_FailedPhaseComplete(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer ts.Close()
+
+	r, _, failed := failedPhase(t, context.Background(), DefaultTransport(), ts.URL)
+	if failed || !r.IsComplete() {
+		t.Fatal("expect request to complete")
+	}
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal("json.Marshal failed:", err)
+	}
+	if strings.Contains(string(b), "failedPhase") {
+		t.Fatalf("expect no failedPhase in JSON of a complete request, got %s", b)
+	}
+}
+
+func TestResult_FailedPhaseNotEnded(t *testing.T) {
+	r := &Result{}
+	r.dnsStart = time.Now()
+	r.tcpStart = r.dnsStart
+	r.tcpDone = r.dnsStart
+	r.serverStart = r.dnsStart
+	r.serverDone = r.dnsStart
+	if p, failed := r.FailedPhase(); !failed || p != PhaseTransfer {
+		t.Fatalf("FailedPhase = %v, %v, want %v", p, failed, PhaseTransfer)
+	}
+
+	b, _ := json.Marshal(r)
+	if !strings.Contains(string(b), `"failedPhase":"Content transfer"`) {
+		t.Fatalf("expect failedPhase in JSON, got %s", b)
+	}
+}
+
+func TestResult_HooksAfterFailure(t *testing.T) {
+	// The hooks of a dial which is still running once the request failed
+	// do not change the delivered Result.
+	var r Result
+	trace := r.clientTrace()
+	trace.ConnectStart("tcp", "127.0.0.1:1")
+	r.abort()
+	trace.ConnectDone("tcp", "127.0.0.1:1", nil)
+	trace.TLSHandshakeStart()
+
+	if p, failed := r.FailedPhase(); !failed || p != PhaseConnect {
+		t.Fatalf("FailedPhase = %v, %v, want %v", p, failed, PhaseConnect)
+	}
+	if r.TCPConnection != 0 || !r.tlsStart.IsZero() {
+		t.Fatalf("expect no phases recorded after the failure, got %+v", r)
+	}
+}
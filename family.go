@@ -0,0 +1,104 @@
+package httpstat
+
+import (
+	"net"
+	"time"
+)
+
+// ConnectAttempt is an attempt to connect to one address of the host.
+type ConnectAttempt struct {
+	// Network is e.g. "tcp", and Addr the address, e.g. "[2001:db8::1]:443".
+	Network string
+	Addr    string
+
+	// Start is the time the attempt started, and Duration the time it
+	// took. Duration is zero while the attempt is in progress.
+	Start    time.Time
+	Duration time.Duration
+
+	// Err is the error of a failed or abandoned attempt.
+	Err error
+}
+
+// ConnectAttempts returns the attempts to connect, in the order they
+// started. There are several if an address could not be connected to, or
+// if an IPv6 and an IPv4 address were tried in parallel (RFC 6555);
+// losing attempts may still be in progress.
+func (r *Result) ConnectAttempts() []ConnectAttempt {
+	r.lock()
+	defer r.unlock()
+	return append([]ConnectAttempt(nil), r.connectAttempts...)
+}
+
+// AddressFamily returns "IPv4" or "IPv6", the address family of the
+// connection, or "" if it is unknown, e.g. for reused connections.
+func (r *Result) AddressFamily() string {
+	r.lock()
+	defer r.unlock()
+	if i := r.winningAttempt(); i >= 0 {
+		return addressFamily(r.connectAttempts[i].Addr)
+	}
+	return ""
+}
+
+// Fallback reports whether the connection was established to another
+// address after the first one failed or was too slow, e.g. falling back
+// from IPv6 to IPv4 on a host with broken IPv6, and how long the first
+// attempt had taken by the time the connection was established.
+func (r *Result) Fallback() (time.Duration, bool) {
+	r.lock()
+	defer r.unlock()
+	i := r.winningAttempt()
+	if i <= 0 {
+		return 0, false
+	}
+	first, won := r.connectAttempts[0], r.connectAttempts[i]
+	if first.Duration > 0 {
+		return first.Duration, true
+	}
+	return won.Start.Add(won.Duration).Sub(first.Start), true
+}
+
+// winningAttempt returns the index of the attempt which connected, or -1.
+// It must be called with r locked.
+func (r *Result) winningAttempt() int {
+	if !r.connected {
+		return -1
+	}
+	for i, a := range r.connectAttempts {
+		if a.Err == nil && a.Duration > 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// endConnectAttempt ends the attempt to connect to addr at t and returns
+// it, or nil if there is none. It must be called with r locked.
+func (r *Result) endConnectAttempt(network, addr string, t time.Time, err error) *ConnectAttempt {
+	for i := range r.connectAttempts {
+		a := &r.connectAttempts[i]
+		if a.Network == network && a.Addr == addr && a.Duration == 0 {
+			a.Duration = t.Sub(a.Start)
+			a.Err = err
+			return a
+		}
+	}
+	return nil
+}
+
+func addressFamily(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	ip := net.ParseIP(host)
+	switch {
+	case ip == nil:
+		return ""
+	case ip.To4() != nil:
+		return "IPv4"
+	default:
+		return "IPv6"
+	}
+}
@@ -0,0 +1,74 @@
+package httpstat
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResult_Fallback(t *testing.T) {
+	var r Result
+	r.onConnectStart("tcp", "[2001:db8::1]:443")
+	time.Sleep(5 * time.Millisecond)
+	r.onConnectStart("tcp", "192.0.2.1:443")
+	r.onConnectDone("tcp", "192.0.2.1:443", nil)
+	// The abandoned IPv6 attempt ends after the connection was established.
+	r.onConnectDone("tcp", "[2001:db8::1]:443", errors.New("operation was canceled"))
+
+	if got := r.AddressFamily(); got != "IPv4" {
+		t.Errorf("AddressFamily = %q, want IPv4", got)
+	}
+	lost, ok := r.Fallback()
+	if !ok || lost < 5*time.Millisecond {
+		t.Errorf("Fallback = %v, %v, want at least 5ms", lost, ok)
+	}
+	if r.failed {
+		t.Error("expect the abandoned attempt not to fail the request")
+	}
+	if attempts := r.ConnectAttempts(); len(attempts) != 2 || attempts[0].Err == nil || attempts[1].Err != nil {
+		t.Errorf("ConnectAttempts = %+v", attempts)
+	}
+	if r.TCPConnection <= 0 || r.TCPConnection > lost {
+		t.Errorf("TCPConnection = %v, want the IPv4 attempt only", r.TCPConnection)
+	}
+
+	if got := fmt.Sprintf("%s", r); !strings.Contains(got, ", Fallback: IPv4 (first attempt ") {
+		t.Errorf("expect the fallback in %q", got)
+	}
+	if got := fmt.Sprintf("%+v", r); !strings.Contains(got, "Address family: IPv4 (fallback, first attempt took ") {
+		t.Errorf("expect the fallback in:\n%s", got)
+	}
+}
+
+func TestResult_FallbackFailed(t *testing.T) {
+	var r Result
+	r.onConnectStart("tcp", "[2001:db8::1]:443")
+	r.onConnectDone("tcp", "[2001:db8::1]:443", errors.New("no route to host"))
+	r.onConnectStart("tcp", "192.0.2.1:443")
+	r.onConnectDone("tcp", "192.0.2.1:443", nil)
+
+	if r.failed {
+		t.Error("expect the connect to succeed")
+	}
+	if _, ok := r.Fallback(); !ok || r.AddressFamily() != "IPv4" {
+		t.Errorf("expect a fallback to IPv4, got %q", r.AddressFamily())
+	}
+}
+
+func TestResult_NoFallback(t *testing.T) {
+	var r Result
+	r.onConnectStart("tcp", "[2001:db8::1]:443")
+	r.onConnectDone("tcp", "[2001:db8::1]:443", nil)
+
+	if got := r.AddressFamily(); got != "IPv6" {
+		t.Errorf("AddressFamily = %q, want IPv6", got)
+	}
+	if _, ok := r.Fallback(); ok {
+		t.Error("expect no fallback")
+	}
+	if got := fmt.Sprintf("%s", r); strings.Contains(got, "Fallback") {
+		t.Errorf("expect no fallback in %q", got)
+	}
+}
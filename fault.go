@@ -0,0 +1,243 @@
+package httpstat
+
+import (
+	"context"
+	"crypto/tls"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Fault is an artificial delay and error injected into a phase of a
+// request.
+type Fault struct {
+	// Delay is added to the duration of the phase.
+	Delay time.Duration
+
+	// Err, if not nil, makes the phase fail with Err once Delay passed.
+	Err error
+
+	// Probability is the chance between 0 and 1 that the fault is injected
+	// into a phase. If zero, the fault is always injected.
+	Probability float64
+}
+
+func (f *Fault) inject() bool {
+	if f.Delay <= 0 && f.Err == nil {
+		return false
+	}
+	return f.Probability <= 0 || rand.Float64() < f.Probability
+}
+
+// wait sleeps for the delay of the fault, or until ctx is done.
+func (f *Fault) wait(ctx context.Context) error {
+	if f.Delay > 0 {
+		t := time.NewTimer(f.Delay)
+		defer t.Stop()
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return f.Err
+}
+
+// FaultTransport is an http.RoundTripper which injects faults into the
+// phases of the requests it sends, for testing timeout and retry
+// configurations. Faults happen inside the phases they are injected into,
+// so a Result measuring the request (e.g. through Transport) records the
+// injected delays as part of the real durations.
+//
+// DNS, connect and TLS faults only affect requests which open a new
+// connection; set DisableKeepAlives on Base to inject them into every
+// request. A server fault holds up the reading of the response, which for
+// HTTP/2 delays all streams of the connection.
+type FaultTransport struct {
+	// Base is the transport whose configuration is used to send the
+	// requests. It is cloned on first use, and its DialContext is replaced
+	// by Dialer. If nil, http.DefaultTransport is used.
+	Base *http.Transport
+
+	// Dialer is used to dial connections. If nil, a net.Dialer with a 30
+	// second timeout is used.
+	Dialer *net.Dialer
+
+	// DNS, Connect, TLS and Server are injected into the DNS lookup, TCP
+	// connect, TLS handshake and server processing phases respectively.
+	DNS     Fault
+	Connect Fault
+	TLS     Fault
+	Server  Fault
+
+	once      sync.Once
+	transport *http.Transport
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *FaultTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.once.Do(t.init)
+
+	if f := t.Server; f.inject() {
+		var conn net.Conn
+		ctx := httptrace.WithClientTrace(req.Context(), &httptrace.ClientTrace{
+			GotConn: func(i httptrace.GotConnInfo) {
+				conn = i.Conn
+			},
+			WroteRequest: func(httptrace.WroteRequestInfo) {
+				if tc, ok := conn.(*tls.Conn); ok {
+					conn = tc.NetConn()
+				}
+				if fc, ok := conn.(*faultConn); ok {
+					fc.setPending(req.Context(), &f)
+				}
+			},
+		})
+		req = req.WithContext(ctx)
+	}
+	return t.transport.RoundTrip(req)
+}
+
+func (t *FaultTransport) init() {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport.(*http.Transport)
+	}
+	t.transport = base.Clone()
+	t.transport.DialContext = t.dial
+}
+
+func (t *FaultTransport) dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	d := net.Dialer{Timeout: 30 * time.Second}
+	if t.Dialer != nil {
+		d = *t.Dialer
+	}
+	if f := t.Connect; f.inject() {
+		control := d.ControlContext
+		d.ControlContext = func(ctx context.Context, network, address string, c syscall.RawConn) error {
+			if control != nil {
+				if err := control(ctx, network, address, c); err != nil {
+					return err
+				}
+			}
+			return f.wait(ctx)
+		}
+	}
+
+	addrs := []string{addr}
+	if host, port, err := net.SplitHostPort(addr); err == nil && net.ParseIP(host) == nil {
+		if f := t.DNS; f.inject() {
+			ips, err := lookup(ctx, host, &f)
+			if err != nil {
+				return nil, err
+			}
+			addrs = addrs[:0]
+			for _, ip := range ips {
+				addrs = append(addrs, net.JoinHostPort(ip.String(), port))
+			}
+		}
+	}
+
+	var err error
+	for _, a := range addrs {
+		var conn net.Conn
+		conn, err = d.DialContext(ctx, network, a)
+		if err == nil {
+			fc := &faultConn{Conn: conn}
+			if f := t.TLS; f.inject() {
+				// The transport runs the TLS handshake with the context
+				// of the dial.
+				fc.ctx, fc.handshake = ctx, &f
+			}
+			return fc, nil
+		}
+	}
+	return nil, err
+}
+
+// lookup resolves host with fault f injected, reporting the lookup to the
+// httptrace.ClientTrace of ctx.
+func lookup(ctx context.Context, host string, f *Fault) ([]net.IPAddr, error) {
+	trace := httptrace.ContextClientTrace(ctx)
+	if trace != nil && trace.DNSStart != nil {
+		trace.DNSStart(httptrace.DNSStartInfo{Host: host})
+	}
+
+	err := f.wait(ctx)
+	var addrs []net.IPAddr
+	if err == nil {
+		// The lookup itself must not report to the trace again.
+		addrs, err = net.DefaultResolver.LookupIPAddr(untraced{ctx}, host)
+	}
+
+	if trace != nil && trace.DNSDone != nil {
+		trace.DNSDone(httptrace.DNSDoneInfo{Addrs: addrs, Err: err})
+	}
+	return addrs, err
+}
+
+// untraced hides the values of a context, including its
+// httptrace.ClientTrace, while keeping its deadline and cancellation.
+type untraced struct {
+	context.Context
+}
+
+func (untraced) Value(interface{}) interface{} {
+	return nil
+}
+
+// faultConn injects TLS faults into the reading of the server's handshake
+// messages, and server faults into the reading of responses.
+type faultConn struct {
+	net.Conn
+
+	mu        sync.Mutex
+	ctx       context.Context
+	handshake *Fault
+	pending   *Fault
+}
+
+// setPending injects f into the next read, which waits on ctx.
+func (c *faultConn) setPending(ctx context.Context, f *Fault) {
+	c.mu.Lock()
+	c.ctx, c.pending = ctx, f
+	c.mu.Unlock()
+}
+
+// recordTypeHandshake is the type of the TLS record sending a ClientHello.
+const recordTypeHandshake = 0x16
+
+func (c *faultConn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	if c.handshake != nil && len(p) > 0 && p[0] == recordTypeHandshake {
+		// The ClientHello went out, so the server's reply is read as part
+		// of the handshake. Writes before it, e.g. a proxy CONNECT, are
+		// not.
+		c.pending, c.handshake = c.handshake, nil
+	}
+	c.mu.Unlock()
+	return c.Conn.Write(p)
+}
+
+func (c *faultConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if n == 0 {
+		return n, err
+	}
+
+	c.mu.Lock()
+	f, ctx := c.pending, c.ctx
+	c.pending = nil
+	c.mu.Unlock()
+	if f != nil {
+		if ferr := f.wait(ctx); ferr != nil {
+			c.Conn.Close()
+			return 0, ferr
+		}
+	}
+	return n, err
+}
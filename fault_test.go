@@ -0,0 +1,180 @@
+package httpstat
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newFaultClient returns a client sending requests to ts through a
+// FaultTransport configured by fn, measured by a Transport.
+func newFaultClient(ts *httptest.Server, fn func(*FaultTransport), onResult func(*Result, error)) *http.Client {
+	base := ts.Client().Transport.(*http.Transport).Clone()
+	if base.TLSClientConfig != nil {
+		// The test certificate is not valid for localhost.
+		base.TLSClientConfig.InsecureSkipVerify = true
+	}
+	base.DisableKeepAlives = true
+
+	ft := &FaultTransport{Base: base}
+	fn(ft)
+	return &http.Client{
+		Transport: &Transport{
+			Base: ft,
+			OnResult: func(_ *http.Request, r *Result, err error) {
+				onResult(r, err)
+			},
+		},
+	}
+}
+
+func localhostURL(ts *httptest.Server) string {
+	return strings.Replace(ts.URL, "127.0.0.1", "localhost", 1)
+}
+
+func TestFaultTransport_Delay(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer ts.Close()
+
+	const delay = 50 * time.Millisecond
+	var result *Result
+	client := newFaultClient(ts, func(ft *FaultTransport) {
+		ft.DNS.Delay = delay
+		ft.Connect.Delay = delay
+		ft.TLS.Delay = delay
+		ft.Server.Delay = delay
+	}, func(r *Result, err error) {
+		if err != nil {
+			t.Error("request failed:", err)
+		}
+		result = r
+	})
+
+	res, err := client.Get(localhostURL(ts))
+	if err != nil {
+		t.Fatal("client.Get failed:", err)
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+
+	durations := map[string]time.Duration{
+		"DNSLookup":        result.DNSLookup,
+		"TCPConnection":    result.TCPConnection,
+		"TLSHandshake":     result.TLSHandshake,
+		"ServerProcessing": result.ServerProcessing,
+	}
+	for name, d := range durations {
+		if d < delay {
+			t.Errorf("%s = %v, want at least %v", name, d, delay)
+		}
+	}
+	if result.Total() < 4*delay {
+		t.Errorf("Total = %v, want at least %v", result.Total(), 4*delay)
+	}
+}
+
+func TestFaultTransport_Error(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer ts.Close()
+
+	errInjected := errors.New("injected")
+	cases := map[string]func(*FaultTransport){
+		"dns":     func(ft *FaultTransport) { ft.DNS.Err = errInjected },
+		"connect": func(ft *FaultTransport) { ft.Connect.Err = errInjected },
+		"tls":     func(ft *FaultTransport) { ft.TLS.Err = errInjected },
+		"server":  func(ft *FaultTransport) { ft.Server.Err = errInjected },
+	}
+	for name, fn := range cases {
+		t.Run(name, func(t *testing.T) {
+			var gotErr error
+			client := newFaultClient(ts, fn, func(_ *Result, err error) {
+				gotErr = err
+			})
+			res, err := client.Get(localhostURL(ts))
+			if err == nil {
+				res.Body.Close()
+				t.Fatal("expect request to fail")
+			}
+			if !errors.Is(err, errInjected) {
+				t.Fatalf("expect injected error, got %v", err)
+			}
+			if gotErr == nil {
+				t.Fatal("expect OnResult to be called with the error")
+			}
+		})
+	}
+}
+
+func TestFaultTransport_Probability(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	errInjected := errors.New("injected")
+	client := newFaultClient(ts, func(ft *FaultTransport) {
+		ft.Connect = Fault{Err: errInjected, Probability: 0.5}
+	}, func(*Result, error) {})
+
+	var failed int
+	const n = 200
+	for i := 0; i < n; i++ {
+		res, err := client.Get(ts.URL)
+		if err != nil {
+			failed++
+			continue
+		}
+		res.Body.Close()
+	}
+	if failed == 0 || failed == n {
+		t.Fatalf("expect some of %d requests to fail, %d failed", n, failed)
+	}
+}
+
+func TestFaultConn_Context(t *testing.T) {
+	const delay = 5 * time.Second
+	cases := map[string]func(*faultConn, context.Context){
+		"tls": func(c *faultConn, ctx context.Context) {
+			c.ctx, c.handshake = ctx, &Fault{Delay: delay}
+		},
+		"server": func(c *faultConn, ctx context.Context) {
+			c.setPending(ctx, &Fault{Delay: delay})
+		},
+	}
+	for name, fn := range cases {
+		t.Run(name, func(t *testing.T) {
+			client, server := net.Pipe()
+			defer server.Close()
+			go func() {
+				buf := make([]byte, 1)
+				if _, err := server.Read(buf); err == nil {
+					server.Write(buf)
+				}
+			}()
+
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			c := &faultConn{Conn: client}
+			fn(c, ctx)
+
+			start := time.Now()
+			if _, err := c.Write([]byte{recordTypeHandshake}); err != nil {
+				t.Fatal("Write failed:", err)
+			}
+			if _, err := c.Read(make([]byte, 1)); !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("Read error = %v, want %v", err, context.DeadlineExceeded)
+			}
+			if d := time.Since(start); d >= delay {
+				t.Fatalf("Read took %v, want it to stop at the deadline", d)
+			}
+		})
+	}
+}
@@ -0,0 +1,297 @@
+package httpstat
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Layout selects the output of AppendFormat.
+type Layout int
+
+const (
+	// LayoutLine formats the Result on a single line, like the %s verb.
+	LayoutLine Layout = 0
+
+	// LayoutMultiline formats the Result as a table, like the %+v verb.
+	LayoutMultiline Layout = 1
+
+	// LayoutPercent adds the share of each phase in the total duration,
+	// like the '#' flag. It can be combined with the other layouts.
+	LayoutPercent Layout = 2
+)
+
+// Format formats stats result. Durations are printed in the unit set with
+// SetUnit, whole milliseconds by default. With the '#' flag (e.g. "%#+v"),
+// the phases are also shown as a percentage of the total duration, once it
+// is known.
+func (r Result) Format(s fmt.State, verb rune) {
+	var layout Layout
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			layout = LayoutMultiline
+		}
+	case 's', 'q':
+	default:
+		return
+	}
+	if s.Flag('#') {
+		layout |= LayoutPercent
+	}
+
+	var buf [1024]byte
+	s.Write(r.AppendFormat(buf[:0], layout))
+}
+
+// AppendFormat appends the formatted Result to dst, in the same way as
+// Format, and returns the extended buffer. It does not allocate if dst is
+// large enough, so it is suited for high-throughput logging.
+func (r *Result) AppendFormat(dst []byte, layout Layout) []byte {
+	share := layout&LayoutPercent != 0 && r.total > 0
+	if layout&LayoutMultiline != 0 {
+		return r.appendMultiline(dst, share, nil)
+	}
+	return r.appendLine(dst, share, nil)
+}
+
+func (r *Result) appendMultiline(dst []byte, share bool, c *ColorFormatter) []byte {
+	u := r.unit
+	w := u.width()
+	if r.Protocol != "" || r.Downgraded {
+		dst = append(dst, "Protocol: "...)
+		dst = append(dst, r.protocol()...)
+		if r.Downgraded {
+			dst = append(dst, " (downgraded from h2)"...)
+		}
+		dst = append(dst, "\n\n"...)
+	}
+	if lost, ok := r.Fallback(); ok {
+		dst = append(dst, "Address family: "...)
+		dst = append(dst, r.AddressFamily()...)
+		dst = append(dst, " (fallback, first attempt took "...)
+		dst = appendDuration(dst, u, lost, true, 0)
+		dst = append(dst, ")\n\n"...)
+	}
+	for i := range phaseNames {
+		p := Phase(i)
+		d, skipped := r.Duration(p), r.skipped(p)
+		if skipped && (p == PhaseProxyConnect || p == PhaseContinueWait) {
+			// Only shown for requests which had them.
+			continue
+		}
+		// A skipped content transfer means End was not called yet.
+		known := !skipped || p != PhaseTransfer
+		color := c.phaseColor(p, d, skipped)
+		dst = append(dst, color...)
+		dst = appendPadded(dst, r.phaseName(p), ":", 19)
+		dst = appendDuration(dst, u, d, known, w)
+		if share && known {
+			dst = append(dst, "  "...)
+			dst = appendPercent(dst, d, r.total, 5)
+			dst = append(dst, '%')
+		}
+		dst = appendReset(dst, color)
+		dst = append(dst, '\n')
+	}
+	dst = append(dst, '\n')
+
+	dst = appendPadded(dst, "Name Lookup", ":", 16)
+	dst = appendDuration(dst, u, r.NameLookup, true, w)
+	dst = appendPadded(append(dst, '\n'), "Connect", ":", 16)
+	dst = appendDuration(dst, u, r.Connect, true, w)
+	dst = appendPadded(append(dst, '\n'), "Pre Transfer", ":", 16)
+	dst = appendDuration(dst, u, r.Pretransfer, true, w)
+	dst = appendPadded(append(dst, '\n'), "Start Transfer", ":", 16)
+	dst = appendDuration(dst, u, r.StartTransfer, true, w)
+	dst = append(dst, '\n')
+	color := c.totalColor(r.total)
+	dst = appendPadded(append(dst, color...), "Total", ":", 16)
+	dst = appendDuration(dst, u, r.total, r.total > 0, w)
+	dst = append(appendReset(dst, color), '\n')
+
+	if r.StreamWait > 0 {
+		dst = append(dst, '\n')
+		dst = appendPadded(dst, "Stream wait", ":", 16)
+		dst = appendDuration(dst, u, r.StreamWait, true, w)
+		dst = append(dst, "  ("...)
+		dst = strconv.AppendInt(dst, int64(r.ConcurrentStreams), 10)
+		dst = append(dst, " concurrent streams)\n"...)
+	}
+
+	if len(r.customPhases) > 0 {
+		dst = append(dst, "\nCustom phases:\n"...)
+		for _, p := range r.customPhases {
+			dst = append(dst, "  "...)
+			dst = appendPadded(dst, p.Name, ":", 16)
+			dst = appendDuration(dst, u, p.Duration, true, w)
+			dst = append(dst, '\n')
+		}
+	}
+
+	if len(r.ServerTiming) > 0 {
+		dst = append(dst, "\nServer timing:\n"...)
+		for _, st := range r.ServerTiming {
+			dst = append(dst, "  "...)
+			dst = appendPadded(dst, st.Name, ":", 16)
+			dst = appendDuration(dst, u, st.Duration, true, w)
+			if st.Description != "" {
+				dst = append(dst, "  ("...)
+				dst = append(dst, st.Description...)
+				dst = append(dst, ')')
+			}
+			dst = append(dst, '\n')
+		}
+	}
+	return dst
+}
+
+func (r *Result) appendLine(dst []byte, share bool, c *ColorFormatter) []byte {
+	u := r.unit
+	start := len(dst)
+	sep := func(dst []byte) []byte {
+		if len(dst) > start {
+			dst = append(dst, ", "...)
+		}
+		return dst
+	}
+
+	if r.Protocol != "" || r.Downgraded {
+		dst = append(dst, "Protocol: "...)
+		dst = append(dst, r.protocol()...)
+		if r.Downgraded {
+			dst = append(dst, " (downgraded)"...)
+		}
+	}
+	for i := range phaseFields {
+		p := Phase(i)
+		d, skipped := r.Duration(p), r.skipped(p)
+		if skipped && (p == PhaseProxyConnect || p == PhaseContinueWait) {
+			continue
+		}
+		dst = sep(dst)
+		color := c.phaseColor(p, d, skipped)
+		dst = append(dst, color...)
+		dst = append(dst, r.phaseField(p)...)
+		dst = append(dst, ": "...)
+		// A skipped content transfer means End was not called yet.
+		known := !skipped || p != PhaseTransfer
+		dst = appendDuration(dst, u, d, known, 0)
+		if share && known {
+			dst = append(dst, " ("...)
+			dst = appendPercent(dst, d, r.total, 0)
+			dst = append(dst, "%)"...)
+		}
+		dst = appendReset(dst, color)
+	}
+
+	dst = sep(dst)
+	dst = append(dst, "NameLookup: "...)
+	dst = appendDuration(dst, u, r.NameLookup, true, 0)
+	dst = append(dst, ", Connect: "...)
+	dst = appendDuration(dst, u, r.Connect, true, 0)
+	dst = append(dst, ", Pretransfer: "...)
+	dst = appendDuration(dst, u, r.Pretransfer, true, 0)
+	dst = append(dst, ", StartTransfer: "...)
+	dst = appendDuration(dst, u, r.StartTransfer, true, 0)
+	color := c.totalColor(r.total)
+	dst = append(dst, ", "...)
+	dst = append(dst, color...)
+	dst = append(dst, "Total: "...)
+	dst = appendDuration(dst, u, r.total, r.total > 0, 0)
+	dst = appendReset(dst, color)
+	if r.StreamWait > 0 {
+		dst = append(dst, ", StreamWait: "...)
+		dst = appendDuration(dst, u, r.StreamWait, true, 0)
+		dst = append(dst, ", ConcurrentStreams: "...)
+		dst = strconv.AppendInt(dst, int64(r.ConcurrentStreams), 10)
+	}
+
+	for _, p := range r.customPhases {
+		dst = append(dst, ", "...)
+		dst = append(dst, p.Name...)
+		dst = append(dst, ": "...)
+		dst = appendDuration(dst, u, p.Duration, true, 0)
+	}
+
+	if lost, ok := r.Fallback(); ok {
+		dst = append(dst, ", Fallback: "...)
+		dst = append(dst, r.AddressFamily()...)
+		dst = append(dst, " (first attempt "...)
+		dst = appendDuration(dst, u, lost, true, 0)
+		dst = append(dst, ')')
+	}
+	return dst
+}
+
+// phaseName returns the name of phase p in the multiline output, and
+// phaseField the one in the line output. Connects to unix domain sockets
+// are socket connects, and the proxy connect of SOCKS5 proxies is their
+// handshake.
+func (r *Result) phaseName(p Phase) string {
+	if p == PhaseConnect && r.SocketPath != "" {
+		return "Socket connect"
+	}
+	if p == PhaseProxyConnect && strings.HasPrefix(r.ProxyURL, "socks5") {
+		return "SOCKS handshake"
+	}
+	return phaseNames[p]
+}
+
+func (r *Result) phaseField(p Phase) string {
+	if p == PhaseConnect && r.SocketPath != "" {
+		return "SocketConnect"
+	}
+	if p == PhaseProxyConnect && strings.HasPrefix(r.ProxyURL, "socks5") {
+		return "SOCKSHandshake"
+	}
+	return phaseFields[p]
+}
+
+// skipped reports whether phase p did not happen, as in PhaseTiming.
+func (r *Result) skipped(p Phase) bool {
+	if p == PhaseTransfer {
+		return r.total == 0
+	}
+	return r.Duration(p) <= 0
+}
+
+// appendPadded appends s and suffix, padded with spaces to width runes.
+func appendPadded(dst []byte, s, suffix string, width int) []byte {
+	dst = append(dst, s...)
+	dst = append(dst, suffix...)
+	for n := utf8.RuneCountInString(s) + len(suffix); n < width; n++ {
+		dst = append(dst, ' ')
+	}
+	return dst
+}
+
+// appendDuration appends d in unit u, right-aligned to width runes. If
+// known is false, d is not known yet and a placeholder is appended.
+func appendDuration(dst []byte, u Unit, d time.Duration, known bool, width int) []byte {
+	var buf [32]byte
+	var v []byte
+	if known {
+		v = u.appendTo(buf[:0], d)
+	} else {
+		v = u.appendUnknown(buf[:0])
+	}
+	return appendRight(dst, v, width)
+}
+
+// appendPercent appends d as a percentage of total with one decimal,
+// right-aligned to width runes.
+func appendPercent(dst []byte, d, total time.Duration, width int) []byte {
+	var buf [32]byte
+	v := strconv.AppendFloat(buf[:0], 100*float64(d)/float64(total), 'f', 1, 64)
+	return appendRight(dst, v, width)
+}
+
+func appendRight(dst, v []byte, width int) []byte {
+	for n := utf8.RuneCount(v); n < width; n++ {
+		dst = append(dst, ' ')
+	}
+	return append(dst, v...)
+}
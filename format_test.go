@@ -0,0 +1,90 @@
+package httpstat
+
+import (
+	"fmt"
+	"io"
+	"testing"
+	"time"
+)
+
+func formatResult() *Result {
+	return &Result{
+		DNSLookup:        10 * time.Millisecond,
+		TCPConnection:    20 * time.Millisecond,
+		TLSHandshake:     30 * time.Millisecond,
+		ServerProcessing: 40 * time.Millisecond,
+		contentTransfer:  50 * time.Millisecond,
+
+		NameLookup:    10 * time.Millisecond,
+		Connect:       30 * time.Millisecond,
+		Pretransfer:   60 * time.Millisecond,
+		StartTransfer: 100 * time.Millisecond,
+		total:         150 * time.Millisecond,
+
+		Protocol:     "h2",
+		customPhases: []CustomPhase{{Name: "sign", Duration: 2 * time.Millisecond}},
+		ServerTiming: []ServerTiming{{Name: "db", Duration: 25 * time.Millisecond, Description: "Database"}},
+	}
+}
+
+func TestResult_AppendFormat(t *testing.T) {
+	r := formatResult()
+	cases := []struct {
+		layout Layout
+		format string
+	}{
+		{LayoutLine, "%s"},
+		{LayoutMultiline, "%+v"},
+		{LayoutLine | LayoutPercent, "%#s"},
+		{LayoutMultiline | LayoutPercent, "%#+v"},
+	}
+	for _, unit := range []Unit{UnitMillisecond, UnitAuto} {
+		r.SetUnit(unit)
+		for _, c := range cases {
+			want := fmt.Sprintf(c.format, *r)
+			if got := string(r.AppendFormat([]byte("prefix "), c.layout)); got != "prefix "+want {
+				t.Errorf("AppendFormat(%d) = %q, want %q", c.layout, got, "prefix "+want)
+			}
+		}
+	}
+}
+
+func TestResult_AppendFormatSkipped(t *testing.T) {
+	// A reused connection skips the DNS lookup and the connect.
+	r := &Result{ServerProcessing: 40 * time.Millisecond, StartTransfer: 40 * time.Millisecond}
+	want := "DNSLookup: 0 ms, TCPConnection: 0 ms, TLSHandshake: 0 ms, ServerProcessing: 40 ms, ContentTransfer: - ms, " +
+		"NameLookup: 0 ms, Connect: 0 ms, Pretransfer: 0 ms, StartTransfer: 40 ms, Total: - ms"
+	if got := string(r.AppendFormat(nil, LayoutLine)); got != want {
+		t.Errorf("AppendFormat = %q, want %q", got, want)
+	}
+}
+
+func TestResult_AppendFormatAllocs(t *testing.T) {
+	r := formatResult()
+	buf := make([]byte, 0, 1024)
+	for _, layout := range []Layout{LayoutLine, LayoutMultiline | LayoutPercent} {
+		allocs := testing.AllocsPerRun(100, func() {
+			buf = r.AppendFormat(buf[:0], layout)
+		})
+		if allocs != 0 {
+			t.Errorf("AppendFormat(%d) allocates %v times, want 0", layout, allocs)
+		}
+	}
+}
+
+func BenchmarkResult_AppendFormat(b *testing.B) {
+	r := formatResult()
+	buf := make([]byte, 0, 1024)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf = r.AppendFormat(buf[:0], LayoutMultiline)
+	}
+}
+
+func BenchmarkResult_Format(b *testing.B) {
+	r := formatResult()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		fmt.Fprintf(io.Discard, "%+v", *r)
+	}
+}
@@ -7,19 +7,45 @@ import (
 	"context"
 	"crypto/tls"
 	"net/http/httptrace"
+	"strings"
+	"sync"
 	"time"
 )
 
-// End sets the time when reading the response is done.
+// End sets the time when reading the response is done to t.
 // This must be called after reading the response body.
-func (r *Result) End() {
+func (r *Result) End(t time.Time) {
+	r.lock()
+	r.t5 = t
+
 	// This means the result is empty, and we'll skip
 	// setting values for contentTransfer and total.
 	if r.dnsStart.IsZero() {
+		r.unlock()
 		return
 	}
-	r.contentTransfer = time.Since(r.transferStart)
-	r.total = time.Since(r.dnsStart)
+
+	// Without the first byte of the response, e.g. after a 100 Continue
+	// if the final response was not recorded, server processing lasts
+	// until the end.
+	if r.serverDone.IsZero() && !r.serverStart.IsZero() {
+		r.endServer(t)
+	}
+	r.contentTransfer = t.Sub(r.transferStart)
+	r.total = t.Sub(r.dnsStart)
+	r.done = true
+
+	r.stream.release()
+	r.unlock()
+
+	if r.observer != nil {
+		r.observer.OnComplete(r)
+	}
+}
+
+// EndNow calls End with the current time.
+func (r *Result) EndNow() {
+	r.End(time.Now())
 }
 
 // ContentTransfer returns the duration of content transfer time.
@@ -50,84 +76,363 @@ func (r *Result) Until(t time.Time) time.Duration {
 	return t.Sub(r.dnsStart)
 }
 
+// detectProtocol sets Protocol when it was not negotiated with ALPN. It must
+// be called with r locked.
+func (r *Result) detectProtocol() {
+	switch {
+	case r.Protocol != "":
+	case r.isQUIC:
+		r.Protocol = "h3"
+	case r.isH2:
+		r.Protocol = "h2c"
+	default:
+		r.Protocol = "http/1.1"
+	}
+}
+
 func withClientTrace(ctx context.Context, r *Result) context.Context {
-	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
-		DNSStart: func(i httptrace.DNSStartInfo) {
-			r.dnsStart = time.Now()
-		},
-
-		DNSDone: func(i httptrace.DNSDoneInfo) {
-			r.DNSLookup = time.Since(r.dnsStart)
-			r.NameLookup = time.Since(r.dnsStart)
-		},
-
-		ConnectStart: func(_, _ string) {
-			r.tcpStart = time.Now()
-
-			// When connecting to IP (e.g. there's no DNS lookup)
-			if r.dnsStart.IsZero() {
-				r.dnsStart = r.tcpStart
-			}
-		},
-
-		ConnectDone: func(network, addr string, err error) {
-			r.TCPConnection = time.Since(r.tcpStart)
-			r.Connect = time.Since(r.dnsStart)
-		},
-
-		TLSHandshakeStart: func() {
-			r.isTLS = true
-			r.tlsStart = time.Now()
-		},
-
-		TLSHandshakeDone: func(_ tls.ConnectionState, _ error) {
-			r.TLSHandshake = time.Since(r.tlsStart)
-			r.Pretransfer = time.Since(r.dnsStart)
-		},
-
-		GotConn: func(i httptrace.GotConnInfo) {
-			// Handle when keep alive is used and the connection is reused.
-			// DNSStart(Done) and ConnectStart(Done) is then skipped.
-			if i.Reused {
-				r.isReused = true
-			}
-		},
-
-		WroteRequest: func(info httptrace.WroteRequestInfo) {
-			r.serverStart = time.Now()
-
-			// When client doesn't use DialContext or using old (before go1.7) `net`
-			// pakcage, DNS/TCP/TLS hook is not called.
-			if r.dnsStart.IsZero() && r.tcpStart.IsZero() {
-				now := r.serverStart
-
-				r.dnsStart = now
-				r.tcpStart = now
-			}
-
-			// When connection is re-used, DNS/TCP/TLS hooks are not called.
-			if r.isReused {
-				now := r.serverStart
-
-				r.dnsStart = now
-				r.tcpStart = now
-				r.tlsStart = now
-			}
-
-			// If no TLS, TLSHandshake is zero and Pretransfer is equal to Connect.
-			if r.isTLS {
-				return
-			}
-			r.TLSHandshake = time.Duration(0)
-			r.Pretransfer = r.Connect
-		},
-
-		GotFirstResponseByte: func() {
-			r.serverDone = time.Now()
-			r.ServerProcessing = time.Since(r.serverStart)
-
-			r.transferStart = time.Now()
-			r.StartTransfer = time.Since(r.dnsStart)
-		},
-	})
+	// WithClientTrace composes the trace it is given with the trace already
+	// in ctx in place, so the trace of r is copied.
+	trace := *r.clientTrace()
+	return httptrace.WithClientTrace(ctx, &trace)
+}
+
+// clientTrace returns the hooks recording into r. They are bound to r once
+// and reused by all requests r measures.
+func (r *Result) clientTrace() *httptrace.ClientTrace {
+	// A copied Result carries the hooks and the lock of the original.
+	if r.trace != nil && r.traceOwner == r {
+		return r.trace
+	}
+	r.mu = new(sync.Mutex)
+	r.trace = &httptrace.ClientTrace{
+		GetConn:              r.onGetConn,
+		DNSStart:             r.onDNSStart,
+		DNSDone:              r.onDNSDone,
+		ConnectStart:         r.onConnectStart,
+		ConnectDone:          r.onConnectDone,
+		TLSHandshakeStart:    r.onTLSHandshakeStart,
+		TLSHandshakeDone:     r.onTLSHandshakeDone,
+		GotConn:              r.onGotConn,
+		WroteHeaderField:     r.onWroteHeaderField,
+		WroteHeaders:         r.onWroteHeaders,
+		WroteRequest:         r.onWroteRequest,
+		Wait100Continue:      r.onWait100Continue,
+		Got100Continue:       r.onGot100Continue,
+		GotFirstResponseByte: r.onGotFirstResponseByte,
+	}
+	r.traceOwner = r
+	return r.trace
+}
+
+func (r *Result) onGetConn(string) {
+	if !r.lockHook() {
+		return
+	}
+	defer r.unlock()
+	r.getConnStart = time.Now()
+}
+
+// endOpaqueDial records a connection dialed by a custom dialer which did
+// not report its phases, e.g. one ignoring the context of DialContext, as
+// a connect lasting from asking for the connection until t. It reports
+// whether the dial was opaque. It must be called with r locked.
+func (r *Result) endOpaqueDial(t time.Time) bool {
+	if r.getConnStart.IsZero() || !r.tcpStart.IsZero() || r.isReused {
+		return false
+	}
+	r.dnsStart = r.getConnStart
+	r.tcpStart = r.getConnStart
+	r.tcpDone = t
+	r.TCPConnection = t.Sub(r.tcpStart)
+	r.Connect = r.TCPConnection
+	r.Pretransfer = r.Connect
+	return true
+}
+
+func (r *Result) onDNSStart(i httptrace.DNSStartInfo) {
+	if !r.lockHook() {
+		return
+	}
+	defer r.unlock()
+	r.dnsStart = time.Now()
+}
+
+func (r *Result) onDNSDone(i httptrace.DNSDoneInfo) {
+	if !r.lockHook() {
+		return
+	}
+	r.DNSLookup = time.Since(r.dnsStart)
+	r.NameLookup = time.Since(r.dnsStart)
+	if i.Err != nil {
+		r.fail(PhaseDNS)
+	}
+	r.unlock()
+
+	if r.observer != nil {
+		r.observer.OnDNSDone(r)
+	}
+}
+
+func (r *Result) onConnectStart(network, addr string) {
+	if !r.lockHook() {
+		return
+	}
+	defer r.unlock()
+	r.tcpStart = time.Now()
+	r.connectAttempts = append(r.connectAttempts, ConnectAttempt{Network: network, Addr: addr, Start: r.tcpStart})
+	if strings.HasPrefix(network, "unix") {
+		r.SocketPath = addr
+	}
+
+	// A failed connect is retried with the next address.
+	if r.failed && r.failedPhase == PhaseConnect {
+		r.failed = false
+	}
+
+	// HTTP/3 transports report their QUIC connection as a udp
+	// connect which includes the TLS handshake.
+	if network == "udp" {
+		r.isQUIC = true
+	}
+
+	// When connecting to IP (e.g. there's no DNS lookup)
+	if r.dnsStart.IsZero() {
+		r.dnsStart = r.tcpStart
+	}
+}
+
+func (r *Result) onConnectDone(network, addr string, err error) {
+	if !r.lockHook() {
+		return
+	}
+	now := time.Now()
+	a := r.endConnectAttempt(network, addr, now, err)
+	// Attempts ending after another one connected, such as the losing
+	// attempt of dual-stack dialing, do not change the phase.
+	if r.connected {
+		r.unlock()
+		return
+	}
+	if a != nil {
+		r.tcpStart = a.Start
+	}
+
+	// There is no separate transport handshake for QUIC, the
+	// connection is established by the TLS handshake.
+	if r.isQUIC && !r.tlsStart.IsZero() {
+		r.TCPConnection = r.tlsStart.Sub(r.tcpStart)
+		r.Connect = r.tlsStart.Sub(r.dnsStart)
+	} else {
+		r.tcpDone = now
+		r.TCPConnection = r.tcpDone.Sub(r.tcpStart)
+		r.Connect = r.tcpDone.Sub(r.dnsStart)
+	}
+	if err != nil {
+		r.fail(PhaseConnect)
+	} else {
+		r.connected = true
+		// An attempt in parallel may have failed before.
+		if r.failed && r.failedPhase == PhaseConnect {
+			r.failed = false
+		}
+	}
+	r.unlock()
+
+	if r.observer != nil {
+		r.observer.OnConnectDone(r)
+	}
+}
+
+func (r *Result) onTLSHandshakeStart() {
+	if !r.lockHook() {
+		return
+	}
+	defer r.unlock()
+	r.isTLS = true
+	r.tlsStart = time.Now()
+	r.endOpaqueDial(r.tlsStart)
+	r.endSOCKSHandshake(r.tlsStart)
+}
+
+func (r *Result) onTLSHandshakeDone(state tls.ConnectionState, err error) {
+	if !r.lockHook() {
+		return
+	}
+	r.TLSHandshake = time.Since(r.tlsStart)
+	r.Pretransfer = time.Since(r.dnsStart)
+	r.Protocol = state.NegotiatedProtocol
+	if err != nil {
+		r.fail(PhaseTLS)
+	} else {
+		r.alpn = true
+		r.detectDowngrade()
+	}
+	r.unlock()
+
+	if r.observer != nil {
+		r.observer.OnTLSDone(r)
+	}
+}
+
+func (r *Result) onGotConn(i httptrace.GotConnInfo) {
+	if !r.lockHook() {
+		return
+	}
+	defer r.unlock()
+
+	// Handle when keep alive is used and the connection is reused.
+	// DNSStart(Done) and ConnectStart(Done) is then skipped.
+	if i.Reused {
+		r.isReused = true
+	} else if r.isSOCKS && !r.isTLS {
+		r.endSOCKSHandshake(time.Now())
+	} else if r.endOpaqueDial(time.Now()) {
+		// A custom dialer, which may also have performed the TLS
+		// handshake with DialTLSContext.
+		if a := i.Conn.RemoteAddr(); a != nil && strings.HasPrefix(a.Network(), "unix") {
+			r.SocketPath = a.String()
+		}
+	}
+	r.ConcurrentStreams = r.acquireStream(i.Conn)
+
+	// The handshake is skipped for reused connections, so read the
+	// negotiated protocol from the connection itself.
+	if c, ok := i.Conn.(*tls.Conn); ok && r.Protocol == "" {
+		r.Protocol = c.ConnectionState().NegotiatedProtocol
+		r.alpn = true
+		r.detectDowngrade()
+	}
+}
+
+func (r *Result) onWroteHeaderField(key string, _ []string) {
+	if !r.lockHook() {
+		return
+	}
+	defer r.unlock()
+	if strings.HasPrefix(key, ":") {
+		r.isH2 = true
+	}
+}
+
+func (r *Result) onWroteHeaders() {
+	if !r.lockHook() {
+		return
+	}
+	defer r.unlock()
+	r.streamStart = time.Now()
+	r.detectProtocol()
+}
+
+func (r *Result) onWroteRequest(info httptrace.WroteRequestInfo) {
+	if !r.lockHook() {
+		return
+	}
+	defer r.unlock()
+	r.serverStart = time.Now()
+	r.detectProtocol()
+	if info.Err != nil {
+		r.fail(PhaseServer)
+	}
+
+	// When client doesn't use DialContext or using old (before go1.7) `net`
+	// pakcage, DNS/TCP/TLS hook is not called.
+	if r.dnsStart.IsZero() && r.tcpStart.IsZero() {
+		now := r.serverStart
+
+		r.dnsStart = now
+		r.tcpStart = now
+	}
+
+	// When connection is re-used, DNS/TCP/TLS hooks are not called.
+	if r.isReused {
+		now := r.serverStart
+
+		r.dnsStart = now
+		r.tcpStart = now
+		r.tlsStart = now
+	}
+
+	// If no TLS, TLSHandshake is zero and Pretransfer is equal to Connect.
+	if r.isTLS {
+		return
+	}
+	r.TLSHandshake = time.Duration(0)
+	r.Pretransfer = r.Connect + r.ProxyConnect
+}
+
+func (r *Result) onWait100Continue() {
+	if !r.lockHook() {
+		return
+	}
+	defer r.unlock()
+	r.continueStart = time.Now()
+}
+
+func (r *Result) onGot100Continue() {
+	if !r.lockHook() {
+		return
+	}
+	defer r.unlock()
+	r.ContinueWait = time.Since(r.continueStart)
+
+	// The first response byte belonged to the interim 100 Continue
+	// response rather than to the response to the request. httptrace does
+	// not report the final response, see gotResponse.
+	r.interim = !r.serverDone.IsZero()
+	r.serverDone = time.Time{}
+	r.ServerProcessing = 0
+	r.transferStart = time.Time{}
+	r.StartTransfer = 0
+}
+
+func (r *Result) onGotFirstResponseByte() {
+	if !r.lockHook() {
+		return
+	}
+	r.serverDone = time.Now()
+	r.ServerProcessing = time.Since(r.serverStart)
+	if (r.Protocol == "h2" || r.Protocol == "h2c") && !r.streamStart.IsZero() {
+		r.StreamWait = r.serverDone.Sub(r.streamStart)
+	}
+
+	// When waiting for a 100 Continue the request body is not sent
+	// yet. If the server answers with a final response instead, the
+	// whole wait is spent on the continue phase.
+	if !r.continueStart.IsZero() && r.serverStart.IsZero() {
+		r.ContinueWait = time.Since(r.continueStart)
+		r.ServerProcessing = 0
+	}
+
+	r.transferStart = time.Now()
+	r.StartTransfer = time.Since(r.dnsStart)
+	r.unlock()
+
+	if r.observer != nil {
+		r.observer.OnFirstByte(r)
+	}
+}
+
+// gotResponse records that the final response to the request arrived at t.
+// After a 100 Continue, the first response byte which httptrace reports is
+// the one of the interim response, so the server processing ends once the
+// round trip returns the response instead.
+func (r *Result) gotResponse(t time.Time) {
+	if !r.lockHook() {
+		return
+	}
+	defer r.unlock()
+	if r.interim {
+		r.interim = false
+		r.endServer(t)
+	}
+}
+
+// endServer ends the server processing at t. It must be called with r
+// locked.
+func (r *Result) endServer(t time.Time) {
+	r.serverDone = t
+	r.ServerProcessing = t.Sub(r.serverStart)
+	r.transferStart = t
+	r.StartTransfer = t.Sub(r.dnsStart)
 }
@@ -0,0 +1,168 @@
+package httpstat
+
+import (
+	"bytes"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GraphiteSink is a Sink writing the phase durations of Records in the
+// Graphite plaintext protocol, one line per phase in milliseconds, e.g.
+//
+//	httpstat.api_example_com.server_processing 23.412 1790000000
+//
+// Records of failed requests are not written.
+type GraphiteSink struct {
+	// Addr is the address of the Carbon daemon, e.g. "localhost:2003".
+	Addr string
+
+	// Network is "tcp" or "udp". If empty, "tcp" is used.
+	Network string
+
+	// Template is the metric path of each line. The placeholders {host},
+	// {method}, {status} and {phase} are replaced by the host of the
+	// request URL, the method, the status code and the phase (or "total"),
+	// and {label:name} by the label name of the Result. Dots in the
+	// replacements are replaced by underscores. If empty,
+	// "httpstat.{host}.{phase}" is used.
+	Template string
+
+	// Timeout is the timeout for connecting and writing. If zero, 5 seconds
+	// is used.
+	Timeout time.Duration
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// WriteRecord implements Sink. The connection is opened on the first call
+// and opened again after errors.
+func (s *GraphiteSink) WriteRecord(rec Record) error {
+	if rec.Err != nil || rec.Result == nil {
+		return nil
+	}
+	var buf bytes.Buffer
+	s.appendLines(&buf, rec)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	if s.conn == nil {
+		network := s.Network
+		if network == "" {
+			network = "tcp"
+		}
+		conn, err := net.DialTimeout(network, s.Addr, timeout)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+	s.conn.SetWriteDeadline(time.Now().Add(timeout))
+	if _, err := s.conn.Write(buf.Bytes()); err != nil {
+		s.conn.Close()
+		s.conn = nil
+		return err
+	}
+	return nil
+}
+
+// Close closes the connection.
+func (s *GraphiteSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+func (s *GraphiteSink) appendLines(buf *bytes.Buffer, rec Record) {
+	r := rec.Result
+	t := rec.Time
+	if t.IsZero() {
+		t = time.Now()
+	}
+	ts := strconv.FormatInt(t.Unix(), 10)
+	line := func(phase string, d time.Duration) {
+		buf.WriteString(s.path(rec, phase))
+		buf.WriteByte(' ')
+		buf.WriteString(strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64))
+		buf.WriteByte(' ')
+		buf.WriteString(ts)
+		buf.WriteByte('\n')
+	}
+	for p := range phaseNames {
+		if d := r.Duration(Phase(p)); d > 0 {
+			line(metricPhases[p], d)
+		}
+	}
+	if r.total > 0 {
+		line("total", r.total)
+	}
+}
+
+// path expands the template for phase of rec.
+func (s *GraphiteSink) path(rec Record, phase string) string {
+	tmpl := s.Template
+	if tmpl == "" {
+		tmpl = "httpstat.{host}.{phase}"
+	}
+
+	var b strings.Builder
+	for {
+		i := strings.IndexByte(tmpl, '{')
+		j := strings.IndexByte(tmpl[i+1:], '}')
+		if i < 0 || j < 0 {
+			b.WriteString(tmpl)
+			return b.String()
+		}
+		b.WriteString(tmpl[:i])
+		b.WriteString(graphiteName(s.placeholder(rec, phase, tmpl[i+1:i+1+j])))
+		tmpl = tmpl[i+j+2:]
+	}
+}
+
+func (s *GraphiteSink) placeholder(rec Record, phase, name string) string {
+	switch name {
+	case "host":
+		if u, err := url.Parse(rec.URL); err == nil {
+			return u.Hostname()
+		}
+		return ""
+	case "method":
+		return rec.Method
+	case "status":
+		return strconv.Itoa(rec.StatusCode)
+	case "phase":
+		return phase
+	}
+	if label, ok := strings.CutPrefix(name, "label:"); ok {
+		v, _ := rec.Result.Label(label)
+		return v
+	}
+	return "{" + name + "}"
+}
+
+// graphiteName replaces the characters which would break the metric path.
+func graphiteName(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.', ' ', '/', ':':
+			return '_'
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,71 @@
+package httpstat
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGraphiteSink(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Listen failed:", err)
+	}
+	defer ln.Close()
+	lines := make(chan string, 16)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		sc := bufio.NewScanner(conn)
+		for sc.Scan() {
+			lines <- sc.Text()
+		}
+		close(lines)
+	}()
+
+	result := &Result{DNSLookup: 2 * time.Millisecond, ServerProcessing: 1500 * time.Microsecond, total: 5 * time.Millisecond}
+	result.SetLabel("region", "eu.west")
+	s := &GraphiteSink{Addr: ln.Addr().String(), Template: "web.{label:region}.{host}.{method}.{status}.{phase}"}
+	now := time.Unix(1790000000, 0)
+	rec := Record{Time: now, Method: "GET", URL: "https://api.example.com/x", StatusCode: 200, Result: result}
+	if err := s.WriteRecord(rec); err != nil {
+		t.Fatal("WriteRecord failed:", err)
+	}
+	if err := s.WriteRecord(Record{Err: errors.New("failed"), Result: result}); err != nil {
+		t.Fatal("WriteRecord of failed request failed:", err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal("Close failed:", err)
+	}
+
+	var got []string
+	for l := range lines {
+		got = append(got, l)
+	}
+	want := []string{
+		"web.eu_west.api_example_com.GET.200.dns_lookup 2.000 1790000000",
+		"web.eu_west.api_example_com.GET.200.server_processing 1.500 1790000000",
+		"web.eu_west.api_example_com.GET.200.total 5.000 1790000000",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("expect lines\n%s\ngot\n%s", strings.Join(want, "\n"), strings.Join(got, "\n"))
+	}
+}
+
+func TestGraphiteSink_DefaultTemplate(t *testing.T) {
+	s := &GraphiteSink{}
+	rec := Record{URL: "http://localhost:8080/", Result: &Result{}}
+	if got := s.path(rec, "connect"); got != "httpstat.localhost.connect" {
+		t.Fatalf("unexpected path %q", got)
+	}
+	s.Template = "x.{nope}.{method}"
+	if got := s.path(rec, "connect"); got != "x.{nope}.unknown" {
+		t.Fatalf("unexpected path %q", got)
+	}
+}
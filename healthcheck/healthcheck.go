@@ -0,0 +1,102 @@
+// Package healthcheck runs HTTP health checks which validate the response
+// and measure its latency with go-httpstat in a single probe.
+package healthcheck
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/jakobilobi/go-httpstat"
+)
+
+// Check is a health check of a single URL.
+type Check struct {
+	// URL is requested with a GET request.
+	URL string
+
+	// Client is used to send the request. If nil, http.DefaultClient
+	// is used.
+	Client *http.Client
+
+	// Validators are evaluated in order against the response. The check
+	// fails on the first validator returning an error.
+	Validators []Validator
+}
+
+// ValidationPhase is the name of the custom phase of Report.Result in which
+// the validators are evaluated.
+const ValidationPhase = "validation"
+
+// Report stores the outcome of a health check.
+type Report struct {
+	// Result stores the latency of the request, and the time spent
+	// evaluating the validators as its custom phase ValidationPhase.
+	Result httpstat.Result
+
+	// StatusCode is the status code of the response.
+	StatusCode int
+
+	// Err is the reason the check failed, or nil if it passed.
+	Err error
+}
+
+// Healthy reports whether the check passed.
+func (r *Report) Healthy() bool {
+	return r.Err == nil
+}
+
+// Validation returns the time spent evaluating the validators.
+func (r *Report) Validation() time.Duration {
+	for _, p := range r.Result.CustomPhases() {
+		if p.Name == ValidationPhase {
+			return p.Duration
+		}
+	}
+	return 0
+}
+
+// Run performs the check. The returned error is only non-nil if the request
+// could not be sent; request and validation failures are reported in
+// Report.Err.
+func (c *Check) Run(ctx context.Context) (*Report, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", c.URL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	rep := &Report{}
+	req = req.WithContext(httpstat.WithHTTPStat(req.Context(), &rep.Result))
+
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		rep.Err = err
+		return rep, nil
+	}
+
+	body, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	rep.Result.EndNow()
+	rep.StatusCode = res.StatusCode
+	if err != nil {
+		rep.Err = fmt.Errorf("reading body: %w", err)
+		return rep, nil
+	}
+
+	rep.Result.StartPhase(ValidationPhase)
+	for _, v := range c.Validators {
+		if err := v(res, body); err != nil {
+			rep.Err = err
+			break
+		}
+	}
+	rep.Result.EndPhase(ValidationPhase)
+
+	return rep, nil
+}
@@ -0,0 +1,77 @@
+package healthcheck
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheck_Run(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"ok","checks":[{"name":"db","up":true}]}`))
+	}))
+	defer ts.Close()
+
+	cases := []struct {
+		validators []Validator
+		healthy    bool
+	}{
+		{nil, true},
+		{[]Validator{Status(200)}, true},
+		{[]Validator{Status(201, 204)}, false},
+		{[]Validator{Header("Content-Type")}, true},
+		{[]Validator{Header("X-Missing")}, false},
+		{[]Validator{BodyMatches(regexp.MustCompile(`"status":"ok"`))}, true},
+		{[]Validator{BodyMatches(regexp.MustCompile(`fail`))}, false},
+		{[]Validator{JSONPath("status", "ok")}, true},
+		{[]Validator{JSONPath("checks.0.up", true)}, true},
+		{[]Validator{JSONPath("checks.0.name", nil)}, true},
+		{[]Validator{JSONPath("checks.1.name", nil)}, false},
+		{[]Validator{JSONPath("status", "down")}, false},
+		{[]Validator{Status(200), JSONPath("missing", nil)}, false},
+	}
+
+	for i, tc := range cases {
+		c := &Check{URL: ts.URL, Validators: tc.validators}
+		rep, err := c.Run(context.Background())
+		if err != nil {
+			t.Fatalf("#%d Run failed: %s", i, err)
+		}
+		if got := rep.Healthy(); got != tc.healthy {
+			t.Fatalf("#%d Healthy = %t, want %t (err: %v)", i, got, tc.healthy, rep.Err)
+		}
+		if rep.StatusCode != http.StatusOK {
+			t.Fatalf("#%d StatusCode = %d, want %d", i, rep.StatusCode, http.StatusOK)
+		}
+		if rep.Result.Total() <= 0 {
+			t.Fatalf("#%d expect Total to be non-zero", i)
+		}
+		if len(tc.validators) > 0 && rep.Validation() <= 0*time.Millisecond {
+			t.Fatalf("#%d expect Validation to be non-zero", i)
+		}
+		if b, _ := json.Marshal(&rep.Result); !strings.Contains(fmt.Sprintf("%+v", rep.Result), ValidationPhase) || !strings.Contains(string(b), `"name":"validation"`) {
+			t.Fatalf("#%d expect the validation phase in the output of the Result, got %s", i, b)
+		}
+	}
+}
+
+func TestCheck_RunRequestError(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	ts.Close()
+
+	c := &Check{URL: ts.URL}
+	rep, err := c.Run(context.Background())
+	if err != nil {
+		t.Fatal("Run failed:", err)
+	}
+	if rep.Healthy() {
+		t.Fatal("expect check against closed server to be unhealthy")
+	}
+}
@@ -0,0 +1,86 @@
+package healthcheck
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Validator validates a response. body is the complete response body.
+type Validator func(res *http.Response, body []byte) error
+
+// Status returns a Validator which requires the status code of the response
+// to be one of codes.
+func Status(codes ...int) Validator {
+	return func(res *http.Response, _ []byte) error {
+		for _, c := range codes {
+			if res.StatusCode == c {
+				return nil
+			}
+		}
+		return fmt.Errorf("unexpected status code %d", res.StatusCode)
+	}
+}
+
+// Header returns a Validator which requires the response to have the
+// header name.
+func Header(name string) Validator {
+	return func(res *http.Response, _ []byte) error {
+		if _, ok := res.Header[http.CanonicalHeaderKey(name)]; !ok {
+			return fmt.Errorf("missing header %s", name)
+		}
+		return nil
+	}
+}
+
+// BodyMatches returns a Validator which requires the response body to
+// match re.
+func BodyMatches(re *regexp.Regexp) Validator {
+	return func(_ *http.Response, body []byte) error {
+		if !re.Match(body) {
+			return fmt.Errorf("body does not match %s", re)
+		}
+		return nil
+	}
+}
+
+// JSONPath returns a Validator which requires the response body to be a
+// JSON document holding a value at path. Path elements are separated by
+// dots, array elements are addressed by index (e.g. "items.0.id"). If want
+// is non-nil, the value must also equal want when both are formatted with
+// fmt.Sprint.
+func JSONPath(path string, want interface{}) Validator {
+	return func(_ *http.Response, body []byte) error {
+		var v interface{}
+		if err := json.Unmarshal(body, &v); err != nil {
+			return fmt.Errorf("body is not JSON: %w", err)
+		}
+
+		for _, key := range strings.Split(path, ".") {
+			switch node := v.(type) {
+			case map[string]interface{}:
+				val, ok := node[key]
+				if !ok {
+					return fmt.Errorf("%s: not found", path)
+				}
+				v = val
+			case []interface{}:
+				i, err := strconv.Atoi(key)
+				if err != nil || i < 0 || i >= len(node) {
+					return fmt.Errorf("%s: not found", path)
+				}
+				v = node[i]
+			default:
+				return fmt.Errorf("%s: not found", path)
+			}
+		}
+
+		if want != nil && fmt.Sprint(v) != fmt.Sprint(want) {
+			return fmt.Errorf("%s = %v, want %v", path, v, want)
+		}
+		return nil
+	}
+}
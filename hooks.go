@@ -0,0 +1,74 @@
+package httpstat
+
+import (
+	"context"
+	"net/http/httptrace"
+)
+
+// Hooks selects groups of httptrace hooks for WithHTTPStatHooks. The hook
+// reporting that the request was written is always registered, as the
+// durations are measured from it when earlier hooks are left out.
+type Hooks int
+
+const (
+	// HookDNS measures the DNS lookup.
+	HookDNS Hooks = 1 << iota
+
+	// HookConnect measures the TCP (or QUIC) connect, or the dial of a
+	// custom dialer which does not report it.
+	HookConnect
+
+	// HookTLS measures the TLS handshake and records the negotiated
+	// protocol.
+	HookTLS
+
+	// HookConn detects reused connections and records the protocol
+	// negotiated on them.
+	HookConn
+
+	// HookProtocol detects HTTP/2 from the written header fields.
+	HookProtocol
+
+	// HookContinue measures the wait for a 100 Continue response.
+	HookContinue
+
+	// HookFirstByte ends the server processing at the first response
+	// byte, for the time to first byte. Without it, the server processing
+	// lasts until End.
+	HookFirstByte
+
+	// AllHooks registers all hooks, like WithHTTPStat.
+	AllHooks = HookDNS | HookConnect | HookTLS | HookConn | HookProtocol | HookContinue | HookFirstByte
+)
+
+// WithHTTPStatHooks is like WithHTTPStat, but only registers the selected
+// hooks, for clients which only care about some phases and want to keep
+// the overhead of the others off the request. Without the DNS, connect and
+// TLS hooks, the request is measured from the time it was written.
+func WithHTTPStatHooks(ctx context.Context, r *Result, hooks Hooks) context.Context {
+	trace := *r.clientTrace()
+	if hooks&HookDNS == 0 {
+		trace.DNSStart, trace.DNSDone = nil, nil
+	}
+	if hooks&HookConnect == 0 {
+		trace.GetConn, trace.ConnectStart, trace.ConnectDone = nil, nil, nil
+	}
+	if hooks&HookTLS == 0 {
+		trace.TLSHandshakeStart, trace.TLSHandshakeDone = nil, nil
+	}
+	if hooks&HookConn == 0 {
+		trace.GotConn = nil
+	}
+	if hooks&HookProtocol == 0 {
+		trace.WroteHeaderField, trace.WroteHeaders = nil, nil
+	}
+	if hooks&HookContinue == 0 {
+		trace.Wait100Continue, trace.Got100Continue = nil, nil
+	}
+	if hooks&HookFirstByte == 0 {
+		trace.GotFirstResponseByte = nil
+	}
+
+	ctx = context.WithValue(ctx, resultKey{}, r)
+	return httptrace.WithClientTrace(ctx, &trace)
+}
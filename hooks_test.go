@@ -0,0 +1,54 @@
+package httpstat
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestWithHTTPStatHooks(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.(http.Flusher).Flush()
+		time.Sleep(20 * time.Millisecond)
+	}))
+	defer ts.Close()
+
+	get := func(hooks Hooks) *Result {
+		var result Result
+		req, _ := http.NewRequestWithContext(WithHTTPStatHooks(context.Background(), &result, hooks), "GET", ts.URL, nil)
+		res, err := new(http.Transport).RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+		result.EndNow()
+		return &result
+	}
+
+	all := get(AllHooks)
+	if all.TCPConnection <= 0 || all.ServerProcessing < 20*time.Millisecond || all.ContentTransfer() < 20*time.Millisecond {
+		t.Fatalf("expect all phases to be measured, got %s", all)
+	}
+
+	ttfb := get(HookFirstByte)
+	if ttfb.DNSLookup != 0 || ttfb.TCPConnection != 0 {
+		t.Fatalf("expect the connect not to be measured, got %s", ttfb)
+	}
+	if ttfb.StartTransfer < 20*time.Millisecond || ttfb.ContentTransfer() < 20*time.Millisecond {
+		t.Fatalf("expect the time to first byte and the transfer to be measured, got %s", ttfb)
+	}
+	if ttfb.Total() < ttfb.StartTransfer+20*time.Millisecond {
+		t.Fatalf("expect the total to cover the measured phases, got %s", ttfb)
+	}
+
+	// Without the first byte, the server processing lasts until End.
+	none := get(0)
+	if none.ServerProcessing < 40*time.Millisecond {
+		t.Fatalf("expect the server processing to last until End, got %s", none)
+	}
+}
@@ -0,0 +1,69 @@
+// Package http3stat integrates go-httpstat with the HTTP/3 transport of
+// quic-go (https://github.com/quic-go/quic-go).
+//
+// The HTTP/3 transport of quic-go reports most httptrace hooks by itself,
+// which go-httpstat interprets as a QUIC connection, i.e. the QUIC handshake
+// is reported as TLSHandshake. Setting Dial as the dial function of the
+// transport additionally records whether a request was sent as 0-RTT early
+// data.
+package http3stat
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http/httptrace"
+
+	"github.com/jakobilobi/go-httpstat"
+	"github.com/quic-go/quic-go"
+)
+
+// Dial establishes a QUIC connection to addr. It is meant to be used as the
+// Dial function of http3.Transport, and reports the DNS lookup and the QUIC
+// handshake to the httptrace.ClientTrace of ctx.
+func Dial(ctx context.Context, addr string, tlsCfg *tls.Config, cfg *quic.Config) (*quic.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	udpAddr := net.JoinHostPort(ips[0].String(), port)
+
+	trace := httptrace.ContextClientTrace(ctx)
+	if trace != nil && trace.ConnectStart != nil {
+		trace.ConnectStart("udp", udpAddr)
+	}
+	if trace != nil && trace.TLSHandshakeStart != nil {
+		trace.TLSHandshakeStart()
+	}
+
+	conn, err := quic.DialAddrEarly(ctx, udpAddr, tlsCfg, cfg)
+
+	var state tls.ConnectionState
+	if conn != nil {
+		state = conn.ConnectionState().TLS
+	}
+	if trace != nil && trace.TLSHandshakeDone != nil {
+		trace.TLSHandshakeDone(state, err)
+	}
+	if trace != nil && trace.ConnectDone != nil {
+		trace.ConnectDone("udp", udpAddr, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// DialAddrEarly returns before the handshake is complete only when the
+	// connection can send 0-RTT data, which the request is then sent as.
+	if r, ok := httpstat.FromContext(ctx); ok {
+		select {
+		case <-conn.HandshakeComplete():
+		default:
+			r.Used0RTT = true
+		}
+	}
+	return conn, nil
+}
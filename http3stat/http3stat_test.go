@@ -0,0 +1,118 @@
+package http3stat
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jakobilobi/go-httpstat"
+	"github.com/quic-go/quic-go"
+	"github.com/quic-go/quic-go/http3"
+)
+
+func newServer(t *testing.T) (string, *x509.CertPool) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal("GenerateKey failed:", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal("CreateCertificate failed:", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal("ParseCertificate failed:", err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("ListenPacket failed:", err)
+	}
+	srv := &http3.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "ok")
+		}),
+		TLSConfig: http3.ConfigureTLSConfig(&tls.Config{
+			Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+		}),
+		QUICConfig: &quic.Config{Allow0RTT: true},
+	}
+	go srv.Serve(conn)
+	t.Cleanup(func() { srv.Close() })
+
+	return conn.LocalAddr().String(), pool
+}
+
+func get(t *testing.T, tr *http3.Transport, url string) *httpstat.Result {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Fatal("NewRequest failed:", err)
+	}
+	var result httpstat.Result
+	req = req.WithContext(httpstat.WithHTTPStat(req.Context(), &result))
+
+	res, err := (&http.Client{Transport: tr}).Do(req)
+	if err != nil {
+		t.Fatal("client.Do failed:", err)
+	}
+	if _, err := io.Copy(io.Discard, res.Body); err != nil {
+		t.Fatal("io.Copy failed:", err)
+	}
+	res.Body.Close()
+	result.EndNow()
+	return &result
+}
+
+func TestDial(t *testing.T) {
+	addr, pool := newServer(t)
+	tlsCfg := &tls.Config{
+		RootCAs:            pool,
+		ClientSessionCache: tls.NewLRUClientSessionCache(1),
+	}
+
+	tr1 := &http3.Transport{TLSClientConfig: tlsCfg, Dial: Dial}
+	defer tr1.Close()
+	result := get(t, tr1, "https://"+addr)
+
+	if result.TLSHandshake <= 0 {
+		t.Fatal("expect QUIC handshake to be reported as TLSHandshake")
+	}
+	if result.TCPConnection >= result.TLSHandshake {
+		t.Fatalf("TCPConnection = %v, expect it to be smaller than the QUIC handshake %v", result.TCPConnection, result.TLSHandshake)
+	}
+	if result.ServerProcessing <= 0 {
+		t.Fatal("expect ServerProcessing to be non-zero")
+	}
+	if result.Used0RTT {
+		t.Fatal("expect first connection not to use 0-RTT")
+	}
+	if got, want := result.Protocol, "h3"; got != want {
+		t.Fatalf("Protocol = %q, want %q", got, want)
+	}
+
+	// A new connection resuming the session sends the request as 0-RTT.
+	tr2 := &http3.Transport{TLSClientConfig: tlsCfg, Dial: Dial}
+	defer tr2.Close()
+	if result := get(t, tr2, "https://"+addr); !result.Used0RTT {
+		t.Fatal("expect resumed connection to use 0-RTT")
+	}
+}
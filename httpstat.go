@@ -4,11 +4,9 @@
 package httpstat
 
 import (
-	"bytes"
 	"context"
-	"fmt"
-	"io"
-	"strings"
+	"net/http/httptrace"
+	"sync"
 	"time"
 )
 
@@ -17,7 +15,9 @@ type Result struct {
 	// The following are the durations for each phase
 	DNSLookup        time.Duration
 	TCPConnection    time.Duration
+	ProxyConnect     time.Duration
 	TLSHandshake     time.Duration
+	ContinueWait     time.Duration
 	ServerProcessing time.Duration
 	contentTransfer  time.Duration
 
@@ -28,6 +28,88 @@ type Result struct {
 	StartTransfer time.Duration
 	total         time.Duration
 
+	// The following describe the response body, recorded when it is read
+	// through Body
+	BodyLength int64
+	BodyDigest []byte
+
+	// ServerTiming holds the metrics the server reported in the
+	// Server-Timing header, recorded when the body is read through Body
+	ServerTiming []ServerTiming
+
+	// ProxyURL is the proxy which tunneled the connection with a CONNECT
+	// request, or the SOCKS5 proxy, with any password redacted. It is
+	// recorded by OnProxyConnectResponse or ProxyFunc
+	ProxyURL string
+
+	// SocketPath is the path of the unix domain socket the connection was
+	// made to, if any. Its connect is then a socket connect rather than a
+	// TCP connection, without a DNS lookup
+	SocketPath string
+
+	// Protocol is the negotiated application protocol, as an ALPN protocol
+	// ID: "http/1.1", "h2", "h2c" (HTTP/2 without TLS) or "h3"
+	Protocol string
+
+	// StreamWait is, for HTTP/2, the time from writing the headers of the
+	// request stream to its first response byte. Unlike the connection
+	// phases, it is measured for requests on reused connections too
+	StreamWait time.Duration
+
+	// ConcurrentStreams is the number of other requests in flight on the
+	// connection when the request got it, which share it on HTTP/2. Only
+	// requests measured by httpstat are counted, until End is called on
+	// them
+	ConcurrentStreams int
+
+	// Downgraded reports whether HTTP/2 was offered in the TLS handshake,
+	// but the connection uses HTTP/1.1, losing multiplexing, as the server
+	// did not accept it. It is recorded once the offered protocols are
+	// known, see SetOfferedProtocols; a Transport records them for an
+	// http.Transport base
+	Downgraded bool
+
+	// Used0RTT reports whether the request was sent as QUIC 0-RTT early
+	// data. It is recorded by the http3stat integration
+	Used0RTT bool
+
+	// Decompression is the part of the content transfer spent on
+	// decompressing the response body, and CompressedLength the length of
+	// the body as transferred. They are recorded by a Transport with
+	// Decompress set
+	Decompression    time.Duration
+	CompressedLength int64
+
+	// TrailerWait is the time from the last body byte to the end of the
+	// body, at which the trailers of the response were received. It is
+	// only recorded by the body wrapper for responses with trailers, e.g.
+	// gRPC-web responses
+	TrailerWait time.Duration
+
+	// Stalled reports whether a read of the response body waited for
+	// longer than the stall window without a byte arriving. It is recorded
+	// by the body wrapper with WithStallDetection, or by a Transport with
+	// StallWindow set
+	Stalled bool
+
+	// DNSInfo details the queries of the DNS lookup. It is recorded when
+	// the name is resolved with a Resolver of NewResolver
+	DNSInfo *DNSInfo
+
+	// DoHResults measure the DNS over HTTPS exchanges of the DNS lookup. They
+	// are recorded when the name is resolved with a Resolver of
+	// NewDoHResolver
+	DoHResults []*Result
+
+	// StreamEvents are the times the events of a streamed response, such
+	// as Server-Sent Events, arrived. They are recorded by the body wrapper
+	// with WithStreamEvents, or by a Transport with StreamEvents set
+	StreamEvents []time.Time
+
+	// Metadata describes the request and its response. It is recorded by
+	// RecordResponse, or by a Transport with RecordMetadata set
+	Metadata *Metadata
+
 	t0 time.Time
 	t1 time.Time
 	t2 time.Time
@@ -35,18 +117,132 @@ type Result struct {
 	t4 time.Time
 	t5 time.Time // Needs to be provided from outside of httpstat
 
+	getConnStart  time.Time
 	dnsStart      time.Time
 	tcpStart      time.Time
+	tcpDone       time.Time
 	tlsStart      time.Time
+	continueStart time.Time
 	serverStart   time.Time
 	serverDone    time.Time
 	transferStart time.Time
+	streamStart   time.Time
+	lastByte      time.Time
+	trailersAt    time.Time
 
 	// isTLS is true when the connection seems to use TLS
 	isTLS bool
 
+	// phaseStarts holds the start of custom phases which did not end yet,
+	// customPhases the ended ones
+	phaseStarts  map[string]time.Time
+	customPhases []CustomPhase
+
+	// labels are the labels set with SetLabel
+	labels map[string]string
+
+	// observer is notified about completed phases
+	observer Observer
+
+	// connectAttempts are the attempts to connect, and connected is true
+	// once one succeeded
+	connectAttempts []ConnectAttempt
+	connected       bool
+
+	// proxyChosen is true when ProxyFunc chose the proxy, or no proxy, and
+	// isSOCKS when the connection is made through a SOCKS5 proxy
+	proxyChosen bool
+	isSOCKS     bool
+
+	// interim is true when the first response byte was the one of a 100
+	// Continue response, until the final response is recorded
+	interim bool
+
 	// isReused is true when the connection is reused (keep-alive)
 	isReused bool
+
+	// isQUIC is true when the connection is a QUIC connection (HTTP/3)
+	isQUIC bool
+
+	// isH2 is true when HTTP/2 pseudo header fields were written
+	isH2 bool
+
+	// stream is the connection the request is in flight on
+	stream *stream
+
+	// offered are the protocols offered in the TLS handshake, and alpn is
+	// true when the connection uses TLS, so Protocol was negotiated in it
+	offered []string
+	alpn    bool
+
+	// failed is true when the request failed in failedPhase at failedAt
+	failed      bool
+	failedPhase Phase
+	failedAt    time.Time
+
+	// unit is the unit durations are formatted in
+	unit Unit
+
+	// trace holds the hooks bound to traceOwner, see clientTrace
+	trace      *httptrace.ClientTrace
+	traceOwner *Result
+
+	// mu guards the fields the hooks record, as the transport calls them
+	// on its own goroutines, e.g. one per address of dual-stack dialing.
+	// It is created with the hooks; Results measured without them, e.g.
+	// decoded ones, have none
+	mu *sync.Mutex
+
+	// done is true once the request ended or failed. The hooks record
+	// nothing afterwards, see lockHook
+	done bool
+}
+
+// Reset clears r so it can measure another request. Reusing Results, e.g.
+// from a sync.Pool, saves building the trace hooks for every request.
+func (r *Result) Reset() {
+	mu := r.mu
+	r.lock()
+	r.stream.release()
+	*r = Result{trace: r.trace, traceOwner: r.traceOwner, mu: mu}
+	r.unlock()
+}
+
+// lock locks the fields r records in its hooks. It does nothing if r has
+// no hooks.
+func (r *Result) lock() {
+	if r.mu != nil {
+		r.mu.Lock()
+	}
+}
+
+func (r *Result) unlock() {
+	if r.mu != nil {
+		r.mu.Unlock()
+	}
+}
+
+// snapshot returns a copy of r taken with r locked, whose fields can be
+// read while the hooks still record into r.
+func (r *Result) snapshot() *Result {
+	r.lock()
+	defer r.unlock()
+	s := *r
+	s.mu = nil
+	return &s
+}
+
+// lockHook locks r for a hook, and reports whether the hook is to record
+// into r. An http.Transport does not wait for the dials of a request which
+// failed, or got another connection, so their hooks may still be called
+// once r was delivered; they are ignored. If false, r is not locked.
+func (r *Result) lockHook() bool {
+	r.lock()
+	if r.done {
+		r.unlock()
+		return false
+	}
+	return true
 }
 
 func (r *Result) durations() map[string]time.Duration {
@@ -59,71 +255,39 @@ func (r *Result) durations() map[string]time.Duration {
 
 		"NameLookup":    r.NameLookup,
 		"Connect":       r.Connect,
-		"Pretransfer":   r.Connect,
+		"Pretransfer":   r.Pretransfer,
 		"StartTransfer": r.StartTransfer,
 		"Total":         r.total,
 	}
 }
 
-// Format formats stats result.
-func (r Result) Format(s fmt.State, verb rune) {
-	switch verb {
-	case 'v':
-		if s.Flag('+') {
-			var buf bytes.Buffer
-			fmt.Fprintf(&buf, "DNS lookup:        %4d ms\n",
-				int(r.DNSLookup/time.Millisecond))
-			fmt.Fprintf(&buf, "TCP connection:    %4d ms\n",
-				int(r.TCPConnection/time.Millisecond))
-			fmt.Fprintf(&buf, "TLS handshake:     %4d ms\n",
-				int(r.TLSHandshake/time.Millisecond))
-			fmt.Fprintf(&buf, "Server processing: %4d ms\n",
-				int(r.ServerProcessing/time.Millisecond))
-
-			if r.total > 0 {
-				fmt.Fprintf(&buf, "Content transfer:  %4d ms\n\n",
-					int(r.contentTransfer/time.Millisecond))
-			} else {
-				fmt.Fprintf(&buf, "Content transfer:  %4s ms\n\n", "-")
-			}
-
-			fmt.Fprintf(&buf, "Name Lookup:    %4d ms\n",
-				int(r.NameLookup/time.Millisecond))
-			fmt.Fprintf(&buf, "Connect:        %4d ms\n",
-				int(r.Connect/time.Millisecond))
-			fmt.Fprintf(&buf, "Pre Transfer:   %4d ms\n",
-				int(r.Pretransfer/time.Millisecond))
-			fmt.Fprintf(&buf, "Start Transfer: %4d ms\n",
-				int(r.StartTransfer/time.Millisecond))
-
-			if r.total > 0 {
-				fmt.Fprintf(&buf, "Total:          %4d ms\n",
-					int(r.total/time.Millisecond))
-			} else {
-				fmt.Fprintf(&buf, "Total:          %4s ms\n", "-")
-			}
-			io.WriteString(s, buf.String())
-			return
-		}
-
-		fallthrough
-	case 's', 'q':
-		d := r.durations()
-		list := make([]string, 0, len(d))
-		for k, v := range d {
-			// Handle when End function is not called
-			if (k == "ContentTransfer" || k == "Total") && r.t5.IsZero() {
-				list = append(list, fmt.Sprintf("%s: - ms", k))
-				continue
-			}
-			list = append(list, fmt.Sprintf("%s: %d ms", k, v/time.Millisecond))
-		}
-		io.WriteString(s, strings.Join(list, ", "))
-	}
-}
-
 // WithHTTPStat is a wrapper of httptrace.WithClientTrace. It records the
-// time of each httptrace hook.
+// time of each httptrace hook. A ClientTrace already in ctx keeps working,
+// its hooks are called after the ones of r.
 func WithHTTPStat(ctx context.Context, r *Result) context.Context {
+	ctx = context.WithValue(ctx, resultKey{}, r)
 	return withClientTrace(ctx, r)
 }
+
+// WithHTTPStatTrace is like WithHTTPStat, and additionally calls the hooks
+// of trace, e.g. of other httptrace based instrumentation. The hooks of
+// trace are called after the ones of r, so they see the updated Result.
+// trace is not modified.
+func WithHTTPStatTrace(ctx context.Context, r *Result, trace *httptrace.ClientTrace) context.Context {
+	if trace != nil {
+		// WithClientTrace composes the given trace in place.
+		t := *trace
+		ctx = httptrace.WithClientTrace(ctx, &t)
+	}
+	return WithHTTPStat(ctx, r)
+}
+
+type resultKey struct{}
+
+// FromContext returns the Result registered in ctx with WithHTTPStat, so
+// that layers which only see the context of a request (interceptors, retry
+// wrappers) can fetch and annotate it.
+func FromContext(ctx context.Context) (*Result, bool) {
+	r, ok := ctx.Value(resultKey{}).(*Result)
+	return r, ok
+}
@@ -2,10 +2,14 @@ package httpstat
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"strings"
 	"testing"
 	"time"
 )
@@ -61,7 +65,7 @@ func TestHTTPStat_HTTPS(t *testing.T) {
 		t.Fatal("io.Copy failed:", err)
 	}
 	res.Body.Close()
-	result.End()
+	result.EndNow()
 
 	if !result.isTLS {
 		t.Fatal("isTLS should be true")
@@ -88,7 +92,7 @@ func TestHTTPStat_HTTP(t *testing.T) {
 		t.Fatal("io.Copy failed:", err)
 	}
 	res.Body.Close()
-	result.End()
+	result.EndNow()
 
 	if result.isTLS {
 		t.Fatal("isTLS should be false")
@@ -139,7 +143,7 @@ func TestHTTPStat_KeepAlive(t *testing.T) {
 		t.Fatal("Copy body failed:", err)
 	}
 	res2.Body.Close()
-	result.End()
+	result.EndNow()
 
 	// The following values should be zero.
 	// Because connection is reused.
@@ -183,25 +187,21 @@ func TestHTTPStat_beforeGO17(t *testing.T) {
 		t.Fatal("io.Copy failed:", err)
 	}
 	res.Body.Close()
-	result.End()
+	result.EndNow()
 
-	// The following values are not mesured.
-	durations := []time.Duration{
-		result.DNSLookup,
-		result.TCPConnection,
-		// result.TLSHandshake,
+	// The dialer does not report the DNS lookup, so the whole dial is
+	// measured as the connect.
+	if got, want := result.DNSLookup, 0*time.Millisecond; got != want {
+		t.Fatalf("expect %d to be eq %d", got, want)
 	}
-
-	for i, d := range durations {
-		if got, want := d, 0*time.Millisecond; got != want {
-			t.Fatalf("#%d expect %d to be eq %d", i, got, want)
-		}
+	if result.TCPConnection <= 0 {
+		t.Fatal("expect the dial to be measured")
 	}
 }
 
 func TestTotal_Zero(t *testing.T) {
 	result := &Result{}
-	result.End()
+	result.EndNow()
 
 	zero := 0 * time.Millisecond
 	if result.total != zero {
@@ -213,6 +213,22 @@ func TestTotal_Zero(t *testing.T) {
 	}
 }
 
+func TestEnd_Time(t *testing.T) {
+	start := time.Now()
+	result := &Result{dnsStart: start, transferStart: start.Add(30 * time.Millisecond)}
+	result.End(start.Add(100 * time.Millisecond))
+
+	if got, want := result.Total(), 100*time.Millisecond; got != want {
+		t.Fatalf("Total time is %v, want %v", got, want)
+	}
+	if got, want := result.ContentTransfer(), 70*time.Millisecond; got != want {
+		t.Fatalf("ContentTransfer time is %v, want %v", got, want)
+	}
+	if got, want := result.EndAt(), start.Add(100*time.Millisecond); !got.Equal(want) {
+		t.Fatalf("EndAt is %v, want %v", got, want)
+	}
+}
+
 func TestContentTransfer(t *testing.T) {
 	var result Result
 	req := NewRequest(t, TestDomainHTTPS, &result)
@@ -235,7 +251,7 @@ func TestContentTransfer(t *testing.T) {
 	}
 
 	// Call End() to mark end of HTTP request (usually done right after reading response body).
-	result.End()
+	result.EndNow()
 
 	// Expect content transfer times to be equal when called after End().
 	ct1 = result.ContentTransfer()
@@ -268,7 +284,7 @@ func TestTotal(t *testing.T) {
 	}
 
 	// Call End() to mark end of HTTP request (usually done right after reading response body).
-	result.End()
+	result.EndNow()
 
 	// Expect total times to be equal when called after End().
 	total1 = result.Total()
@@ -314,3 +330,306 @@ Total:           100 ms
 		t.Fatalf("expect to be eq:\n\nwant:\n\n%s\ngot:\n\n%s\n", want, got)
 	}
 }
+
+func TestHTTPStat_FormatterPercent(t *testing.T) {
+	result := Result{
+		DNSLookup:        50 * time.Millisecond,
+		TCPConnection:    50 * time.Millisecond,
+		TLSHandshake:     100 * time.Millisecond,
+		ServerProcessing: 250 * time.Millisecond,
+		contentTransfer:  50 * time.Millisecond,
+
+		NameLookup:    50 * time.Millisecond,
+		Connect:       100 * time.Millisecond,
+		Pretransfer:   200 * time.Millisecond,
+		StartTransfer: 450 * time.Millisecond,
+		total:         500 * time.Millisecond,
+	}
+
+	want := `DNS lookup:          50 ms   10.0%
+TCP connection:      50 ms   10.0%
+TLS handshake:      100 ms   20.0%
+Server processing:  250 ms   50.0%
+Content transfer:    50 ms   10.0%
+
+Name Lookup:      50 ms
+Connect:         100 ms
+Pre Transfer:    200 ms
+Start Transfer:  450 ms
+Total:           500 ms
+`
+	if got := fmt.Sprintf("%#+v", result); want != got {
+		t.Fatalf("expect to be eq:\n\nwant:\n\n%s\ngot:\n\n%s\n", want, got)
+	}
+
+	if got, want := fmt.Sprintf("%#s", result), "ServerProcessing: 250 ms (50.0%)"; !strings.Contains(got, want) {
+		t.Fatalf("expect %q in %q", want, got)
+	}
+
+	// Without a total there is nothing to compare the phases to.
+	result.total = 0
+	if got := fmt.Sprintf("%#s", result); strings.Contains(got, "%") {
+		t.Fatalf("expect no percentages in %q", got)
+	}
+}
+
+func TestHTTPStat_ExpectContinue(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		if _, err := io.Copy(io.Discard, r.Body); err != nil {
+			t.Error("io.Copy failed:", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+		io.WriteString(w, "ok")
+	}))
+	defer ts.Close()
+
+	req, err := http.NewRequest("POST", ts.URL, strings.NewReader("body"))
+	if err != nil {
+		t.Fatal("NewRequest failed:", err)
+	}
+	req.Header.Set("Expect", "100-continue")
+
+	var result Result
+	req = req.WithContext(WithHTTPStat(req.Context(), &result))
+
+	client := DefaultClient()
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal("client.Do failed:", err)
+	}
+	if _, err := io.Copy(io.Discard, res.Body); err != nil {
+		t.Fatal("io.Copy failed:", err)
+	}
+	res.Body.Close()
+	result.EndNow()
+
+	if result.ContinueWait < 50*time.Millisecond {
+		t.Fatalf("ContinueWait = %v, want at least 50ms", result.ContinueWait)
+	}
+	if result.ServerProcessing < 20*time.Millisecond || result.ServerProcessing >= result.ContinueWait {
+		t.Fatalf("ServerProcessing = %v, want between 20ms and %v", result.ServerProcessing, result.ContinueWait)
+	}
+	if result.StartTransfer < result.ContinueWait+result.ServerProcessing {
+		t.Fatalf("StartTransfer = %v, want at least %v", result.StartTransfer, result.ContinueWait+result.ServerProcessing)
+	}
+}
+
+func TestTransport_ExpectContinueTransfer(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		time.Sleep(20 * time.Millisecond)
+		io.WriteString(w, "first")
+		w.(http.Flusher).Flush()
+		time.Sleep(50 * time.Millisecond)
+		io.WriteString(w, "second")
+	}))
+	defer ts.Close()
+
+	req, err := http.NewRequest("POST", ts.URL, strings.NewReader("body"))
+	if err != nil {
+		t.Fatal("NewRequest failed:", err)
+	}
+	req.Header.Set("Expect", "100-continue")
+
+	var result *Result
+	client := &http.Client{Transport: &Transport{
+		Base:     DefaultTransport(),
+		OnResult: func(_ *http.Request, r *Result, _ error) { result = r },
+	}}
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal("client.Do failed:", err)
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+
+	// The final response arrives after the interim 100 Continue, and its
+	// body is transferred apart from the server processing.
+	if result.ServerProcessing < 20*time.Millisecond || result.ServerProcessing >= 50*time.Millisecond {
+		t.Fatalf("ServerProcessing = %v, want between 20ms and 50ms", result.ServerProcessing)
+	}
+	if ct := result.ContentTransfer(); ct < 40*time.Millisecond {
+		t.Fatalf("ContentTransfer = %v, want at least 40ms", ct)
+	}
+}
+
+func TestHTTPStat_FormatterServerTiming(t *testing.T) {
+	result := Result{
+		ServerProcessing: 100 * time.Millisecond,
+		ServerTiming: []ServerTiming{
+			{Name: "db", Duration: 53 * time.Millisecond, Description: "Database"},
+			{Name: "app", Duration: 40 * time.Millisecond},
+		},
+	}
+
+	want := `
+Server timing:
+  db:               53 ms  (Database)
+  app:              40 ms
+`
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%+v", result)
+	if got := buf.String(); !strings.HasSuffix(got, want) {
+		t.Fatalf("expect to end with:\n\n%s\ngot:\n\n%s\n", want, got)
+	}
+}
+
+func TestHTTPStat_Protocol(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	})
+
+	ts1 := httptest.NewServer(handler)
+	defer ts1.Close()
+
+	ts2 := httptest.NewUnstartedServer(handler)
+	ts2.EnableHTTP2 = true
+	ts2.StartTLS()
+	defer ts2.Close()
+
+	cases := []struct {
+		client *http.Client
+		url    string
+		want   string
+	}{
+		{DefaultClient(), ts1.URL, "http/1.1"},
+		{ts2.Client(), ts2.URL, "h2"},
+		// The second request reuses the connection of the first one.
+		{ts2.Client(), ts2.URL, "h2"},
+	}
+
+	for i, tc := range cases {
+		var result Result
+		req := NewRequest(t, tc.url, &result)
+		res, err := tc.client.Do(req)
+		if err != nil {
+			t.Fatal("client.Do failed:", err)
+		}
+		if _, err := io.Copy(io.Discard, res.Body); err != nil {
+			t.Fatal("io.Copy failed:", err)
+		}
+		res.Body.Close()
+		result.EndNow()
+
+		if got := result.Protocol; got != tc.want {
+			t.Fatalf("#%d Protocol = %q, want %q", i, got, tc.want)
+		}
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	if _, ok := FromContext(context.Background()); ok {
+		t.Fatal("expect no Result in background context")
+	}
+
+	var result Result
+	ctx := WithHTTPStat(context.Background(), &result)
+	if got, ok := FromContext(ctx); !ok || got != &result {
+		t.Fatalf("FromContext = %p, %t, want %p, true", got, ok, &result)
+	}
+}
+
+func TestResult_Reset(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+
+	get := func(r *Result) {
+		req, _ := http.NewRequestWithContext(WithHTTPStat(context.Background(), r), "GET", ts.URL, nil)
+		res, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+		r.EndNow()
+	}
+
+	var result Result
+	get(&result)
+	result.Reset()
+	if result.total != 0 || result.TCPConnection != 0 {
+		t.Fatalf("expect a reset Result to be empty, got %s", result)
+	}
+	get(&result)
+	if result.TCPConnection <= 0 || result.Total() <= 0 {
+		t.Fatalf("expect a reset Result to measure again, got %s", result)
+	}
+
+	// The hooks of a copy must record into the copy.
+	end := result.EndAt()
+	cp := result
+	cp.Reset()
+	get(&cp)
+	if cp.Total() <= 0 {
+		t.Fatalf("expect the copy to be measured, got %s", cp)
+	}
+	if !result.EndAt().Equal(end) || !result.ConnectStartAt().Before(end) {
+		t.Fatal("expect the original Result not to be measured again")
+	}
+}
+
+func TestWithHTTPStat_ReusedTraceAllocs(t *testing.T) {
+	ctx := context.Background()
+	fresh := testing.AllocsPerRun(100, func() {
+		WithHTTPStat(ctx, &Result{})
+	})
+	var result Result
+	reused := testing.AllocsPerRun(100, func() {
+		result.Reset()
+		WithHTTPStat(ctx, &result)
+	})
+	if reused*2 > fresh {
+		t.Fatalf("WithHTTPStat allocates %v times for a reused Result and %v for a fresh one", reused, fresh)
+	}
+}
+
+func TestWithHTTPStatTrace(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	var inCtx, chained []string
+	var seenConnect time.Duration
+	outer := &httptrace.ClientTrace{
+		GotFirstResponseByte: func() { inCtx = append(inCtx, "GotFirstResponseByte") },
+	}
+	var result Result
+	trace := &httptrace.ClientTrace{
+		ConnectDone: func(network, addr string, err error) {
+			chained = append(chained, "ConnectDone")
+			seenConnect = result.TCPConnection
+		},
+		GotFirstResponseByte: func() { chained = append(chained, "GotFirstResponseByte") },
+	}
+
+	ctx := httptrace.WithClientTrace(context.Background(), outer)
+	ctx = WithHTTPStatTrace(ctx, &result, trace)
+	req, _ := http.NewRequestWithContext(ctx, "GET", ts.URL, nil)
+	res, err := new(http.Transport).RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+	result.EndNow()
+
+	if got, want := strings.Join(chained, ","), "ConnectDone,GotFirstResponseByte"; got != want {
+		t.Fatalf("chained hooks = %s, want %s", got, want)
+	}
+	if got, want := strings.Join(inCtx, ","), "GotFirstResponseByte"; got != want {
+		t.Fatalf("hooks in context = %s, want %s", got, want)
+	}
+	if seenConnect <= 0 || seenConnect != result.TCPConnection {
+		t.Fatalf("chained hook saw TCPConnection %v, want %v", seenConnect, result.TCPConnection)
+	}
+	if result.Total() <= 0 {
+		t.Fatal("expect the request to be measured")
+	}
+	// Had trace been composed in place, its hook would call the outer one.
+	inCtx = nil
+	trace.GotFirstResponseByte()
+	if len(inCtx) != 0 {
+		t.Fatal("expect trace not to be modified")
+	}
+}
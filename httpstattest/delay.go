@@ -0,0 +1,91 @@
+package httpstattest
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Delays are the delays injected by a server started with NewDelayed.
+type Delays struct {
+	// Accept delays serving each accepted connection. The TCP connection
+	// itself is established by the kernel, so clients see the delay in
+	// the phase following the connect (i.e. the TLS handshake).
+	Accept time.Duration
+
+	// Handshake delays each TLS handshake.
+	Handshake time.Duration
+
+	// Header delays writing the response header.
+	Header time.Duration
+
+	// Body delays writing each of the BodyChunks chunks of the response
+	// body.
+	Body       time.Duration
+	BodyChunks int
+}
+
+// NewDelayed starts an HTTP/1.1 server over TLS which injects the given
+// delays, so that the phases measured by clients are known in advance.
+func NewDelayed(tb testing.TB, d Delays) *Server {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(d.Header)
+		w.WriteHeader(http.StatusOK)
+		for i := 0; i < d.BodyChunks; i++ {
+			if flusher, ok := w.(http.Flusher); ok {
+				flusher.Flush()
+			}
+			time.Sleep(d.Body)
+			io.WriteString(w, "ok\n")
+		}
+	}))
+	ts.Listener = &delayListener{Listener: ts.Listener, delay: d.Accept}
+	ts.TLS = delayHandshake(d.Handshake)
+	ts.StartTLS()
+
+	s := &Server{Server: ts, Protocol: "http/1.1"}
+	tb.Cleanup(s.Close)
+	return s
+}
+
+// delayHandshake returns a TLS config delaying each handshake by delay.
+func delayHandshake(delay time.Duration) *tls.Config {
+	return &tls.Config{
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			time.Sleep(delay)
+			return nil, nil
+		},
+	}
+}
+
+// delayListener delays serving the connections it accepts. The delay is
+// applied by the first read of each connection, on the goroutine serving
+// it, so connections accepted together are delayed concurrently.
+type delayListener struct {
+	net.Listener
+	delay time.Duration
+}
+
+func (l *delayListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil || l.delay <= 0 {
+		return c, err
+	}
+	return &delayConn{Conn: c, delay: l.delay}, nil
+}
+
+type delayConn struct {
+	net.Conn
+	delay time.Duration
+	once  sync.Once
+}
+
+func (c *delayConn) Read(b []byte) (int, error) {
+	c.once.Do(func() { time.Sleep(c.delay) })
+	return c.Conn.Read(b)
+}
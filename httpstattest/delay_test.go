@@ -0,0 +1,93 @@
+package httpstattest
+
+import (
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jakobilobi/go-httpstat"
+)
+
+func TestNewDelayed(t *testing.T) {
+	ms := time.Millisecond
+	s := NewDelayed(t, Delays{
+		Accept:     20 * ms,
+		Handshake:  30 * ms,
+		Header:     40 * ms,
+		Body:       10 * ms,
+		BodyChunks: 5,
+	})
+
+	req, err := http.NewRequest("GET", s.URL, nil)
+	if err != nil {
+		t.Fatal("NewRequest failed:", err)
+	}
+	var result httpstat.Result
+	req = req.WithContext(httpstat.WithHTTPStat(req.Context(), &result))
+
+	res, err := s.Client().Do(req)
+	if err != nil {
+		t.Fatal("client.Do failed:", err)
+	}
+	if _, err := io.Copy(io.Discard, res.Body); err != nil {
+		t.Fatal("io.Copy failed:", err)
+	}
+	res.Body.Close()
+	result.EndNow()
+
+	cases := []struct {
+		name      string
+		got, want time.Duration
+	}{
+		{"TLSHandshake", result.TLSHandshake, 50 * ms},
+		{"ServerProcessing", result.ServerProcessing, 40 * ms},
+		{"ContentTransfer", result.ContentTransfer(), 50 * ms},
+	}
+	for _, tc := range cases {
+		if tc.got < tc.want || tc.got > tc.want*2 {
+			t.Fatalf("%s = %v, want about %v", tc.name, tc.got, tc.want)
+		}
+	}
+}
+
+func TestNewDelayed_AcceptConcurrent(t *testing.T) {
+	// The accept delay of a connection does not hold up the others.
+	const delay = 100 * time.Millisecond
+	s := NewDelayed(t, Delays{Accept: delay})
+	client := s.Client()
+	client.Transport.(*http.Transport).DisableKeepAlives = true
+
+	start := time.Now()
+	errs := make(chan error, 3)
+	for i := 0; i < cap(errs); i++ {
+		go func() {
+			res, err := client.Get(s.URL)
+			if err == nil {
+				res.Body.Close()
+			}
+			errs <- err
+		}()
+	}
+	for i := 0; i < cap(errs); i++ {
+		if err := <-errs; err != nil {
+			t.Fatal("Get failed:", err)
+		}
+	}
+	if d := time.Since(start); d >= 2*delay {
+		t.Fatalf("expect the connections to be delayed concurrently, took %v", d)
+	}
+}
+
+func TestNewSlowHandshake(t *testing.T) {
+	s := NewSlowHandshake(t, 20*time.Millisecond)
+	res, err := s.Client().Get(s.URL + "/redirect/2")
+	if err != nil {
+		t.Fatal("Get failed:", err)
+	}
+	b, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if res.Request.URL.Path != "/" || string(b) != "ok" {
+		t.Fatalf("expect the redirects of Handler to be followed, got %s %q", res.Request.URL.Path, b)
+	}
+}
@@ -0,0 +1,29 @@
+//go:build go1.24
+// +build go1.24
+
+package httpstattest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// NewH2C starts an HTTP/2 server without TLS (h2c). Its client speaks
+// HTTP/2 with prior knowledge.
+func NewH2C(tb testing.TB) *Server {
+	ts := httptest.NewUnstartedServer(Handler())
+	ts.Config.Protocols = new(http.Protocols)
+	ts.Config.Protocols.SetUnencryptedHTTP2(true)
+	ts.Start()
+
+	tr := &http.Transport{Protocols: new(http.Protocols)}
+	tr.Protocols.SetUnencryptedHTTP2(true)
+
+	s := &Server{Server: ts, Protocol: "h2c", client: &http.Client{Transport: tr}}
+	tb.Cleanup(func() {
+		tr.CloseIdleConnections()
+		s.Close()
+	})
+	return s
+}
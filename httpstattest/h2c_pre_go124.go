@@ -0,0 +1,14 @@
+//go:build !go1.24
+// +build !go1.24
+
+package httpstattest
+
+import (
+	"testing"
+)
+
+// NewH2C skips the test, since h2c servers require go1.24.
+func NewH2C(tb testing.TB) *Server {
+	tb.Skip("h2c requires go1.24")
+	return nil
+}
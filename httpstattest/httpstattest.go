@@ -0,0 +1,101 @@
+// Package httpstattest provides local test servers for the protocol variants
+// go-httpstat has to handle (HTTP/1.1, HTTP/2, h2c, TLS 1.2 and 1.3, slow
+// handshakes and redirects) and servers with injected per-phase delays. They
+// are used by the tests of go-httpstat and can be reused to validate other
+// instrumentation stacks.
+package httpstattest
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Server is a local test server.
+type Server struct {
+	*httptest.Server
+
+	// Protocol is the application protocol the server speaks, as an ALPN
+	// protocol ID ("http/1.1", "h2" or "h2c").
+	Protocol string
+
+	client *http.Client
+}
+
+// Client returns an HTTP client configured to talk to the server.
+func (s *Server) Client() *http.Client {
+	if s.client != nil {
+		return s.client
+	}
+	return s.Server.Client()
+}
+
+// Handler returns the handler of the test servers. It responds with "ok",
+// except for paths of the form /redirect/N, which redirect N times before
+// responding.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if n, ok := strings.CutPrefix(r.URL.Path, "/redirect/"); ok {
+			left, err := strconv.Atoi(n)
+			if err != nil || left < 0 {
+				http.Error(w, "invalid redirect count", http.StatusBadRequest)
+				return
+			}
+			target := "/"
+			if left > 1 {
+				target = fmt.Sprintf("/redirect/%d", left-1)
+			}
+			http.Redirect(w, r, target, http.StatusFound)
+			return
+		}
+		io.WriteString(w, "ok")
+	})
+}
+
+// NewHTTP1 starts a plain HTTP/1.1 server.
+func NewHTTP1(tb testing.TB) *Server {
+	s := &Server{Server: httptest.NewServer(Handler()), Protocol: "http/1.1"}
+	tb.Cleanup(s.Close)
+	return s
+}
+
+// NewHTTP2 starts an HTTP/2 server over TLS.
+func NewHTTP2(tb testing.TB) *Server {
+	ts := httptest.NewUnstartedServer(Handler())
+	ts.EnableHTTP2 = true
+	ts.StartTLS()
+	s := &Server{Server: ts, Protocol: "h2"}
+	tb.Cleanup(s.Close)
+	return s
+}
+
+// NewTLS starts an HTTP/1.1 server over TLS which only accepts the given
+// TLS version (e.g. tls.VersionTLS12).
+func NewTLS(tb testing.TB, version uint16) *Server {
+	ts := httptest.NewUnstartedServer(Handler())
+	ts.TLS = &tls.Config{
+		MinVersion: version,
+		MaxVersion: version,
+	}
+	ts.StartTLS()
+	s := &Server{Server: ts, Protocol: "http/1.1"}
+	tb.Cleanup(s.Close)
+	return s
+}
+
+// NewSlowHandshake starts an HTTP/1.1 server over TLS which delays each TLS
+// handshake by delay.
+func NewSlowHandshake(tb testing.TB, delay time.Duration) *Server {
+	ts := httptest.NewUnstartedServer(Handler())
+	ts.TLS = delayHandshake(delay)
+	ts.StartTLS()
+	s := &Server{Server: ts, Protocol: "http/1.1"}
+	tb.Cleanup(s.Close)
+	return s
+}
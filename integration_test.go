@@ -0,0 +1,99 @@
+//go:build integration
+// +build integration
+
+package httpstat_test
+
+import (
+	"crypto/tls"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jakobilobi/go-httpstat"
+	"github.com/jakobilobi/go-httpstat/httpstattest"
+)
+
+func measure(t *testing.T, client *http.Client, url string) *httpstat.Result {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		t.Fatal("NewRequest failed:", err)
+	}
+	var result httpstat.Result
+	req = req.WithContext(httpstat.WithHTTPStat(req.Context(), &result))
+
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal("client.Do failed:", err)
+	}
+	if _, err := io.Copy(io.Discard, res.Body); err != nil {
+		t.Fatal("io.Copy failed:", err)
+	}
+	res.Body.Close()
+	result.EndNow()
+	return &result
+}
+
+func TestIntegration_Protocols(t *testing.T) {
+	cases := []struct {
+		name   string
+		server func(testing.TB) *httpstattest.Server
+		tls    bool
+	}{
+		{"HTTP/1.1", httpstattest.NewHTTP1, false},
+		{"HTTP/2", httpstattest.NewHTTP2, true},
+		{"h2c", httpstattest.NewH2C, false},
+		{"TLS 1.2", func(tb testing.TB) *httpstattest.Server { return httpstattest.NewTLS(tb, tls.VersionTLS12) }, true},
+		{"TLS 1.3", func(tb testing.TB) *httpstattest.Server { return httpstattest.NewTLS(tb, tls.VersionTLS13) }, true},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := tc.server(t)
+			result := measure(t, s.Client(), s.URL)
+
+			if got, want := result.Protocol, s.Protocol; got != want {
+				t.Fatalf("Protocol = %q, want %q", got, want)
+			}
+			if tc.tls && result.TLSHandshake <= 0 {
+				t.Fatal("expect TLSHandshake to be non-zero")
+			}
+			if !tc.tls && result.TLSHandshake != 0 {
+				t.Fatalf("TLSHandshake = %v, want 0", result.TLSHandshake)
+			}
+			if result.TCPConnection <= 0 || result.ServerProcessing <= 0 || result.Total() <= 0 {
+				t.Fatalf("expect TCPConnection, ServerProcessing and Total to be non-zero: %+v", result)
+			}
+
+			// The second request reuses the connection.
+			result = measure(t, s.Client(), s.URL)
+			if result.TCPConnection != 0 || result.TLSHandshake != 0 {
+				t.Fatalf("expect reused connection to skip connect and TLS handshake: %+v", result)
+			}
+			if got, want := result.Protocol, s.Protocol; got != want {
+				t.Fatalf("Protocol of reused connection = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestIntegration_SlowHandshake(t *testing.T) {
+	s := httpstattest.NewSlowHandshake(t, 50*time.Millisecond)
+	result := measure(t, s.Client(), s.URL)
+
+	if result.TLSHandshake < 50*time.Millisecond {
+		t.Fatalf("TLSHandshake = %v, want at least 50ms", result.TLSHandshake)
+	}
+	if result.TCPConnection >= 50*time.Millisecond {
+		t.Fatalf("TCPConnection = %v, expect the handshake delay not to be attributed to it", result.TCPConnection)
+	}
+}
+
+func TestIntegration_Redirects(t *testing.T) {
+	s := httpstattest.NewHTTP1(t)
+	result := measure(t, s.Client(), s.URL+"/redirect/3")
+
+	if result.StartTransfer <= 0 || result.Total() < result.StartTransfer {
+		t.Fatalf("expect redirected request to be measured: %+v", result)
+	}
+}
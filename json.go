@@ -0,0 +1,154 @@
+package httpstat
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type jsonResult struct {
+	Protocol   string `json:"protocol,omitempty"`
+	ProxyURL   string `json:"proxyURL,omitempty"`
+	SocketPath string `json:"socketPath,omitempty"`
+
+	DNSLookup        time.Duration `json:"dnsLookup"`
+	TCPConnection    time.Duration `json:"tcpConnection"`
+	ProxyConnect     time.Duration `json:"proxyConnect,omitempty"`
+	TLSHandshake     time.Duration `json:"tlsHandshake"`
+	ContinueWait     time.Duration `json:"continueWait,omitempty"`
+	ServerProcessing time.Duration `json:"serverProcessing"`
+	ContentTransfer  time.Duration `json:"contentTransfer"`
+	TrailerWait      time.Duration `json:"trailerWait,omitempty"`
+	Decompression    time.Duration `json:"decompression,omitempty"`
+	StreamWait       time.Duration `json:"streamWait,omitempty"`
+
+	NameLookup    time.Duration `json:"nameLookup"`
+	Connect       time.Duration `json:"connect"`
+	Pretransfer   time.Duration `json:"pretransfer"`
+	StartTransfer time.Duration `json:"startTransfer"`
+	Total         time.Duration `json:"total"`
+
+	BodyLength       int64              `json:"bodyLength,omitempty"`
+	CompressedLength int64              `json:"compressedLength,omitempty"`
+	BodyDigest       []byte             `json:"bodyDigest,omitempty"`
+	ServerTiming     []jsonServerTiming `json:"serverTiming,omitempty"`
+	CustomPhases     []jsonCustomPhase  `json:"customPhases,omitempty"`
+	Labels           map[string]string  `json:"labels,omitempty"`
+	Metadata         *Metadata          `json:"metadata,omitempty"`
+
+	FailedPhase string `json:"failedPhase,omitempty"`
+	Stalled     bool   `json:"stalled,omitempty"`
+	Downgraded  bool   `json:"downgraded,omitempty"`
+
+	ConcurrentStreams int `json:"concurrentStreams,omitempty"`
+}
+
+type jsonCustomPhase struct {
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"dur"`
+}
+
+type jsonServerTiming struct {
+	Name        string        `json:"name"`
+	Duration    time.Duration `json:"dur"`
+	Description string        `json:"desc,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler. Durations are encoded as
+// nanoseconds, like time.Duration.
+func (r Result) MarshalJSON() ([]byte, error) {
+	j := jsonResult{
+		Protocol:   r.Protocol,
+		ProxyURL:   r.ProxyURL,
+		SocketPath: r.SocketPath,
+
+		DNSLookup:        r.DNSLookup,
+		TCPConnection:    r.TCPConnection,
+		ProxyConnect:     r.ProxyConnect,
+		TLSHandshake:     r.TLSHandshake,
+		ContinueWait:     r.ContinueWait,
+		ServerProcessing: r.ServerProcessing,
+		ContentTransfer:  r.contentTransfer,
+		TrailerWait:      r.TrailerWait,
+		Decompression:    r.Decompression,
+		StreamWait:       r.StreamWait,
+
+		NameLookup:    r.NameLookup,
+		Connect:       r.Connect,
+		Pretransfer:   r.Pretransfer,
+		StartTransfer: r.StartTransfer,
+		Total:         r.total,
+
+		BodyLength:        r.BodyLength,
+		CompressedLength:  r.CompressedLength,
+		BodyDigest:        r.BodyDigest,
+		Labels:            r.labels,
+		Metadata:          r.Metadata,
+		Stalled:           r.Stalled,
+		Downgraded:        r.Downgraded,
+		ConcurrentStreams: r.ConcurrentStreams,
+	}
+	for _, st := range r.ServerTiming {
+		j.ServerTiming = append(j.ServerTiming, jsonServerTiming(st))
+	}
+	for _, p := range r.customPhases {
+		j.CustomPhases = append(j.CustomPhases, jsonCustomPhase{Name: p.Name, Duration: p.Duration})
+	}
+	if p, failed := r.FailedPhase(); failed {
+		j.FailedPhase = p.String()
+	}
+	return json.Marshal(j)
+}
+
+type jsonServerResult struct {
+	TimeToHandler   time.Duration `json:"timeToHandler"`
+	HandlerDuration time.Duration `json:"handler"`
+	ResponseWrite   time.Duration `json:"responseWrite"`
+	BytesWritten    int64         `json:"bytesWritten"`
+	StatusCode      int           `json:"statusCode"`
+	ReceivedAt      *time.Time    `json:"receivedAt,omitempty"`
+	RespondedAt     *time.Time    `json:"respondedAt,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler. Durations are encoded as
+// nanoseconds, like time.Duration.
+func (r ServerResult) MarshalJSON() ([]byte, error) {
+	j := jsonServerResult{
+		TimeToHandler:   r.TimeToHandler,
+		HandlerDuration: r.HandlerDuration,
+		ResponseWrite:   r.ResponseWrite,
+		BytesWritten:    r.BytesWritten,
+		StatusCode:      r.StatusCode,
+	}
+	if !r.ReceivedAt.IsZero() {
+		j.ReceivedAt = &r.ReceivedAt
+	}
+	if !r.RespondedAt.IsZero() {
+		j.RespondedAt = &r.RespondedAt
+	}
+	return json.Marshal(j)
+}
+
+type jsonRecord struct {
+	Time       time.Time `json:"time"`
+	Method     string    `json:"method"`
+	URL        string    `json:"url"`
+	StatusCode int       `json:"statusCode,omitempty"`
+	Err        string    `json:"error,omitempty"`
+	Result     *Result   `json:"result,omitempty"`
+}
+
+// MarshalJSON implements json.Marshaler. The error is encoded as its
+// message.
+func (rec Record) MarshalJSON() ([]byte, error) {
+	j := jsonRecord{
+		Time:       rec.Time,
+		Method:     rec.Method,
+		URL:        rec.URL,
+		StatusCode: rec.StatusCode,
+		Result:     rec.Result,
+	}
+	if rec.Err != nil {
+		j.Err = rec.Err.Error()
+	}
+	return json.Marshal(j)
+}
@@ -0,0 +1,38 @@
+package httpstat
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestResult_MarshalJSON(t *testing.T) {
+	result := Result{
+		DNSLookup:        1 * time.Millisecond,
+		TCPConnection:    2 * time.Millisecond,
+		TLSHandshake:     3 * time.Millisecond,
+		ServerProcessing: 4 * time.Millisecond,
+		contentTransfer:  5 * time.Millisecond,
+
+		NameLookup:    1 * time.Millisecond,
+		Connect:       3 * time.Millisecond,
+		Pretransfer:   6 * time.Millisecond,
+		StartTransfer: 10 * time.Millisecond,
+		total:         15 * time.Millisecond,
+
+		ServerTiming: []ServerTiming{{Name: "db", Duration: time.Millisecond, Description: "query"}},
+	}
+
+	b, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal("json.Marshal failed:", err)
+	}
+
+	want := `{"dnsLookup":1000000,"tcpConnection":2000000,"tlsHandshake":3000000,` +
+		`"serverProcessing":4000000,"contentTransfer":5000000,"nameLookup":1000000,` +
+		`"connect":3000000,"pretransfer":6000000,"startTransfer":10000000,"total":15000000,` +
+		`"serverTiming":[{"name":"db","dur":1000000,"desc":"query"}]}`
+	if got := string(b); got != want {
+		t.Fatalf("expect to be eq:\n\nwant: %s\ngot:  %s", want, got)
+	}
+}
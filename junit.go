@@ -0,0 +1,163 @@
+package httpstat
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// JUnitReport collects latency checks, budget checks and comparisons with a
+// baseline, as the test cases of a JUnit XML test suite, so they show up in
+// the test reports of CI systems. A JUnitReport is not safe for concurrent
+// use.
+type JUnitReport struct {
+	// Name is the name of the test suite. If empty, "httpstat" is used.
+	Name string
+
+	cases []junitCase
+}
+
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+
+	d time.Duration
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+func (j *JUnitReport) add(class, name string, d time.Duration, failure *junitFailure) {
+	j.cases = append(j.cases, junitCase{
+		ClassName: class,
+		Name:      name,
+		Time:      junitSeconds(d),
+		Failure:   failure,
+		d:         d,
+	})
+}
+
+// AddBudget adds a test case for each limit of b checked by CheckBudget,
+// which fails if r exceeded it. The test cases are in the class name, e.g.
+// the request URL.
+func (j *JUnitReport) AddBudget(name string, r *Result, b Budget) {
+	violations := r.CheckBudget(b)
+	check := func(limit string, budget, actual time.Duration) {
+		if budget <= 0 {
+			return
+		}
+		var failure *junitFailure
+		for _, v := range violations {
+			if v.Name == limit {
+				failure = &junitFailure{
+					Message: v.Error(),
+					Type:    "budget",
+					Text:    fmt.Sprintf("%s took %v, budget is %v; longest phase: %s", v.Name, v.Actual, v.Limit, v.Phase),
+				}
+			}
+		}
+		j.add(name, limit, actual, failure)
+	}
+	check("DNS", b.DNS, r.DNSLookup)
+	check("Connect", b.Connect, r.TCPConnection)
+	check("TLS", b.TLS, r.TLSHandshake)
+	check("TTFB", b.TTFB, r.StartTransfer)
+	check("Total", b.Total, r.Total())
+}
+
+// AddComparison adds a test case for each phase of c and its total, which
+// fails if B, the request checked against the baseline A, regressed by
+// more than tolerance percent.
+func (j *JUnitReport) AddComparison(name string, c *Comparison, tolerance float64) {
+	for _, d := range c.Phases {
+		j.addDelta(name, d.Phase.String(), d.Delta, tolerance)
+	}
+	j.addDelta(name, "Total", c.Total, tolerance)
+}
+
+// AddAggregateComparison is like AddComparison for the medians of the
+// phases of aggregated requests.
+func (j *JUnitReport) AddAggregateComparison(name string, c *AggregateComparison, tolerance float64) {
+	for _, d := range c.Phases {
+		j.addDelta(name, d.Phase.String(), d.P50, tolerance)
+	}
+	j.addDelta(name, "Total", c.Total.P50, tolerance)
+}
+
+func (j *JUnitReport) addDelta(class, name string, d Delta, tolerance float64) {
+	var failure *junitFailure
+	if d.Percent() > tolerance {
+		failure = &junitFailure{
+			Message: fmt.Sprintf("httpstat: %s regressed by %.1f%%, tolerance is %.1f%%", name, d.Percent(), tolerance),
+			Type:    "regression",
+			Text:    fmt.Sprintf("baseline %v, now %v, %v", d.A, d.B, d),
+		}
+	}
+	j.add(class, name, d.B, failure)
+}
+
+// Failures returns the number of failed test cases.
+func (j *JUnitReport) Failures() int {
+	n := 0
+	for _, c := range j.cases {
+		if c.Failure != nil {
+			n++
+		}
+	}
+	return n
+}
+
+// WriteTo writes the JUnit XML document to w.
+func (j *JUnitReport) WriteTo(w io.Writer) (int64, error) {
+	suite := junitSuite{
+		Name:     j.Name,
+		Tests:    len(j.cases),
+		Failures: j.Failures(),
+		Cases:    j.cases,
+	}
+	if suite.Name == "" {
+		suite.Name = "httpstat"
+	}
+	var total time.Duration
+	for _, c := range j.cases {
+		total += c.d
+	}
+	suite.Time = junitSeconds(total)
+
+	cw := &countWriter{w: w}
+	if _, err := io.WriteString(cw, xml.Header); err != nil {
+		return cw.n, err
+	}
+	enc := xml.NewEncoder(cw)
+	enc.Indent("", "  ")
+	if err := enc.Encode(junitSuites{Suites: []junitSuite{suite}}); err != nil {
+		return cw.n, err
+	}
+	_, err := io.WriteString(cw, "\n")
+	return cw.n, err
+}
+
+// junitSeconds formats d in seconds, as JUnit expects.
+func junitSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+}
@@ -0,0 +1,64 @@
+package httpstat
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJUnitReport(t *testing.T) {
+	r := NewResultFromPhases(map[Phase]time.Duration{
+		PhaseDNS:    5 * time.Millisecond,
+		PhaseServer: 80 * time.Millisecond,
+	})
+	baseline := NewResultFromPhases(map[Phase]time.Duration{
+		PhaseDNS:    5 * time.Millisecond,
+		PhaseServer: 40 * time.Millisecond,
+	})
+
+	var j JUnitReport
+	j.AddBudget("GET https://example.com", r, Budget{DNS: 10 * time.Millisecond, TTFB: 50 * time.Millisecond})
+	j.AddComparison("GET https://example.com", Compare(baseline, r), 10)
+	if got := j.Failures(); got != 3 {
+		t.Fatalf("expect the TTFB budget and the server and total regressions to fail, got %d failures", got)
+	}
+
+	var buf bytes.Buffer
+	if _, err := j.WriteTo(&buf); err != nil {
+		t.Fatal("WriteTo failed:", err)
+	}
+	var doc struct {
+		Suites []struct {
+			Name     string `xml:"name,attr"`
+			Tests    int    `xml:"tests,attr"`
+			Failures int    `xml:"failures,attr"`
+			Cases    []struct {
+				Name    string `xml:"name,attr"`
+				Time    string `xml:"time,attr"`
+				Failure *struct {
+					Type string `xml:"type,attr"`
+				} `xml:"failure"`
+			} `xml:"testcase"`
+		} `xml:"testsuite"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid XML: %v\n%s", err, buf.String())
+	}
+	if len(doc.Suites) != 1 || doc.Suites[0].Name != "httpstat" || doc.Suites[0].Tests != 5 || doc.Suites[0].Failures != 3 {
+		t.Fatalf("unexpected suite:\n%s", buf.String())
+	}
+	var failed []string
+	for _, c := range doc.Suites[0].Cases {
+		if c.Failure != nil {
+			failed = append(failed, c.Name+":"+c.Failure.Type)
+		}
+	}
+	if got, want := strings.Join(failed, " "), "TTFB:budget Server processing:regression Total:regression"; got != want {
+		t.Fatalf("failed test cases = %q, want %q", got, want)
+	}
+	if c := doc.Suites[0].Cases[0]; c.Name != "DNS" || c.Time != "0.005" {
+		t.Fatalf("unexpected DNS test case %+v", c)
+	}
+}
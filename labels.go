@@ -0,0 +1,27 @@
+package httpstat
+
+// SetLabel tags r with the label k set to v, e.g. the tenant, endpoint
+// name or retry attempt of the request, so aggregated latencies can be
+// sliced by them. Labels are included in the JSON encoding of r and stored
+// by the Sinks.
+func (r *Result) SetLabel(k, v string) {
+	if r.labels == nil {
+		r.labels = make(map[string]string)
+	}
+	r.labels[k] = v
+}
+
+// Label returns the value of the label k of r.
+func (r *Result) Label(k string) (string, bool) {
+	v, ok := r.labels[k]
+	return v, ok
+}
+
+// Labels returns a copy of the labels of r.
+func (r *Result) Labels() map[string]string {
+	labels := make(map[string]string, len(r.labels))
+	for k, v := range r.labels {
+		labels[k] = v
+	}
+	return labels
+}
@@ -0,0 +1,35 @@
+package httpstat
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestResult_SetLabel(t *testing.T) {
+	var r Result
+	if _, ok := r.Label("tenant"); ok {
+		t.Fatal("expect no label on an empty Result")
+	}
+
+	r.SetLabel("tenant", "acme")
+	r.SetLabel("attempt", "1")
+	r.SetLabel("attempt", "2")
+	if v, ok := r.Label("attempt"); !ok || v != "2" {
+		t.Fatalf("Label(attempt) = %q, %t, want 2, true", v, ok)
+	}
+
+	labels := r.Labels()
+	labels["tenant"] = "changed"
+	if v, _ := r.Label("tenant"); v != "acme" {
+		t.Fatalf("expect Labels to return a copy, tenant is %q", v)
+	}
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal("json.Marshal failed:", err)
+	}
+	if want := `"labels":{"attempt":"2","tenant":"acme"}`; !strings.Contains(string(b), want) {
+		t.Fatalf("expect %s in %s", want, b)
+	}
+}
@@ -0,0 +1,44 @@
+package httpstat
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// WriteMarkdown writes a Markdown table of the phases of the request to w,
+// with the time each phase started at and its duration, for pasting the
+// measurement into pull requests, incident docs and chat.
+func (r *Result) WriteMarkdown(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("| Phase | Start | Duration |\n")
+	bw.WriteString("| --- | ---: | ---: |\n")
+	for _, pt := range r.Phases() {
+		if pt.Skipped {
+			fmt.Fprintf(bw, "| %s | | skipped |\n", pt.Name)
+			continue
+		}
+		fmt.Fprintf(bw, "| %s | %v | %v |\n", pt.Name, pt.StartOffset, pt.Duration)
+	}
+	fmt.Fprintf(bw, "| **Total** | | **%v** |\n", r.Total())
+	return bw.Flush()
+}
+
+// WriteMarkdown writes a Markdown table of the percentiles of each phase
+// and of the total durations kept by a to w. Phases without durations are
+// left out.
+func (a *Aggregator) WriteMarkdown(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("| Phase | Count | Min | p50 | p90 | p95 | p99 | Max |\n")
+	bw.WriteString("| --- | ---: | ---: | ---: | ---: | ---: | ---: | ---: |\n")
+	row := func(name string, s Stats) {
+		fmt.Fprintf(bw, "| %s | %d | %v | %v | %v | %v | %v | %v |\n", name, s.Count, s.Min, s.P50, s.P90, s.P95, s.P99, s.Max)
+	}
+	for i := range phaseNames {
+		if s := a.Phase(Phase(i)); s.Count > 0 {
+			row(Phase(i).String(), s)
+		}
+	}
+	row("**Total**", a.Total())
+	return bw.Flush()
+}
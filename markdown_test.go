@@ -0,0 +1,55 @@
+package httpstat
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestResult_WriteMarkdown(t *testing.T) {
+	r := NewResultFromPhases(map[Phase]time.Duration{
+		PhaseDNS:      10 * time.Millisecond,
+		PhaseConnect:  20 * time.Millisecond,
+		PhaseServer:   30 * time.Millisecond,
+		PhaseTransfer: 5 * time.Millisecond,
+	})
+	var buf bytes.Buffer
+	if err := r.WriteMarkdown(&buf); err != nil {
+		t.Fatal("WriteMarkdown failed:", err)
+	}
+	want := `| Phase | Start | Duration |
+| --- | ---: | ---: |
+| DNS lookup | 0s | 10ms |
+| TCP connection | 10ms | 20ms |
+| Proxy CONNECT | | skipped |
+| TLS handshake | | skipped |
+| 100-continue wait | | skipped |
+| Server processing | 30ms | 30ms |
+| Content transfer | 60ms | 5ms |
+| **Total** | | **65ms** |
+`
+	if got := buf.String(); got != want {
+		t.Fatalf("expect table\n%s\ngot\n%s", want, got)
+	}
+}
+
+func TestAggregator_WriteMarkdown(t *testing.T) {
+	var a Aggregator
+	for i := 1; i <= 100; i++ {
+		a.Add(NewResultFromPhases(map[Phase]time.Duration{
+			PhaseServer: time.Duration(i) * time.Millisecond,
+		}))
+	}
+	var buf bytes.Buffer
+	if err := a.WriteMarkdown(&buf); err != nil {
+		t.Fatal("WriteMarkdown failed:", err)
+	}
+	want := `| Phase | Count | Min | p50 | p90 | p95 | p99 | Max |
+| --- | ---: | ---: | ---: | ---: | ---: | ---: | ---: |
+| Server processing | 100 | 1ms | 50ms | 90ms | 95ms | 99ms | 100ms |
+| **Total** | 100 | 1ms | 50ms | 90ms | 95ms | 99ms | 100ms |
+`
+	if got := buf.String(); got != want {
+		t.Fatalf("expect table\n%s\ngot\n%s", want, got)
+	}
+}
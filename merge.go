@@ -0,0 +1,144 @@
+package httpstat
+
+import (
+	"time"
+)
+
+// Sum returns a Result whose phases took the summed durations of the
+// phases of results, e.g. the time a job spent in each phase over all its
+// requests. It starts at the earliest start of results.
+func Sum(results ...*Result) *Result {
+	b := sumResults(results)
+	return b.Build()
+}
+
+// Mean returns a Result whose phases took the mean durations of the phases
+// of results. Phases skipped by some of the requests are counted with a
+// duration of zero, so the phases of the mean Result add up to the mean
+// total duration. It returns an empty Result if results is empty.
+func Mean(results ...*Result) *Result {
+	if len(results) == 0 {
+		return &Result{}
+	}
+	b := sumResults(results)
+	for p, d := range b.phases {
+		b.phases[p] = d / time.Duration(len(results))
+	}
+	return b.Build()
+}
+
+func sumResults(results []*Result) *ResultBuilder {
+	b := NewResultBuilder()
+	var start time.Time
+	for i, r := range results {
+		for p := range phaseNames {
+			b.phases[Phase(p)] += r.Duration(Phase(p))
+		}
+		if s := r.dnsStart; !s.IsZero() && (start.IsZero() || s.Before(start)) {
+			start = s
+		}
+		// The protocol is kept if all requests used the same.
+		if i == 0 {
+			b.protocol = r.Protocol
+		} else if r.Protocol != b.protocol {
+			b.protocol = ""
+		}
+	}
+	if !start.IsZero() {
+		b.start = start
+	}
+	return b
+}
+
+// Merge adds the Results added to others to a, e.g. to combine the
+// Aggregators of the workers of a load generator. If a has a Window, only
+// the newest durations are kept, taking the durations of others as the
+// newer ones.
+func (a *Aggregator) Merge(others ...*Aggregator) {
+	for _, o := range others {
+		// o is copied first, as it may be a itself.
+		s, hists := o.snapshot()
+		a.mergeSnapshot(s, &hists)
+	}
+}
+
+// AggregatorSnapshot holds the durations kept by an Aggregator, e.g. to
+// send them to another process as JSON and merge them there. Phases are
+// keyed like in the JSON encoding of Result, e.g. "dnsLookup", and custom
+// phases by their names.
+type AggregatorSnapshot struct {
+	Count        int                        `json:"count"`
+	Phases       map[string][]time.Duration `json:"phases"`
+	Total        []time.Duration            `json:"total"`
+	CustomPhases map[string][]time.Duration `json:"customPhases,omitempty"`
+}
+
+// Snapshot returns a copy of the durations kept by a.
+func (a *Aggregator) Snapshot() AggregatorSnapshot {
+	s, _ := a.snapshot()
+	return s
+}
+
+// snapshot returns a copy of the durations and the histograms kept by a.
+func (a *Aggregator) snapshot() (AggregatorSnapshot, [len(phaseNames) + 1]histogram) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var hists [len(phaseNames) + 1]histogram
+	for i, h := range a.hists {
+		hists[i] = h.clone()
+	}
+	s := AggregatorSnapshot{
+		Count:  a.count,
+		Phases: make(map[string][]time.Duration),
+		Total:  append([]time.Duration(nil), a.total...),
+	}
+	for p, durations := range a.phases {
+		if len(durations) > 0 {
+			s.Phases[phaseKeys[p]] = append([]time.Duration(nil), durations...)
+		}
+	}
+	if len(a.custom) > 0 {
+		s.CustomPhases = make(map[string][]time.Duration, len(a.custom))
+		for name, durations := range a.custom {
+			s.CustomPhases[name] = append([]time.Duration(nil), durations...)
+		}
+	}
+	return s, hists
+}
+
+// MergeSnapshot adds the durations of s to a, like Merge. Unknown phases
+// are ignored. As s only holds the durations kept by a windowed
+// Aggregator, the histograms written by WriteOpenMetrics only count those.
+func (a *Aggregator) MergeSnapshot(s AggregatorSnapshot) {
+	a.mergeSnapshot(s, nil)
+}
+
+// mergeSnapshot adds the durations of s to a, and hists to its histograms.
+// If hists is nil, the durations of s are added to the histograms instead.
+func (a *Aggregator) mergeSnapshot(s AggregatorSnapshot, hists *[len(phaseNames) + 1]histogram) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.count += s.Count
+	for p, key := range phaseKeys {
+		a.phases[p] = a.appendWindow(a.phases[p], s.Phases[key]...)
+	}
+	a.total = a.appendWindow(a.total, s.Total...)
+	for name, durations := range s.CustomPhases {
+		a.addCustom(name, durations...)
+	}
+
+	if hists != nil {
+		for i, h := range hists {
+			a.hists[i].merge(h)
+		}
+		return
+	}
+	for p, key := range phaseKeys {
+		for _, d := range s.Phases[key] {
+			a.hists[p].add(OpenMetricsBuckets, d)
+		}
+	}
+	for _, d := range s.Total {
+		a.hists[len(phaseNames)].add(OpenMetricsBuckets, d)
+	}
+}
@@ -0,0 +1,105 @@
+package httpstat
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestSumMean(t *testing.T) {
+	ms := time.Millisecond
+	cold := NewResultBuilder().
+		Start(time.Unix(100, 0)).
+		Phase(PhaseDNS, 10*ms).
+		Phase(PhaseConnect, 20*ms).
+		Phase(PhaseServer, 30*ms).
+		Protocol("h2").
+		Build()
+	warm := NewResultBuilder().
+		Start(time.Unix(200, 0)).
+		Phase(PhaseServer, 50*ms).
+		Phase(PhaseTransfer, 10*ms).
+		Protocol("h2").
+		Build()
+
+	sum := Sum(cold, warm)
+	if sum.DNSLookup != 10*ms || sum.ServerProcessing != 80*ms || sum.Total() != 120*ms {
+		t.Fatalf("unexpected sum %s", sum)
+	}
+	if !sum.DNSStartAt().Equal(time.Unix(100, 0)) || sum.Protocol != "h2" {
+		t.Fatalf("unexpected start %v or protocol %q of sum", sum.DNSStartAt(), sum.Protocol)
+	}
+
+	mean := Mean(cold, warm)
+	if mean.DNSLookup != 5*ms || mean.ServerProcessing != 40*ms || mean.ContentTransfer() != 5*ms || mean.Total() != 60*ms {
+		t.Fatalf("unexpected mean %s", mean)
+	}
+
+	warm.Protocol = "http/1.1"
+	if p := Mean(cold, warm).Protocol; p != "" {
+		t.Fatalf("expect no protocol for mixed protocols, got %q", p)
+	}
+	if r := Mean(); r.total != 0 || r.DNSLookup != 0 {
+		t.Fatalf("expect empty mean, got %s", r)
+	}
+}
+
+func TestAggregator_Merge(t *testing.T) {
+	add := func(a *Aggregator, from, to int) {
+		for i := from; i <= to; i++ {
+			a.Add(NewResultFromPhases(map[Phase]time.Duration{
+				PhaseDNS:    time.Duration(i) * time.Millisecond,
+				PhaseServer: time.Millisecond,
+			}))
+		}
+	}
+	var w1, w2, all Aggregator
+	add(&w1, 1, 50)
+	add(&w2, 51, 100)
+	add(&all, 1, 100)
+
+	var merged Aggregator
+	merged.Merge(&w1, &w2)
+	if merged.Count() != 100 || merged.Phase(PhaseDNS) != all.Phase(PhaseDNS) || merged.Total() != all.Total() {
+		t.Fatalf("merged stats %+v, want %+v", merged.Phase(PhaseDNS), all.Phase(PhaseDNS))
+	}
+
+	// Merging an Aggregator into itself doubles its samples.
+	w1.Merge(&w1)
+	if s := w1.Phase(PhaseDNS); s.Count != 100 || s.Max != 50*time.Millisecond {
+		t.Fatalf("unexpected stats after self merge %+v", s)
+	}
+
+	windowed := Aggregator{Window: 10}
+	windowed.Merge(&w2)
+	if s := windowed.Phase(PhaseDNS); s.Count != 10 || s.Min != 91*time.Millisecond {
+		t.Fatalf("expect the newest durations to be kept, got %+v", s)
+	}
+}
+
+func TestAggregator_Snapshot(t *testing.T) {
+	var a Aggregator
+	for i := 1; i <= 10; i++ {
+		a.Add(NewResultFromPhases(map[Phase]time.Duration{
+			PhaseTLS: time.Duration(i) * time.Millisecond,
+		}))
+	}
+
+	data, err := json.Marshal(a.Snapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s AggregatorSnapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.Phases["tlsHandshake"]; !ok || len(s.Phases) != 1 {
+		t.Fatalf("unexpected phases in %s", data)
+	}
+
+	var b Aggregator
+	b.MergeSnapshot(s)
+	if b.Count() != 10 || b.Phase(PhaseTLS) != a.Phase(PhaseTLS) || b.Total() != a.Total() {
+		t.Fatalf("decoded stats %+v, want %+v", b.Phase(PhaseTLS), a.Phase(PhaseTLS))
+	}
+}
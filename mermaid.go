@@ -0,0 +1,61 @@
+package httpstat
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// WriteMermaid writes the timelines of results to w as a Mermaid gantt
+// chart, with a section per request and a task per phase, so they can be
+// pasted into wikis and issue trackers which render Mermaid.
+//
+// The chart starts at the start of the first request; times are in
+// milliseconds. Sections are named after the method and URL of the
+// Metadata of the Results, if recorded. The phase a request failed in is
+// marked as critical.
+func WriteMermaid(w io.Writer, results []*Result) error {
+	var t0 time.Time
+	for _, r := range results {
+		if s := r.dnsStart; !s.IsZero() && (t0.IsZero() || s.Before(t0)) {
+			t0 = s
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString("gantt\n")
+	bw.WriteString("    dateFormat x\n")
+	bw.WriteString("    axisFormat %S.%L s\n")
+	for i, r := range results {
+		name := fmt.Sprintf("Request %d", i+1)
+		if m := r.Metadata; m != nil && m.URL != "" {
+			name = strings.TrimSpace(m.Method + " " + m.URL)
+		}
+		fmt.Fprintf(bw, "    section %s\n", mermaidText(name))
+
+		var offset time.Duration
+		if !r.dnsStart.IsZero() && !t0.IsZero() {
+			offset = r.dnsStart.Sub(t0)
+		}
+		for _, pt := range r.Phases() {
+			if pt.Skipped || pt.Duration <= 0 {
+				continue
+			}
+			tag := ""
+			if r.failed && r.failedPhase == pt.Phase {
+				tag = "crit, "
+			}
+			start := offset + pt.StartOffset
+			fmt.Fprintf(bw, "    %s :%s%d, %d\n", pt.Name, tag, start.Milliseconds(), (start + pt.Duration).Milliseconds())
+		}
+	}
+	return bw.Flush()
+}
+
+// mermaidText returns s fit for a line of a Mermaid chart: on one line and
+// without the characters starting comments and separating task data.
+func mermaidText(s string) string {
+	return strings.NewReplacer("\n", " ", "\r", " ", ":", "#58;", ";", "#59;", "%%", "%").Replace(s)
+}
@@ -0,0 +1,38 @@
+package httpstat
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestWriteMermaid(t *testing.T) {
+	start := time.Now()
+	first := NewResultBuilder().Start(start).
+		Phase(PhaseDNS, 10*time.Millisecond).
+		Phase(PhaseConnect, 20*time.Millisecond).
+		Phase(PhaseServer, 30*time.Millisecond).
+		Build()
+	first.Metadata = &Metadata{Method: "GET", URL: "https://example.com/a"}
+	second := NewResultBuilder().Start(start.Add(60*time.Millisecond)).
+		Phase(PhaseServer, 15*time.Millisecond).
+		Build()
+
+	var buf bytes.Buffer
+	if err := WriteMermaid(&buf, []*Result{first, second}); err != nil {
+		t.Fatal("WriteMermaid failed:", err)
+	}
+	want := `gantt
+    dateFormat x
+    axisFormat %S.%L s
+    section GET https#58;//example.com/a
+    DNS lookup :0, 10
+    TCP connection :10, 30
+    Server processing :30, 60
+    section Request 2
+    Server processing :60, 75
+`
+	if got := buf.String(); got != want {
+		t.Fatalf("expect chart\n%s\ngot\n%s", want, got)
+	}
+}
@@ -0,0 +1,50 @@
+package httpstat
+
+import (
+	"net/http"
+)
+
+// Metadata describes the request measured by a Result and its response,
+// e.g. for writing structured access log lines from the Result alone.
+type Metadata struct {
+	Method string `json:"method"`
+
+	// URL is the request URL, with any password redacted.
+	URL string `json:"url"`
+
+	StatusCode int `json:"statusCode"`
+
+	// ContentLength is the Content-Length of the response, or -1 if it is
+	// unknown.
+	ContentLength int64 `json:"contentLength"`
+
+	// Header is a copy of the response header.
+	Header http.Header `json:"header,omitempty"`
+
+	// Proxy is the ProxyURL of the Result, or "direct" if the request was
+	// sent without a proxy. It is only known when the Proxy function of
+	// the http.Transport is wrapped with ProxyFunc, or the proxy reported
+	// with OnProxyConnectResponse, and empty otherwise.
+	Proxy string `json:"proxy,omitempty"`
+}
+
+// RecordResponse sets the Metadata of r from res and the request which got
+// it. The Transport does so when its RecordMetadata is set.
+func (r *Result) RecordResponse(res *http.Response) {
+	m := &Metadata{
+		StatusCode:    res.StatusCode,
+		ContentLength: res.ContentLength,
+		Header:        res.Header.Clone(),
+	}
+	if req := res.Request; req != nil {
+		m.Method = req.Method
+		m.URL = req.URL.Redacted()
+	}
+	switch {
+	case r.ProxyURL != "":
+		m.Proxy = r.ProxyURL
+	case r.proxyChosen:
+		m.Proxy = "direct"
+	}
+	r.Metadata = m
+}
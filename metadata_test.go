@@ -0,0 +1,59 @@
+package httpstat
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTransport_RecordMetadata(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Cache", "HIT")
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, "created")
+	}))
+	defer ts.Close()
+
+	var result *Result
+	client := &http.Client{Transport: &Transport{
+		RecordMetadata: true,
+		OnResult: func(req *http.Request, r *Result, err error) {
+			result = r
+		},
+	}}
+	u := strings.Replace(ts.URL, "http://", "http://user:secret@", 1) + "/items?id=1"
+	res, err := client.Post(u, "text/plain", strings.NewReader("item"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+
+	m := result.Metadata
+	if m == nil {
+		t.Fatal("expect Metadata to be recorded")
+	}
+	if m.Method != "POST" || m.URL != strings.Replace(u, "secret", "xxxxx", 1) || m.StatusCode != http.StatusCreated {
+		t.Fatalf("unexpected metadata %+v", m)
+	}
+	if m.ContentLength != int64(len("created")) || m.Header.Get("X-Cache") != "HIT" {
+		t.Fatalf("unexpected response metadata %+v", m)
+	}
+
+	// The header is a snapshot.
+	res.Header.Set("X-Cache", "MISS")
+	if m.Header.Get("X-Cache") != "HIT" {
+		t.Fatal("expect the header to be copied")
+	}
+
+	b, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal("json.Marshal failed:", err)
+	}
+	if want := `"metadata":{"method":"POST",`; !strings.Contains(string(b), want) {
+		t.Fatalf("expect %s in %s", want, b)
+	}
+}
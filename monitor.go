@@ -0,0 +1,165 @@
+package httpstat
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Monitor probes URLs periodically, in the manner of smokeping, and
+// aggregates the Results per URL. The callbacks are called concurrently for
+// different URLs.
+type Monitor struct {
+	// URLs are requested with GET requests.
+	URLs []string
+
+	// Interval is the time between two probes of a URL. If zero, 1 minute
+	// is used.
+	Interval time.Duration
+
+	// Jitter, if positive, adds a random delay of up to Jitter to each
+	// interval, so probes of several URLs do not happen in lockstep.
+	Jitter time.Duration
+
+	// Client is used to send the probes. If nil, http.DefaultClient is
+	// used.
+	Client *http.Client
+
+	// Window is the number of Results kept per URL in the Aggregators. If
+	// zero, 1000 is used.
+	Window int
+
+	// Budget is checked against every successful probe.
+	Budget Budget
+
+	// OnProbe, if not nil, is called with the Record of every probe.
+	OnProbe func(rec Record)
+
+	// OnFailure, if not nil, is called with the Record of every probe which
+	// failed or got a response with a status code of 400 or above.
+	OnFailure func(rec Record)
+
+	// OnBreach, if not nil, is called with the Record of every successful
+	// probe which exceeded the Budget, and the violations.
+	OnBreach func(rec Record, violations []Violation)
+
+	mu   sync.Mutex
+	aggs map[string]*Aggregator
+}
+
+// Run probes the URLs until ctx is done, starting with one probe of each
+// URL right away. It returns ctx.Err().
+func (m *Monitor) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	for _, u := range m.URLs {
+		wg.Add(1)
+		go func(u string) {
+			defer wg.Done()
+			m.run(ctx, u)
+		}(u)
+	}
+	wg.Wait()
+	return ctx.Err()
+}
+
+func (m *Monitor) run(ctx context.Context, url string) {
+	interval := m.Interval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		m.Probe(ctx, url)
+
+		next := interval
+		if m.Jitter > 0 {
+			next += time.Duration(rand.Int63n(int64(m.Jitter)))
+		}
+		timer.Reset(next)
+	}
+}
+
+// Probe probes url once, records the Result into the Aggregator of url and
+// calls the callbacks. Probes aborted because ctx is done are not recorded.
+func (m *Monitor) Probe(ctx context.Context, url string) Record {
+	rec := probe(ctx, m.Client, url)
+	if rec.Err != nil && ctx.Err() != nil {
+		return rec
+	}
+
+	failed := rec.Err != nil || rec.StatusCode >= 400
+	if !failed {
+		m.Aggregator(url).Add(rec.Result)
+	}
+
+	if m.OnProbe != nil {
+		m.OnProbe(rec)
+	}
+	if failed {
+		if m.OnFailure != nil {
+			m.OnFailure(rec)
+		}
+		return rec
+	}
+	if v := rec.Result.CheckBudget(m.Budget); v != nil && m.OnBreach != nil {
+		m.OnBreach(rec, v)
+	}
+	return rec
+}
+
+// probe sends a GET request to url with client, reads the response body
+// and returns the Record of the request.
+func probe(ctx context.Context, client *http.Client, url string) Record {
+	rec := Record{Time: time.Now(), Method: "GET", URL: url, Result: &Result{}}
+	req, err := http.NewRequestWithContext(WithHTTPStat(ctx, rec.Result), "GET", url, nil)
+	if err != nil {
+		rec.Err = err
+		return rec
+	}
+	rec.URL = req.URL.Redacted()
+
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		rec.Err = err
+		return rec
+	}
+	_, err = io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+	rec.Result.EndNow()
+	rec.StatusCode = res.StatusCode
+	rec.Err = err
+	return rec
+}
+
+// Aggregator returns the Aggregator of the successful probes of url.
+func (m *Monitor) Aggregator(url string) *Aggregator {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.aggs == nil {
+		m.aggs = make(map[string]*Aggregator)
+	}
+	a, ok := m.aggs[url]
+	if !ok {
+		window := m.Window
+		if window <= 0 {
+			window = 1000
+		}
+		a = &Aggregator{Window: window}
+		m.aggs[url] = a
+	}
+	return a
+}
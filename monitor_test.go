@@ -0,0 +1,82 @@
+package httpstat
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMonitor(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/fail", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(30 * time.Millisecond)
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	var mu sync.Mutex
+	probes := make(map[string]int)
+	failures := make(map[string]int)
+	breaches := make(map[string]int)
+	m := &Monitor{
+		URLs:     []string{ts.URL + "/ok", ts.URL + "/fail", ts.URL + "/slow"},
+		Interval: 20 * time.Millisecond,
+		Jitter:   5 * time.Millisecond,
+		Budget:   Budget{TTFB: 25 * time.Millisecond},
+		OnProbe: func(rec Record) {
+			mu.Lock()
+			probes[rec.URL]++
+			mu.Unlock()
+		},
+		OnFailure: func(rec Record) {
+			mu.Lock()
+			failures[rec.URL]++
+			mu.Unlock()
+		},
+		OnBreach: func(rec Record, v []Violation) {
+			if v[0].Name != "TTFB" {
+				t.Errorf("unexpected violation %v", v[0])
+			}
+			mu.Lock()
+			breaches[rec.URL]++
+			mu.Unlock()
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	if err := m.Run(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("expect Run to return the context error, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	for _, u := range m.URLs {
+		if probes[u] < 2 {
+			t.Errorf("expect %s to be probed repeatedly, got %d probes", u, probes[u])
+		}
+	}
+	if failures[ts.URL+"/fail"] != probes[ts.URL+"/fail"] || len(failures) != 1 {
+		t.Errorf("expect only /fail to fail, got %v", failures)
+	}
+	if breaches[ts.URL+"/slow"] == 0 || len(breaches) != 1 {
+		t.Errorf("expect only /slow to breach the budget, got %v", breaches)
+	}
+
+	if n := m.Aggregator(ts.URL + "/ok").Count(); n != probes[ts.URL+"/ok"] {
+		t.Errorf("expect all probes of /ok to be aggregated, got %d", n)
+	}
+	if n := m.Aggregator(ts.URL + "/fail").Count(); n != 0 {
+		t.Errorf("expect failed probes not to be aggregated, got %d", n)
+	}
+	if s := m.Aggregator(ts.URL + "/slow").Phase(PhaseServer); s.Min < 30*time.Millisecond {
+		t.Errorf("unexpected server stats of /slow %+v", s)
+	}
+}
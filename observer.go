@@ -0,0 +1,74 @@
+package httpstat
+
+// Observer is notified as the phases of a request complete, e.g. to drive
+// live progress output or emit metrics. Each method is called with the
+// Result being recorded, the durations of the completed phases are already
+// set on it. Register an Observer with Result.SetObserver or
+// Transport.Observer.
+type Observer interface {
+	// OnDNSDone is called when the DNS lookup is done.
+	OnDNSDone(r *Result)
+
+	// OnConnectDone is called when the connection is established.
+	OnConnectDone(r *Result)
+
+	// OnTLSDone is called when the TLS handshake is done.
+	OnTLSDone(r *Result)
+
+	// OnFirstByte is called when the first response byte is received.
+	OnFirstByte(r *Result)
+
+	// OnComplete is called by End.
+	OnComplete(r *Result)
+}
+
+// ObserverFuncs is an Observer calling the functions which are not nil.
+type ObserverFuncs struct {
+	DNSDone     func(r *Result)
+	ConnectDone func(r *Result)
+	TLSDone     func(r *Result)
+	FirstByte   func(r *Result)
+	Complete    func(r *Result)
+	Progress    func(r *Result, p Progress)
+}
+
+// OnDNSDone implements Observer.
+func (o ObserverFuncs) OnDNSDone(r *Result) {
+	if o.DNSDone != nil {
+		o.DNSDone(r)
+	}
+}
+
+// OnConnectDone implements Observer.
+func (o ObserverFuncs) OnConnectDone(r *Result) {
+	if o.ConnectDone != nil {
+		o.ConnectDone(r)
+	}
+}
+
+// OnTLSDone implements Observer.
+func (o ObserverFuncs) OnTLSDone(r *Result) {
+	if o.TLSDone != nil {
+		o.TLSDone(r)
+	}
+}
+
+// OnFirstByte implements Observer.
+func (o ObserverFuncs) OnFirstByte(r *Result) {
+	if o.FirstByte != nil {
+		o.FirstByte(r)
+	}
+}
+
+// OnComplete implements Observer.
+func (o ObserverFuncs) OnComplete(r *Result) {
+	if o.Complete != nil {
+		o.Complete(r)
+	}
+}
+
+// SetObserver registers o to be notified about the progress of the request
+// measured by r. It must be called before the request is sent.
+func (r *Result) SetObserver(o Observer) {
+	r.observer = o
+}
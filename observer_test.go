@@ -0,0 +1,70 @@
+package httpstat
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestResult_SetObserver(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer ts.Close()
+
+	var calls []string
+	observer := ObserverFuncs{
+		ConnectDone: func(r *Result) {
+			if r.TCPConnection <= 0 {
+				t.Error("expect TCPConnection to be set in OnConnectDone")
+			}
+			calls = append(calls, "connect")
+		},
+		TLSDone:   func(*Result) { calls = append(calls, "tls") },
+		FirstByte: func(*Result) { calls = append(calls, "first byte") },
+		Complete:  func(*Result) { calls = append(calls, "complete") },
+	}
+
+	var result Result
+	result.SetObserver(observer)
+	req := NewRequest(t, ts.URL, &result)
+	res, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal("client.Do failed:", err)
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+	result.EndNow()
+
+	// The test server is reached by IP, so there is no DNS lookup.
+	want := []string{"connect", "tls", "first byte", "complete"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Fatalf("observer calls = %v, want %v", calls, want)
+	}
+}
+
+func TestTransport_Observer(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer ts.Close()
+
+	var completed *Result
+	client := &http.Client{
+		Transport: &Transport{
+			Observer: ObserverFuncs{Complete: func(r *Result) { completed = r }},
+		},
+	}
+	res, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatal("Get failed:", err)
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+
+	if completed == nil || completed.Total() <= 0 {
+		t.Fatal("expect OnComplete to be called with the measured Result")
+	}
+}
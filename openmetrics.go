@@ -0,0 +1,126 @@
+package httpstat
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpenMetricsBuckets are the upper bounds in seconds of the histogram
+// buckets written by WriteOpenMetrics. They must not be changed once
+// Results were added to an Aggregator.
+var OpenMetricsBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// histogram is a histogram of durations in seconds. counts holds the count
+// of each bucket, not the cumulative counts, with the last one counting the
+// durations above all bounds.
+type histogram struct {
+	count  uint64
+	sum    float64
+	counts []uint64
+}
+
+func (h *histogram) add(bounds []float64, d time.Duration) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(bounds)+1)
+	}
+	s := d.Seconds()
+	i := 0
+	for i < len(bounds) && s > bounds[i] {
+		i++
+	}
+	h.counts[i]++
+	h.count++
+	h.sum += s
+}
+
+// merge adds the counts of o, which must have the same bounds as h.
+func (h *histogram) merge(o histogram) {
+	if o.count == 0 {
+		return
+	}
+	if h.counts == nil {
+		h.counts = make([]uint64, len(o.counts))
+	}
+	for i, n := range o.counts {
+		h.counts[i] += n
+	}
+	h.count += o.count
+	h.sum += o.sum
+}
+
+func (h histogram) clone() histogram {
+	h.counts = append([]uint64(nil), h.counts...)
+	return h
+}
+
+// metricPhases are the values of the phase label in the OpenMetrics
+// output.
+var metricPhases = [...]string{
+	PhaseDNS:          "dns_lookup",
+	PhaseConnect:      "tcp_connection",
+	PhaseProxyConnect: "proxy_connect",
+	PhaseTLS:          "tls_handshake",
+	PhaseContinueWait: "continue_wait",
+	PhaseServer:       "server_processing",
+	PhaseTransfer:     "content_transfer",
+}
+
+// WriteOpenMetrics writes the durations of the Results added to a to w in
+// the OpenMetrics text format, as the histogram family
+// httpstat_phase_seconds with a phase label per phase and "total" for the
+// total duration, and the counter httpstat_requests of the Results added.
+// It needs no Prometheus client library, e.g. for serving a custom scrape
+// endpoint. The histograms are cumulative over all Results added, whatever
+// the Window of a, so they never go down between scrapes.
+func (a *Aggregator) WriteOpenMetrics(w io.Writer) error {
+	a.mu.Lock()
+	count := a.count
+	var hists [len(phaseNames) + 1]histogram
+	for i, h := range a.hists {
+		hists[i] = h.clone()
+	}
+	a.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString("# TYPE httpstat_phase_seconds histogram\n")
+	bw.WriteString("# UNIT httpstat_phase_seconds seconds\n")
+	bw.WriteString("# HELP httpstat_phase_seconds Duration of the phases of HTTP requests.\n")
+	for p := range phaseNames {
+		writeHistogram(bw, metricPhases[p], hists[p])
+	}
+	writeHistogram(bw, "total", hists[len(phaseNames)])
+
+	bw.WriteString("# TYPE httpstat_requests counter\n")
+	bw.WriteString("# HELP httpstat_requests Number of measured HTTP requests.\n")
+	bw.WriteString("httpstat_requests_total " + strconv.Itoa(count) + "\n")
+	bw.WriteString("# EOF\n")
+	return bw.Flush()
+}
+
+func writeHistogram(w *bufio.Writer, phase string, h histogram) {
+	labels := `{phase="` + phase + `"`
+	var n uint64
+	for i, le := range OpenMetricsBuckets {
+		if i < len(h.counts) {
+			n += h.counts[i]
+		}
+		w.WriteString("httpstat_phase_seconds_bucket" + labels + `,le="` + formatBound(le) + `"} ` + strconv.FormatUint(n, 10) + "\n")
+	}
+	count := strconv.FormatUint(h.count, 10)
+	w.WriteString("httpstat_phase_seconds_bucket" + labels + `,le="+Inf"} ` + count + "\n")
+	w.WriteString("httpstat_phase_seconds_sum" + labels + "} " + strconv.FormatFloat(h.sum, 'g', -1, 64) + "\n")
+	w.WriteString("httpstat_phase_seconds_count" + labels + "} " + count + "\n")
+}
+
+// formatBound formats the bucket bound f in the canonical form of
+// OpenMetrics, which always has a fraction, e.g. "1.0" and "0.25".
+func formatBound(f float64) string {
+	s := strconv.FormatFloat(f, 'f', -1, 64)
+	if !strings.Contains(s, ".") {
+		s += ".0"
+	}
+	return s
+}
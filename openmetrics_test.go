@@ -0,0 +1,71 @@
+package httpstat
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAggregator_WriteOpenMetrics(t *testing.T) {
+	var a Aggregator
+	for _, d := range []time.Duration{2 * time.Millisecond, 20 * time.Millisecond, 3 * time.Second} {
+		a.Add(NewResultFromPhases(map[Phase]time.Duration{PhaseServer: d}))
+	}
+
+	var buf bytes.Buffer
+	if err := a.WriteOpenMetrics(&buf); err != nil {
+		t.Fatal("WriteOpenMetrics failed:", err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"# TYPE httpstat_phase_seconds histogram\n",
+		`httpstat_phase_seconds_bucket{phase="server_processing",le="0.001"} 0` + "\n",
+		`httpstat_phase_seconds_bucket{phase="server_processing",le="0.025"} 2` + "\n",
+		`httpstat_phase_seconds_bucket{phase="server_processing",le="1.0"} 2` + "\n",
+		`httpstat_phase_seconds_bucket{phase="server_processing",le="10.0"} 3` + "\n",
+		`httpstat_phase_seconds_bucket{phase="server_processing",le="+Inf"} 3` + "\n",
+		`httpstat_phase_seconds_sum{phase="server_processing"} 3.022` + "\n",
+		`httpstat_phase_seconds_count{phase="dns_lookup"} 0` + "\n",
+		`httpstat_phase_seconds_count{phase="total"} 3` + "\n",
+		"httpstat_requests_total 3\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expect %q in output:\n%s", want, out)
+		}
+	}
+	if !strings.HasSuffix(out, "# EOF\n") {
+		t.Error("expect the output to end with # EOF")
+	}
+}
+
+func TestAggregator_WriteOpenMetricsWindow(t *testing.T) {
+	// The histograms keep counting the durations which dropped out of the
+	// window, so the counters never go down.
+	a := Aggregator{Window: 2}
+	for _, d := range []time.Duration{2 * time.Millisecond, 20 * time.Millisecond, 3 * time.Second} {
+		a.Add(NewResultFromPhases(map[Phase]time.Duration{PhaseServer: d}))
+	}
+	var b Aggregator
+	b.Merge(&a)
+
+	for _, agg := range []*Aggregator{&a, &b} {
+		var buf bytes.Buffer
+		if err := agg.WriteOpenMetrics(&buf); err != nil {
+			t.Fatal("WriteOpenMetrics failed:", err)
+		}
+		out := buf.String()
+		for _, want := range []string{
+			`httpstat_phase_seconds_bucket{phase="server_processing",le="0.0025"} 1` + "\n",
+			`httpstat_phase_seconds_count{phase="server_processing"} 3` + "\n",
+			`httpstat_phase_seconds_count{phase="total"} 3` + "\n",
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("expect %q in output:\n%s", want, out)
+			}
+		}
+	}
+	if got := a.Phase(PhaseServer).Count; got != 2 {
+		t.Fatalf("Phase(PhaseServer).Count = %d, want the window of 2", got)
+	}
+}
@@ -0,0 +1,260 @@
+package httpstat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// OTLPExporter is a Sink which accumulates histograms of the phase
+// durations of the Records written to it and pushes them to an
+// OpenTelemetry collector with OTLP/HTTP in the JSON encoding, for setups
+// standardized on the OpenTelemetry pipeline. Only Records of requests
+// which got a response are counted. The histograms are cumulative since
+// the first Record.
+type OTLPExporter struct {
+	// Endpoint is the URL the metrics are POSTed to, e.g.
+	// "http://localhost:4318/v1/metrics".
+	Endpoint string
+
+	// Header is added to the requests, e.g. for authentication.
+	Header http.Header
+
+	// Client is used to send the metrics. If nil, http.DefaultClient is
+	// used. It must not write its own requests to the OTLPExporter.
+	Client *http.Client
+
+	// Interval is the interval at which Run pushes the metrics. If zero,
+	// 1 minute is used.
+	Interval time.Duration
+
+	// ServiceName is the service.name attribute of the resource. If empty,
+	// "httpstat" is used.
+	ServiceName string
+
+	// Buckets are the upper bounds in seconds of the histogram buckets. If
+	// nil, OpenMetricsBuckets is used. They must not be changed once
+	// Records were written.
+	Buckets []float64
+
+	// OnError, if not nil, is called with the errors of the pushes made by
+	// Run.
+	OnError func(err error)
+
+	mu     sync.Mutex
+	start  time.Time
+	phases [len(phaseNames) + 1]histogram
+}
+
+// WriteRecord implements Sink.
+func (e *OTLPExporter) WriteRecord(rec Record) error {
+	if rec.Err != nil || rec.Result == nil {
+		return nil
+	}
+	r := rec.Result
+	bounds := e.buckets()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.start.IsZero() {
+		e.start = time.Now()
+	}
+	for p := range phaseNames {
+		if d := r.Duration(Phase(p)); d > 0 {
+			e.phases[p].add(bounds, d)
+		}
+	}
+	if d := r.total; d > 0 {
+		e.phases[len(phaseNames)].add(bounds, d)
+	}
+	return nil
+}
+
+func (e *OTLPExporter) buckets() []float64 {
+	if e.Buckets == nil {
+		return OpenMetricsBuckets
+	}
+	return e.Buckets
+}
+
+// Run pushes the metrics every Interval until ctx is done. It returns
+// ctx.Err().
+func (e *OTLPExporter) Run(ctx context.Context) error {
+	interval := e.Interval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		if err := e.Export(ctx); err != nil && e.OnError != nil {
+			e.OnError(err)
+		}
+	}
+}
+
+// Export pushes the metrics once. Nothing is pushed before the first
+// Record was written.
+func (e *OTLPExporter) Export(ctx context.Context) error {
+	body, ok, err := e.payload(time.Now())
+	if err != nil || !ok {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", e.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range e.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := e.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("httpstat: OTLP endpoint responded with %s", res.Status)
+	}
+	return nil
+}
+
+// The following mirror the JSON encoding of the OTLP
+// ExportMetricsServiceRequest message, in which 64 bit integers are
+// encoded as strings.
+
+type otlpRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpMetric struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description"`
+	Unit        string            `json:"unit"`
+	Histogram   otlpHistogramData `json:"histogram"`
+}
+
+type otlpHistogramData struct {
+	AggregationTemporality int                  `json:"aggregationTemporality"`
+	DataPoints             []otlpHistogramPoint `json:"dataPoints"`
+}
+
+type otlpHistogramPoint struct {
+	Attributes        []otlpAttribute `json:"attributes"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	TimeUnixNano      string          `json:"timeUnixNano"`
+	Count             string          `json:"count"`
+	Sum               float64         `json:"sum"`
+	BucketCounts      []string        `json:"bucketCounts"`
+	ExplicitBounds    []float64       `json:"explicitBounds"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+// otlpCumulative is AGGREGATION_TEMPORALITY_CUMULATIVE.
+const otlpCumulative = 2
+
+// payload encodes the metrics at now. ok is false if there is nothing to
+// push yet.
+func (e *OTLPExporter) payload(now time.Time) (data []byte, ok bool, err error) {
+	bounds := e.buckets()
+	service := e.ServiceName
+	if service == "" {
+		service = "httpstat"
+	}
+
+	e.mu.Lock()
+	if e.start.IsZero() {
+		e.mu.Unlock()
+		return nil, false, nil
+	}
+	start := strconv.FormatInt(e.start.UnixNano(), 10)
+	t := strconv.FormatInt(now.UnixNano(), 10)
+	var points []otlpHistogramPoint
+	for i, h := range e.phases {
+		phase := "total"
+		if i < len(phaseNames) {
+			phase = metricPhases[i]
+		}
+		counts := make([]string, len(bounds)+1)
+		for j := range counts {
+			var n uint64
+			if h.counts != nil {
+				n = h.counts[j]
+			}
+			counts[j] = strconv.FormatUint(n, 10)
+		}
+		points = append(points, otlpHistogramPoint{
+			Attributes:        []otlpAttribute{{Key: "phase", Value: otlpValue{StringValue: phase}}},
+			StartTimeUnixNano: start,
+			TimeUnixNano:      t,
+			Count:             strconv.FormatUint(h.count, 10),
+			Sum:               h.sum,
+			BucketCounts:      counts,
+			ExplicitBounds:    bounds,
+		})
+	}
+	e.mu.Unlock()
+
+	req := otlpRequest{ResourceMetrics: []otlpResourceMetrics{{
+		Resource: otlpResource{Attributes: []otlpAttribute{
+			{Key: "service.name", Value: otlpValue{StringValue: service}},
+		}},
+		ScopeMetrics: []otlpScopeMetrics{{
+			Scope: otlpScope{Name: "github.com/jakobilobi/go-httpstat"},
+			Metrics: []otlpMetric{{
+				Name:        "httpstat.phase.duration",
+				Description: "Duration of the phases of HTTP requests.",
+				Unit:        "s",
+				Histogram: otlpHistogramData{
+					AggregationTemporality: otlpCumulative,
+					DataPoints:             points,
+				},
+			}},
+		}},
+	}}}
+	data, err = json.Marshal(req)
+	return data, err == nil, err
+}
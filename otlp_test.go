@@ -0,0 +1,89 @@
+package httpstat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestOTLPExporter(t *testing.T) {
+	bodies := make(chan []byte, 10)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" || r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		bodies <- b
+	}))
+	defer ts.Close()
+
+	e := &OTLPExporter{
+		Endpoint: ts.URL + "/v1/metrics",
+		Header:   http.Header{"Authorization": {"Bearer token"}},
+		Buckets:  []float64{0.01, 0.1},
+	}
+	ctx := context.Background()
+	if err := e.Export(ctx); err != nil || len(bodies) != 0 {
+		t.Fatalf("expect nothing to be pushed before the first Record, got %v", err)
+	}
+
+	for _, d := range []time.Duration{5 * time.Millisecond, 50 * time.Millisecond, time.Second} {
+		e.WriteRecord(Record{Result: NewResultFromPhases(map[Phase]time.Duration{PhaseServer: d})})
+	}
+	e.WriteRecord(Record{Err: errors.New("refused"), Result: &Result{}})
+	if err := e.Export(ctx); err != nil {
+		t.Fatal("Export failed:", err)
+	}
+
+	var req struct {
+		ResourceMetrics []struct {
+			ScopeMetrics []struct {
+				Metrics []struct {
+					Name      string
+					Histogram struct {
+						AggregationTemporality int
+						DataPoints             []struct {
+							Attributes []struct {
+								Key   string
+								Value struct{ StringValue string }
+							}
+							Count        string
+							BucketCounts []string
+						}
+					}
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(<-bodies, &req); err != nil {
+		t.Fatal("decoding the request failed:", err)
+	}
+	m := req.ResourceMetrics[0].ScopeMetrics[0].Metrics[0]
+	if m.Name != "httpstat.phase.duration" || m.Histogram.AggregationTemporality != 2 {
+		t.Fatalf("unexpected metric %+v", m)
+	}
+	counts := make(map[string][]string)
+	for _, p := range m.Histogram.DataPoints {
+		counts[p.Attributes[0].Value.StringValue] = append([]string{p.Count}, p.BucketCounts...)
+	}
+	if got := counts["server_processing"]; len(got) != 4 || got[0] != "3" || got[1] != "1" || got[2] != "1" || got[3] != "1" {
+		t.Fatalf("unexpected server_processing histogram %v", got)
+	}
+	if got := counts["dns_lookup"]; got[0] != "0" {
+		t.Fatalf("unexpected dns_lookup histogram %v", got)
+	}
+	if got := counts["total"]; got[0] != "3" {
+		t.Fatalf("unexpected total histogram %v", got)
+	}
+
+	e.Header = nil
+	if err := e.Export(ctx); err == nil {
+		t.Fatal("expect an error for a rejected push")
+	}
+}
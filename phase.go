@@ -0,0 +1,170 @@
+package httpstat
+
+import (
+	"strconv"
+	"time"
+)
+
+// Phase identifies a phase of a request.
+type Phase int
+
+// The phases of a request, in the order they happen.
+const (
+	PhaseDNS Phase = iota
+	PhaseConnect
+	PhaseProxyConnect
+	PhaseTLS
+	PhaseContinueWait
+	PhaseServer
+	PhaseTransfer
+)
+
+// String returns the human-readable name of the phase, e.g. "DNS lookup".
+func (p Phase) String() string {
+	if p < 0 || int(p) >= len(phaseNames) {
+		return "Phase(" + strconv.Itoa(int(p)) + ")"
+	}
+	return phaseNames[p]
+}
+
+// CustomPhase is an application defined phase of a request, such as
+// fetching an auth token or signing the request, recorded with StartPhase
+// and EndPhase.
+type CustomPhase struct {
+	Name     string
+	Start    time.Time
+	Duration time.Duration
+}
+
+// StartPhase starts the custom phase name.
+func (r *Result) StartPhase(name string) {
+	if r.phaseStarts == nil {
+		r.phaseStarts = make(map[string]time.Time)
+	}
+	r.phaseStarts[name] = time.Now()
+}
+
+// EndPhase ends the custom phase name and records it. It does nothing if
+// the phase was not started.
+func (r *Result) EndPhase(name string) {
+	start, ok := r.phaseStarts[name]
+	if !ok {
+		return
+	}
+	delete(r.phaseStarts, name)
+	r.customPhases = append(r.customPhases, CustomPhase{
+		Name:     name,
+		Start:    start,
+		Duration: time.Since(start),
+	})
+}
+
+// CustomPhases returns the ended custom phases in the order they ended.
+func (r *Result) CustomPhases() []CustomPhase {
+	phases := make([]CustomPhase, len(r.customPhases))
+	copy(phases, r.customPhases)
+	return phases
+}
+
+// PhaseTiming is the timing of one phase of a request.
+type PhaseTiming struct {
+	Phase Phase
+
+	// Name is the human-readable name of the phase, e.g. "DNS lookup".
+	Name string
+
+	Duration time.Duration
+
+	// StartOffset is the time from the start of the request to the start
+	// of the phase. For skipped phases, it is the time the phase would
+	// have started at.
+	StartOffset time.Duration
+
+	// Skipped reports whether the phase did not happen, e.g. the DNS
+	// lookup and connect of a reused connection. The content transfer is
+	// skipped until End is called.
+	Skipped bool
+}
+
+// Phases returns the timings of all phases of the request in the order
+// they happen, including the skipped ones. It may be called while the
+// request is in flight.
+func (r *Result) Phases() []PhaseTiming {
+	r = r.snapshot()
+	starts := [len(phaseNames)]time.Time{
+		PhaseDNS:          r.dnsStart,
+		PhaseConnect:      r.tcpStart,
+		PhaseProxyConnect: r.tcpDone,
+		PhaseTLS:          r.tlsStart,
+		PhaseContinueWait: r.continueStart,
+		PhaseServer:       r.serverStart,
+		PhaseTransfer:     r.transferStart,
+	}
+
+	phases := make([]PhaseTiming, len(phaseNames))
+	var offset time.Duration
+	for i := range phases {
+		p := Phase(i)
+		pt := PhaseTiming{
+			Phase:    p,
+			Name:     p.String(),
+			Duration: r.Duration(p),
+		}
+		pt.Skipped = r.skipped(p)
+
+		pt.StartOffset = offset
+		if start := starts[p]; !pt.Skipped && !start.IsZero() && !r.dnsStart.IsZero() {
+			pt.StartOffset = start.Sub(r.dnsStart)
+		}
+		if !pt.Skipped {
+			offset = pt.StartOffset + pt.Duration
+		}
+		phases[i] = pt
+	}
+	return phases
+}
+
+// phaseNames are the names of the phases, as used in the output of Format.
+var phaseNames = [...]string{
+	PhaseDNS:          "DNS lookup",
+	PhaseConnect:      "TCP connection",
+	PhaseProxyConnect: "Proxy CONNECT",
+	PhaseTLS:          "TLS handshake",
+	PhaseContinueWait: "100-continue wait",
+	PhaseServer:       "Server processing",
+	PhaseTransfer:     "Content transfer",
+}
+
+// phaseFields are the names of the Result fields of the phases.
+var phaseFields = [...]string{
+	PhaseDNS:          "DNSLookup",
+	PhaseConnect:      "TCPConnection",
+	PhaseProxyConnect: "ProxyConnect",
+	PhaseTLS:          "TLSHandshake",
+	PhaseContinueWait: "ContinueWait",
+	PhaseServer:       "ServerProcessing",
+	PhaseTransfer:     "ContentTransfer",
+}
+
+// Duration returns the duration of phase p of the request.
+func (r *Result) Duration(p Phase) time.Duration {
+	r.lock()
+	defer r.unlock()
+	switch p {
+	case PhaseDNS:
+		return r.DNSLookup
+	case PhaseConnect:
+		return r.TCPConnection
+	case PhaseProxyConnect:
+		return r.ProxyConnect
+	case PhaseTLS:
+		return r.TLSHandshake
+	case PhaseContinueWait:
+		return r.ContinueWait
+	case PhaseServer:
+		return r.ServerProcessing
+	case PhaseTransfer:
+		return r.contentTransfer
+	}
+	return 0
+}
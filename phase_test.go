@@ -0,0 +1,106 @@
+package httpstat
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResult_CustomPhases(t *testing.T) {
+	var result Result
+	result.StartPhase("auth-token")
+	result.StartPhase("signing")
+	time.Sleep(10 * time.Millisecond)
+	result.EndPhase("signing")
+	result.EndPhase("auth-token")
+	result.EndPhase("never-started")
+
+	phases := result.CustomPhases()
+	if len(phases) != 2 {
+		t.Fatalf("expect 2 custom phases, got %d", len(phases))
+	}
+	if phases[0].Name != "signing" || phases[1].Name != "auth-token" {
+		t.Fatalf("expect phases in the order they ended, got %+v", phases)
+	}
+	for _, p := range phases {
+		if p.Duration < 10*time.Millisecond {
+			t.Fatalf("%s duration = %v, want at least 10ms", p.Name, p.Duration)
+		}
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%+v", result)
+	if !strings.Contains(buf.String(), "\nCustom phases:\n  signing:  ") {
+		t.Fatalf("expect custom phases in Format output, got:\n%s", buf.String())
+	}
+
+	b, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal("json.Marshal failed:", err)
+	}
+	if !strings.Contains(string(b), `"customPhases":[{"name":"signing","dur":`) {
+		t.Fatalf("expect custom phases in JSON output, got %s", b)
+	}
+}
+
+func TestResult_Phases(t *testing.T) {
+	ms := time.Millisecond
+	r := NewResultFromPhases(map[Phase]time.Duration{
+		PhaseConnect:  10 * ms,
+		PhaseTLS:      20 * ms,
+		PhaseServer:   30 * ms,
+		PhaseTransfer: 40 * ms,
+	})
+
+	want := []PhaseTiming{
+		{Phase: PhaseDNS, Name: "DNS lookup", Skipped: true},
+		{Phase: PhaseConnect, Name: "TCP connection", Duration: 10 * ms},
+		{Phase: PhaseProxyConnect, Name: "Proxy CONNECT", StartOffset: 10 * ms, Skipped: true},
+		{Phase: PhaseTLS, Name: "TLS handshake", Duration: 20 * ms, StartOffset: 10 * ms},
+		{Phase: PhaseContinueWait, Name: "100-continue wait", StartOffset: 30 * ms, Skipped: true},
+		{Phase: PhaseServer, Name: "Server processing", Duration: 30 * ms, StartOffset: 30 * ms},
+		{Phase: PhaseTransfer, Name: "Content transfer", Duration: 40 * ms, StartOffset: 60 * ms},
+	}
+	if got := r.Phases(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Phases =\n%+v\nwant\n%+v", got, want)
+	}
+
+	var pending Result
+	if p := pending.Phases()[PhaseTransfer]; !p.Skipped {
+		t.Fatal("expect content transfer to be skipped before End")
+	}
+}
+
+func TestResult_FormatOrdered(t *testing.T) {
+	ms := time.Millisecond
+	r := NewResultFromPhases(map[Phase]time.Duration{
+		PhaseDNS:      1 * ms,
+		PhaseConnect:  2 * ms,
+		PhaseTLS:      3 * ms,
+		PhaseServer:   4 * ms,
+		PhaseTransfer: 5 * ms,
+	})
+
+	want := "DNSLookup: 1 ms, TCPConnection: 2 ms, TLSHandshake: 3 ms, ServerProcessing: 4 ms, " +
+		"ContentTransfer: 5 ms, NameLookup: 1 ms, Connect: 3 ms, Pretransfer: 6 ms, " +
+		"StartTransfer: 10 ms, Total: 15 ms"
+	if got := fmt.Sprintf("%s", r); got != want {
+		t.Fatalf("expect to be eq:\n\nwant: %s\ngot:  %s", want, got)
+	}
+	if d := r.durations()["Pretransfer"]; d != 6*ms {
+		t.Fatalf("expect Pretransfer to be 6ms, got %v", d)
+	}
+}
+
+func TestPhase_String(t *testing.T) {
+	if got := PhaseTLS.String(); got != "TLS handshake" {
+		t.Fatalf("PhaseTLS.String() = %q", got)
+	}
+	if got := fmt.Sprint(Phase(42)); got != "Phase(42)" {
+		t.Fatalf("unknown phase = %q", got)
+	}
+}
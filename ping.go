@@ -0,0 +1,214 @@
+package httpstat
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// HTTP/2 frame types and flags used by PingConn (RFC 9113, section 6).
+const (
+	h2FrameSettings = 0x4
+	h2FramePing     = 0x6
+	h2FrameGoAway   = 0x7
+	h2FlagAck       = 0x1
+)
+
+const h2Preface = "PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n"
+
+// errPingClosed is returned by Ping once the connection is closed.
+var errPingClosed = errors.New("httpstat: ping connection closed")
+
+// PingConn is an HTTP/2 connection which only sends PING frames, a cheap
+// and ongoing signal of the network latency to a server, apart from the
+// time the server takes to handle full requests. It is safe for concurrent
+// use.
+type PingConn struct {
+	// Registry, if not nil, aggregates the Result of each Ping into the
+	// bucket Key, which defaults to the host followed by " ping", so the
+	// RTTs are kept apart from the requests to the host.
+	Registry *Registry
+	Key      string
+
+	host string
+	conn net.Conn
+
+	wmu sync.Mutex // serializes writes
+
+	mu      sync.Mutex
+	next    uint64
+	pending map[uint64]chan time.Time
+	err     error
+}
+
+// DialPing connects to host like MeasureConnect, negotiating HTTP/2, and
+// returns the connection along with the Result measuring its setup.
+// tlsConfig may be nil for the default configuration; its NextProtos are
+// replaced with "h2".
+func DialPing(ctx context.Context, host string, tlsConfig *tls.Config) (*PingConn, *Result, error) {
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	} else {
+		tlsConfig = tlsConfig.Clone()
+	}
+	tlsConfig.NextProtos = []string{"h2"}
+
+	r := &Result{}
+	conn, err := connect(WithHTTPStat(ctx, r), r, host, tlsConfig)
+	if err != nil {
+		return nil, r, err
+	}
+	if r.Protocol != "h2" {
+		conn.Close()
+		return nil, r, errors.New("httpstat: server does not support HTTP/2")
+	}
+
+	c := &PingConn{
+		host:    host,
+		conn:    conn,
+		pending: make(map[uint64]chan time.Time),
+	}
+	// The client preface ends with a SETTINGS frame, empty for the
+	// defaults.
+	if err := c.writeFrame(h2FrameSettings, 0, []byte(h2Preface), nil); err != nil {
+		conn.Close()
+		return nil, r, err
+	}
+	go c.readLoop()
+	return c, r, nil
+}
+
+// Ping sends a PING frame and waits for the server to acknowledge it. The
+// returned Result is that of a request on the established connection which
+// the server answered at once: its ServerProcessing and Total are the RTT.
+func (c *PingConn) Ping(ctx context.Context) (*Result, error) {
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return nil, c.err
+	}
+	c.next++
+	id := c.next
+	ack := make(chan time.Time, 1)
+	c.pending[id] = ack
+	c.mu.Unlock()
+
+	var data [8]byte
+	binary.BigEndian.PutUint64(data[:], id)
+	start := time.Now()
+	if err := c.writeFrame(h2FramePing, 0, nil, data[:]); err != nil {
+		c.forget(id)
+		return nil, err
+	}
+
+	select {
+	case t, ok := <-ack:
+		if !ok {
+			return nil, c.closeErr()
+		}
+		r := NewResultBuilder().Start(start).Phase(PhaseServer, t.Sub(start)).Protocol("h2").Build()
+		r.isReused = true
+		if c.Registry != nil {
+			key := c.Key
+			if key == "" {
+				key = c.host + " ping"
+			}
+			c.Registry.Aggregator(key).Add(r)
+		}
+		return r, nil
+	case <-ctx.Done():
+		c.forget(id)
+		return nil, ctx.Err()
+	}
+}
+
+// Close closes the connection. Pings waiting for their acknowledgement
+// fail.
+func (c *PingConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *PingConn) forget(id uint64) {
+	c.mu.Lock()
+	delete(c.pending, id)
+	c.mu.Unlock()
+}
+
+func (c *PingConn) closeErr() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// writeFrame writes prefix, if any, followed by a frame on stream 0.
+func (c *PingConn) writeFrame(typ, flags byte, prefix, payload []byte) error {
+	buf := make([]byte, 0, len(prefix)+9+len(payload))
+	buf = append(buf, prefix...)
+	n := len(payload)
+	buf = append(buf, byte(n>>16), byte(n>>8), byte(n), typ, flags, 0, 0, 0, 0)
+	buf = append(buf, payload...)
+
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	_, err := c.conn.Write(buf)
+	return err
+}
+
+// readLoop reads the frames of the server, acknowledging its SETTINGS and
+// PING frames, until the connection fails. Frames of other types are
+// skipped, as no streams are opened.
+func (c *PingConn) readLoop() {
+	br := bufio.NewReader(c.conn)
+	var hdr [9]byte
+	var err error
+	for {
+		if _, err = io.ReadFull(br, hdr[:]); err != nil {
+			break
+		}
+		n := int(hdr[0])<<16 | int(hdr[1])<<8 | int(hdr[2])
+		payload := make([]byte, n)
+		if _, err = io.ReadFull(br, payload); err != nil {
+			break
+		}
+		typ, flags := hdr[3], hdr[4]
+
+		switch {
+		case typ == h2FrameSettings && flags&h2FlagAck == 0:
+			err = c.writeFrame(h2FrameSettings, h2FlagAck, nil, nil)
+		case typ == h2FramePing && flags&h2FlagAck == 0:
+			err = c.writeFrame(h2FramePing, h2FlagAck, nil, payload)
+		case typ == h2FramePing && len(payload) == 8:
+			t := time.Now()
+			id := binary.BigEndian.Uint64(payload)
+			c.mu.Lock()
+			if ack, ok := c.pending[id]; ok {
+				delete(c.pending, id)
+				ack <- t
+			}
+			c.mu.Unlock()
+		case typ == h2FrameGoAway:
+			err = errors.New("httpstat: server sent GOAWAY")
+		}
+		if err != nil {
+			break
+		}
+	}
+
+	if errors.Is(err, net.ErrClosed) || err == io.EOF {
+		err = errPingClosed
+	}
+	c.mu.Lock()
+	c.err = err
+	for id, ack := range c.pending {
+		delete(c.pending, id)
+		close(ack)
+	}
+	c.mu.Unlock()
+	c.conn.Close()
+}
@@ -0,0 +1,57 @@
+package httpstat
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDialPing(t *testing.T) {
+	ts := httptest.NewUnstartedServer(http.NotFoundHandler())
+	ts.EnableHTTP2 = true
+	ts.StartTLS()
+	defer ts.Close()
+
+	host := strings.TrimPrefix(ts.URL, "https://")
+	tlsConfig := ts.Client().Transport.(*http.Transport).TLSClientConfig
+	ctx := context.Background()
+	conn, setup, err := DialPing(ctx, host, tlsConfig)
+	if err != nil {
+		t.Fatal("DialPing failed:", err)
+	}
+	if setup.TLSHandshake <= 0 || setup.Protocol != "h2" {
+		t.Fatalf("expect the setup to be measured, got %+v", setup)
+	}
+
+	var reg Registry
+	conn.Registry = &reg
+	for i := 0; i < 3; i++ {
+		r, err := conn.Ping(ctx)
+		if err != nil {
+			t.Fatal("Ping failed:", err)
+		}
+		if r.ServerProcessing <= 0 || r.Total() != r.ServerProcessing || r.TCPConnection != 0 {
+			t.Fatalf("expect the RTT as server processing, got %+v", r)
+		}
+	}
+	if got := reg.Aggregator(host + " ping").Count(); got != 3 {
+		t.Fatalf("expect 3 pings in the Registry, got %d", got)
+	}
+
+	conn.Close()
+	if _, err := conn.Ping(ctx); err == nil {
+		t.Fatal("expect Ping to fail on a closed connection")
+	}
+}
+
+func TestDialPing_NoHTTP2(t *testing.T) {
+	ts := httptest.NewTLSServer(http.NotFoundHandler())
+	defer ts.Close()
+
+	tlsConfig := ts.Client().Transport.(*http.Transport).TLSClientConfig
+	if _, _, err := DialPing(context.Background(), strings.TrimPrefix(ts.URL, "https://"), tlsConfig); err == nil {
+		t.Fatal("expect DialPing to fail without HTTP/2")
+	}
+}
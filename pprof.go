@@ -0,0 +1,38 @@
+package httpstat
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"runtime/pprof"
+)
+
+// withPprofLabels returns a copy of ctx with hooks that set the pprof label
+// "phase" on the goroutines running the phases of the request: "dns",
+// "connect" and "tls" on the goroutine dialing the connection, and "wait"
+// on the goroutine sending the request once it got a connection. At the end
+// of each phase the labels of base are restored.
+func withPprofLabels(ctx, base context.Context) context.Context {
+	set := func(phase string) func() {
+		labels := pprof.WithLabels(base, pprof.Labels("phase", phase))
+		return func() { pprof.SetGoroutineLabels(labels) }
+	}
+	dns, connect, tlsStart, wait := set("dns"), set("connect"), set("tls"), set("wait")
+	reset := func() { pprof.SetGoroutineLabels(base) }
+
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart:          func(httptrace.DNSStartInfo) { dns() },
+		DNSDone:           func(httptrace.DNSDoneInfo) { reset() },
+		ConnectStart:      func(string, string) { connect() },
+		ConnectDone:       func(string, string, error) { reset() },
+		TLSHandshakeStart: tlsStart,
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { reset() },
+		GotConn:           func(httptrace.GotConnInfo) { wait() },
+	})
+}
+
+// transferLabels returns the labels of ctx with the pprof label "phase"
+// set to "transfer".
+func transferLabels(ctx context.Context) context.Context {
+	return pprof.WithLabels(ctx, pprof.Labels("phase", "transfer"))
+}
@@ -0,0 +1,72 @@
+package httpstat
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime/pprof"
+	"strings"
+	"testing"
+	"time"
+)
+
+// goroutineLabels returns the goroutine profile with the labels of each
+// goroutine.
+func goroutineLabels() string {
+	var buf bytes.Buffer
+	pprof.Lookup("goroutine").WriteTo(&buf, 1)
+	return buf.String()
+}
+
+func TestTransport_PprofLabels(t *testing.T) {
+	waiting, transferring := make(chan struct{}), make(chan struct{})
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(waiting)
+		<-release
+		io.WriteString(w, "first")
+		w.(http.Flusher).Flush()
+		close(transferring)
+		<-release
+	}))
+	defer ts.Close()
+
+	client := &http.Client{Transport: &Transport{Base: DefaultTransport(), PprofLabels: true}}
+	ctx := pprof.WithLabels(context.Background(), pprof.Labels("caller", "test"))
+	done := make(chan struct{})
+	go pprof.Do(ctx, pprof.Labels(), func(ctx context.Context) {
+		defer close(done)
+		req, _ := http.NewRequestWithContext(ctx, "GET", ts.URL, nil)
+		res, err := client.Do(req)
+		if err != nil {
+			t.Error("request failed:", err)
+			return
+		}
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+	})
+
+	expect := func(label string) {
+		t.Helper()
+		// The goroutine may not be blocked yet.
+		for i := 0; i < 100; i++ {
+			if strings.Contains(goroutineLabels(), label) {
+				return
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+		t.Fatalf("expect a goroutine labeled %s in:\n%s", label, goroutineLabels())
+	}
+	<-waiting
+	expect(`{"caller":"test", "phase":"wait"}`)
+	release <- struct{}{}
+	<-transferring
+	expect(`{"caller":"test", "phase":"transfer"}`)
+	close(release)
+	<-done
+	if strings.Contains(goroutineLabels(), `"phase":"transfer"`) {
+		t.Fatal("expect the labels to be restored after the transfer")
+	}
+}
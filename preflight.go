@@ -0,0 +1,92 @@
+package httpstat
+
+import (
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PreflightResult stores httpstat information of a CORS preflight
+// (OPTIONS) request and the actual request that followed it.
+type PreflightResult struct {
+	Preflight Result
+	Actual    Result
+
+	// PreflightStatusCode is the status code of the preflight response.
+	PreflightStatusCode int
+}
+
+// Total returns the combined latency of the preflight and the actual
+// request, which is the latency a browser user perceives. End must be
+// called on Actual after reading the response body for it to be final.
+func (p *PreflightResult) Total() time.Duration {
+	return p.Preflight.Total() + p.Actual.Total()
+}
+
+// DoWithPreflight sends the CORS preflight request a browser would send
+// for req from the given origin, and then sends req itself. Both requests
+// are measured. The preflight response body is read and closed; the
+// caller must read and close the returned response body and call
+// p.Actual.EndNow() afterwards.
+func DoWithPreflight(client *http.Client, req *http.Request, origin string) (*http.Response, *PreflightResult, error) {
+	p := &PreflightResult{}
+
+	preq, err := http.NewRequest(http.MethodOptions, req.URL.String(), nil)
+	if err != nil {
+		return nil, p, err
+	}
+	preq.Header.Set("Origin", origin)
+	preq.Header.Set("Access-Control-Request-Method", req.Method)
+	if h := preflightHeaders(req.Header); h != "" {
+		preq.Header.Set("Access-Control-Request-Headers", h)
+	}
+	preq = preq.WithContext(WithHTTPStat(req.Context(), &p.Preflight))
+
+	pres, err := client.Do(preq)
+	if err != nil {
+		return nil, p, err
+	}
+	_, err = io.Copy(io.Discard, pres.Body)
+	pres.Body.Close()
+	p.Preflight.EndNow()
+	p.PreflightStatusCode = pres.StatusCode
+	if err != nil {
+		return nil, p, err
+	}
+	if pres.StatusCode < 200 || pres.StatusCode > 299 {
+		return nil, p, fmt.Errorf("preflight request failed: %s", pres.Status)
+	}
+
+	req = req.Clone(WithHTTPStat(req.Context(), &p.Actual))
+	req.Header.Set("Origin", origin)
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, p, err
+	}
+	return res, p, nil
+}
+
+// preflightHeaders returns the comma separated, lower-cased names of the
+// headers which are not CORS-safelisted and therefore need to be announced
+// in a preflight request.
+func preflightHeaders(h http.Header) string {
+	names := make([]string, 0, len(h))
+	for k := range h {
+		switch strings.ToLower(k) {
+		case "accept", "accept-language", "content-language", "origin":
+			continue
+		case "content-type":
+			switch mt, _, _ := mime.ParseMediaType(h.Get(k)); mt {
+			case "application/x-www-form-urlencoded", "multipart/form-data", "text/plain":
+				continue
+			}
+		}
+		names = append(names, strings.ToLower(k))
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,77 @@
+package httpstat
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDoWithPreflight(t *testing.T) {
+	var preflight *http.Request
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			preflight = r
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	defer ts.Close()
+
+	req, err := http.NewRequest("PUT", ts.URL, nil)
+	if err != nil {
+		t.Fatal("NewRequest failed:", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Request-Id", "1")
+
+	res, p, err := DoWithPreflight(DefaultClient(), req, "https://example.org")
+	if err != nil {
+		t.Fatal("DoWithPreflight failed:", err)
+	}
+	if _, err := io.Copy(io.Discard, res.Body); err != nil {
+		t.Fatal("io.Copy failed:", err)
+	}
+	res.Body.Close()
+	p.Actual.EndNow()
+
+	if got, want := preflight.Header.Get("Access-Control-Request-Method"), "PUT"; got != want {
+		t.Fatalf("Access-Control-Request-Method = %q, want %q", got, want)
+	}
+	if got, want := preflight.Header.Get("Access-Control-Request-Headers"), "content-type,x-request-id"; got != want {
+		t.Fatalf("Access-Control-Request-Headers = %q, want %q", got, want)
+	}
+	if got, want := p.PreflightStatusCode, http.StatusNoContent; got != want {
+		t.Fatalf("PreflightStatusCode = %d, want %d", got, want)
+	}
+	if req.Header.Get("Origin") != "" {
+		t.Fatal("expect the original request to be left untouched")
+	}
+
+	if p.Preflight.ServerProcessing <= 0 || p.Actual.ServerProcessing <= 0 {
+		t.Fatal("expect ServerProcessing of both requests to be non-zero")
+	}
+	if got, want := p.Total(), p.Preflight.Total()+p.Actual.Total(); got != want {
+		t.Fatalf("Total = %d, want %d", got, want)
+	}
+}
+
+func TestDoWithPreflight_Rejected(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer ts.Close()
+
+	req, err := http.NewRequest("DELETE", ts.URL, nil)
+	if err != nil {
+		t.Fatal("NewRequest failed:", err)
+	}
+
+	if _, p, err := DoWithPreflight(DefaultClient(), req, "https://example.org"); err == nil {
+		t.Fatal("expect error when preflight is rejected")
+	} else if p.PreflightStatusCode != http.StatusForbidden {
+		t.Fatalf("PreflightStatusCode = %d, want %d", p.PreflightStatusCode, http.StatusForbidden)
+	}
+}
@@ -0,0 +1,100 @@
+package httpstat
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// ProbeOption configures Probe.
+type ProbeOption func(*probeConfig)
+
+type probeConfig struct {
+	method    string
+	header    http.Header
+	timeout   time.Duration
+	tlsConfig *tls.Config
+}
+
+// WithProbeMethod sets the method of the request sent by Probe, which is
+// GET by default.
+func WithProbeMethod(method string) ProbeOption {
+	return func(c *probeConfig) { c.method = method }
+}
+
+// WithProbeHeader adds a header to the request sent by Probe.
+func WithProbeHeader(key, value string) ProbeOption {
+	return func(c *probeConfig) { c.header.Add(key, value) }
+}
+
+// WithProbeTimeout sets the timeout of Probe, 10 seconds by default.
+func WithProbeTimeout(d time.Duration) ProbeOption {
+	return func(c *probeConfig) { c.timeout = d }
+}
+
+// WithProbeTLSConfig sets the TLS config of the connection of Probe, e.g.
+// to trust a private CA.
+func WithProbeTLSConfig(tlsConfig *tls.Config) ProbeOption {
+	return func(c *probeConfig) { c.tlsConfig = tlsConfig }
+}
+
+// Probe sends a request to url on a new connection, reads the response body
+// and returns the ended Result, with the Metadata of the response recorded.
+// It is a one-call measurement for health checks and uptime monitors: each
+// Probe measures the full connection setup, as no connections are kept
+// alive. Responses with a status code of 400 or above are returned with an
+// error along with the Result.
+func Probe(ctx context.Context, url string, opts ...ProbeOption) (*Result, error) {
+	c := probeConfig{method: http.MethodGet, header: make(http.Header), timeout: 10 * time.Second}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	r := &Result{}
+	req, err := http.NewRequestWithContext(WithHTTPStat(ctx, r), c.method, url, nil)
+	if err != nil {
+		return r, err
+	}
+	for key, values := range c.header {
+		req.Header[key] = values
+	}
+	if host := req.Header.Get("Host"); host != "" {
+		req.Host = host
+	}
+
+	transport := &http.Transport{
+		Proxy:             http.ProxyFromEnvironment,
+		DialContext:       (&net.Dialer{Timeout: 30 * time.Second}).DialContext,
+		TLSClientConfig:   c.tlsConfig,
+		ForceAttemptHTTP2: true,
+		DisableKeepAlives: true,
+	}
+	defer transport.CloseIdleConnections()
+	client := &http.Client{Transport: transport}
+
+	res, err := client.Do(req)
+	if err != nil {
+		r.abort()
+		return r, err
+	}
+	_, err = io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+	r.EndNow()
+	r.RecordResponse(res)
+	if err != nil {
+		return r, err
+	}
+	if res.StatusCode >= 400 {
+		return r, fmt.Errorf("httpstat: %s %s: %s", c.method, req.URL.Redacted(), res.Status)
+	}
+	return r, nil
+}
@@ -0,0 +1,64 @@
+package httpstat
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestProbe(t *testing.T) {
+	var conns int
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Check") != "1" || r.Method != "HEAD" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	ts.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			conns++
+		}
+	}
+	ts.StartTLS()
+	defer ts.Close()
+
+	tlsConfig := ts.Client().Transport.(*http.Transport).TLSClientConfig
+	for i := 0; i < 2; i++ {
+		r, err := Probe(context.Background(), ts.URL, WithProbeMethod("HEAD"), WithProbeHeader("X-Check", "1"), WithProbeTLSConfig(tlsConfig))
+		if err != nil {
+			t.Fatal("Probe failed:", err)
+		}
+		if !r.IsComplete() || r.TLSHandshake <= 0 || r.Metadata == nil || r.Metadata.StatusCode != 200 {
+			t.Fatalf("expect a complete Result with the TLS handshake, got %+v", r)
+		}
+	}
+	if conns != 2 {
+		t.Fatalf("server got %d connections, want one per probe", conns)
+	}
+
+	r, err := Probe(context.Background(), ts.URL, WithProbeTLSConfig(tlsConfig))
+	if err == nil || !strings.Contains(err.Error(), "400 Bad Request") || !r.IsComplete() {
+		t.Fatalf("expect an error for status 400 along with the Result, got %v", err)
+	}
+}
+
+func TestProbe_Timeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer ts.Close()
+
+	r, err := Probe(context.Background(), ts.URL, WithProbeTimeout(20*time.Millisecond))
+	if err == nil {
+		t.Fatal("expect the probe to time out")
+	}
+	if p, ok := r.FailedPhase(); !ok || p != PhaseServer {
+		t.Fatalf("FailedPhase = %v, %v, want the server processing", p, ok)
+	}
+}
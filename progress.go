@@ -0,0 +1,83 @@
+package httpstat
+
+import (
+	"time"
+)
+
+// Progress describes how far the content transfer of a response got.
+type Progress struct {
+	// BytesRead is the number of body bytes read so far.
+	BytesRead int64
+
+	// ContentLength is the length of the body, or -1 if it is unknown.
+	ContentLength int64
+
+	// Elapsed is the time since the content transfer started.
+	Elapsed time.Duration
+
+	// Rate is the current throughput in bytes per second, measured since
+	// the previous report.
+	Rate float64
+}
+
+// ProgressObserver is an Observer which is also notified about the
+// progress of the content transfer, e.g. to drive download progress bars.
+// The reports are enabled with WithProgress or Transport.ProgressInterval.
+type ProgressObserver interface {
+	Observer
+
+	// OnProgress is called periodically while the body is read, and once
+	// it was read to the end or closed.
+	OnProgress(r *Result, p Progress)
+}
+
+// OnProgress implements ProgressObserver.
+func (o ObserverFuncs) OnProgress(r *Result, p Progress) {
+	if o.Progress != nil {
+		o.Progress(r, p)
+	}
+}
+
+// WithProgress makes the body wrapper report the progress of reading the
+// body to the Observer of the Result at most once per interval, if it is a
+// ProgressObserver. The reports are made from Read, so a stalled body is
+// not reported until it makes progress again.
+func WithProgress(interval time.Duration) BodyOption {
+	return func(b *body) {
+		b.progressInterval = interval
+	}
+}
+
+// progress reports the progress if the interval passed since the previous
+// report, or if final is set.
+func (b *body) progress(final bool) {
+	o, ok := b.result.observer.(ProgressObserver)
+	if !ok || b.progressInterval <= 0 {
+		return
+	}
+	now := time.Now()
+	if b.progressStart.IsZero() {
+		// Without the first byte hook, the transfer is measured from
+		// the first read.
+		b.progressStart = b.result.transferStart
+		if b.progressStart.IsZero() {
+			b.progressStart = now
+		}
+		b.lastReport = b.progressStart
+	}
+	since := now.Sub(b.lastReport)
+	if !final && since < b.progressInterval {
+		return
+	}
+
+	p := Progress{
+		BytesRead:     b.result.BodyLength,
+		ContentLength: b.res.ContentLength,
+		Elapsed:       now.Sub(b.progressStart),
+	}
+	if since > 0 {
+		p.Rate = float64(p.BytesRead-b.lastBytes) / since.Seconds()
+	}
+	b.lastReport, b.lastBytes = now, p.BytesRead
+	o.OnProgress(b.result, p)
+}
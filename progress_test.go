@@ -0,0 +1,52 @@
+package httpstat
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestBody_Progress(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(5*1000))
+		for i := 0; i < 5; i++ {
+			w.Write(make([]byte, 1000))
+			w.(http.Flusher).Flush()
+			time.Sleep(20 * time.Millisecond)
+		}
+	}))
+	defer ts.Close()
+
+	var reports []Progress
+	client := &http.Client{Transport: &Transport{
+		Base:             DefaultTransport(),
+		ProgressInterval: 15 * time.Millisecond,
+		Observer: &AnomalyDetector{Next: ObserverFuncs{
+			Progress: func(r *Result, p Progress) {
+				reports = append(reports, p)
+			},
+		}},
+	}}
+	res, err := client.Get(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+
+	if len(reports) < 3 {
+		t.Fatalf("expect periodic reports, got %+v", reports)
+	}
+	last := reports[len(reports)-1]
+	if last.BytesRead != 5000 || last.ContentLength != 5000 || last.Elapsed < 80*time.Millisecond {
+		t.Fatalf("unexpected final report %+v", last)
+	}
+	for i, p := range reports[:len(reports)-1] {
+		if p.BytesRead > reports[i+1].BytesRead || p.Rate <= 0 {
+			t.Fatalf("unexpected report %+v", p)
+		}
+	}
+}
@@ -0,0 +1,444 @@
+package httpstat
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// The wire types of the protocol buffers encoding.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var errProtoTruncated = errors.New("httpstat: truncated protobuf message")
+
+// MarshalProto returns the protocol buffers encoding of r, as a
+// httpstat.v1.Result message of result.proto, for shipping Results
+// compactly between collectors. Unlike MarshalJSON, it keeps the timestamps
+// of the phases, so the Result can be restored with UnmarshalProto.
+func (r *Result) MarshalProto() ([]byte, error) {
+	return r.snapshot().appendProto(nil), nil
+}
+
+// UnmarshalProto sets r from the protocol buffers encoding b returned by
+// MarshalProto. Unknown fields are skipped.
+func (r *Result) UnmarshalProto(b []byte) error {
+	var res Result
+	err := consumeFields(b, func(num int, typ int, v uint64, data []byte) error {
+		switch num {
+		case 1:
+			res.Protocol = string(data)
+		case 2:
+			res.ProxyURL = string(data)
+		case 3:
+			res.isTLS = v != 0
+		case 4:
+			res.isReused = v != 0
+		case 5:
+			res.Used0RTT = v != 0
+		case 6:
+			res.isQUIC = v != 0
+		case 7:
+			res.Downgraded = v != 0
+		case 8:
+			res.SocketPath = string(data)
+		case 10:
+			res.DNSLookup = time.Duration(v)
+		case 11:
+			res.TCPConnection = time.Duration(v)
+		case 12:
+			res.ProxyConnect = time.Duration(v)
+		case 13:
+			res.TLSHandshake = time.Duration(v)
+		case 14:
+			res.ContinueWait = time.Duration(v)
+		case 15:
+			res.ServerProcessing = time.Duration(v)
+		case 16:
+			res.contentTransfer = time.Duration(v)
+		case 17:
+			res.TrailerWait = time.Duration(v)
+		case 18:
+			res.Decompression = time.Duration(v)
+		case 19:
+			res.StreamWait = time.Duration(v)
+		case 20:
+			res.NameLookup = time.Duration(v)
+		case 21:
+			res.Connect = time.Duration(v)
+		case 22:
+			res.Pretransfer = time.Duration(v)
+		case 23:
+			res.StartTransfer = time.Duration(v)
+		case 24:
+			res.total = time.Duration(v)
+		case 30:
+			res.dnsStart = protoTime(v)
+		case 31:
+			res.tcpStart = protoTime(v)
+		case 32:
+			res.tcpDone = protoTime(v)
+		case 33:
+			res.tlsStart = protoTime(v)
+		case 34:
+			res.continueStart = protoTime(v)
+		case 35:
+			res.serverStart = protoTime(v)
+		case 36:
+			res.serverDone = protoTime(v)
+		case 37:
+			res.transferStart = protoTime(v)
+		case 38:
+			res.lastByte = protoTime(v)
+		case 39:
+			res.trailersAt = protoTime(v)
+		case 40:
+			res.BodyLength = int64(v)
+		case 41:
+			res.CompressedLength = int64(v)
+		case 42:
+			res.BodyDigest = append([]byte(nil), data...)
+		case 43:
+			var st ServerTiming
+			err := consumeFields(data, func(num, _ int, v uint64, data []byte) error {
+				switch num {
+				case 1:
+					st.Name = string(data)
+				case 2:
+					st.Duration = time.Duration(v)
+				case 3:
+					st.Description = string(data)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			res.ServerTiming = append(res.ServerTiming, st)
+		case 44:
+			var p CustomPhase
+			err := consumeFields(data, func(num, _ int, v uint64, data []byte) error {
+				switch num {
+				case 1:
+					p.Name = string(data)
+				case 2:
+					p.Start = protoTime(v)
+				case 3:
+					p.Duration = time.Duration(v)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			res.customPhases = append(res.customPhases, p)
+		case 45:
+			var k, val string
+			err := consumeFields(data, func(num, _ int, _ uint64, data []byte) error {
+				switch num {
+				case 1:
+					k = string(data)
+				case 2:
+					val = string(data)
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			res.SetLabel(k, val)
+		case 46:
+			m, err := unmarshalProtoMetadata(data)
+			if err != nil {
+				return err
+			}
+			res.Metadata = m
+		case 47:
+			res.ConcurrentStreams = int(int32(v))
+		case 50:
+			res.Stalled = v != 0
+		case 51:
+			res.failed = v != 0
+		case 52:
+			res.failedPhase = Phase(v)
+		case 53:
+			res.failedAt = protoTime(v)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	res.t5 = addIfSet(res.dnsStart, res.total)
+	*r = res
+	return nil
+}
+
+func (r *Result) appendProto(b []byte) []byte {
+	b = appendProtoString(b, 1, r.Protocol)
+	b = appendProtoString(b, 2, r.ProxyURL)
+	b = appendProtoBool(b, 3, r.isTLS)
+	b = appendProtoBool(b, 4, r.isReused)
+	b = appendProtoBool(b, 5, r.Used0RTT)
+	b = appendProtoBool(b, 6, r.isQUIC)
+	b = appendProtoBool(b, 7, r.Downgraded)
+	b = appendProtoString(b, 8, r.SocketPath)
+
+	b = appendProtoInt(b, 10, int64(r.DNSLookup))
+	b = appendProtoInt(b, 11, int64(r.TCPConnection))
+	b = appendProtoInt(b, 12, int64(r.ProxyConnect))
+	b = appendProtoInt(b, 13, int64(r.TLSHandshake))
+	b = appendProtoInt(b, 14, int64(r.ContinueWait))
+	b = appendProtoInt(b, 15, int64(r.ServerProcessing))
+	b = appendProtoInt(b, 16, int64(r.contentTransfer))
+	b = appendProtoInt(b, 17, int64(r.TrailerWait))
+	b = appendProtoInt(b, 18, int64(r.Decompression))
+	b = appendProtoInt(b, 19, int64(r.StreamWait))
+
+	b = appendProtoInt(b, 20, int64(r.NameLookup))
+	b = appendProtoInt(b, 21, int64(r.Connect))
+	b = appendProtoInt(b, 22, int64(r.Pretransfer))
+	b = appendProtoInt(b, 23, int64(r.StartTransfer))
+	b = appendProtoInt(b, 24, int64(r.total))
+
+	b = appendProtoTime(b, 30, r.dnsStart)
+	b = appendProtoTime(b, 31, r.tcpStart)
+	b = appendProtoTime(b, 32, r.tcpDone)
+	b = appendProtoTime(b, 33, r.tlsStart)
+	b = appendProtoTime(b, 34, r.continueStart)
+	b = appendProtoTime(b, 35, r.serverStart)
+	b = appendProtoTime(b, 36, r.serverDone)
+	b = appendProtoTime(b, 37, r.transferStart)
+	b = appendProtoTime(b, 38, r.lastByte)
+	b = appendProtoTime(b, 39, r.trailersAt)
+
+	b = appendProtoInt(b, 40, r.BodyLength)
+	b = appendProtoInt(b, 41, r.CompressedLength)
+	if len(r.BodyDigest) > 0 {
+		b = appendProtoBytes(b, 42, r.BodyDigest)
+	}
+	for _, st := range r.ServerTiming {
+		var m []byte
+		m = appendProtoString(m, 1, st.Name)
+		m = appendProtoInt(m, 2, int64(st.Duration))
+		m = appendProtoString(m, 3, st.Description)
+		b = appendProtoBytes(b, 43, m)
+	}
+	for _, p := range r.customPhases {
+		var m []byte
+		m = appendProtoString(m, 1, p.Name)
+		m = appendProtoTime(m, 2, p.Start)
+		m = appendProtoInt(m, 3, int64(p.Duration))
+		b = appendProtoBytes(b, 44, m)
+	}
+	// Map entries are sorted, so the encoding is deterministic.
+	keys := make([]string, 0, len(r.labels))
+	for k := range r.labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		var m []byte
+		m = appendProtoString(m, 1, k)
+		m = appendProtoString(m, 2, r.labels[k])
+		b = appendProtoBytes(b, 45, m)
+	}
+	if r.Metadata != nil {
+		b = appendProtoBytes(b, 46, appendProtoMetadata(nil, r.Metadata))
+	}
+	b = appendProtoInt(b, 47, int64(r.ConcurrentStreams))
+
+	b = appendProtoBool(b, 50, r.Stalled)
+	b = appendProtoBool(b, 51, r.failed)
+	if r.failed {
+		b = appendProtoInt(b, 52, int64(r.failedPhase))
+	}
+	b = appendProtoTime(b, 53, r.failedAt)
+	return b
+}
+
+func appendProtoMetadata(b []byte, m *Metadata) []byte {
+	b = appendProtoString(b, 1, m.Method)
+	b = appendProtoString(b, 2, m.URL)
+	b = appendProtoInt(b, 3, int64(m.StatusCode))
+	b = appendProtoInt(b, 4, m.ContentLength)
+	keys := make([]string, 0, len(m.Header))
+	for k := range m.Header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		h := appendProtoString(nil, 1, k)
+		for _, v := range m.Header[k] {
+			h = appendProtoBytes(h, 2, []byte(v))
+		}
+		b = appendProtoBytes(b, 5, h)
+	}
+	b = appendProtoString(b, 6, m.Proxy)
+	return b
+}
+
+func unmarshalProtoMetadata(b []byte) (*Metadata, error) {
+	m := &Metadata{}
+	err := consumeFields(b, func(num, _ int, v uint64, data []byte) error {
+		switch num {
+		case 1:
+			m.Method = string(data)
+		case 2:
+			m.URL = string(data)
+		case 3:
+			m.StatusCode = int(int32(v))
+		case 4:
+			m.ContentLength = int64(v)
+		case 5:
+			var name string
+			var values []string
+			err := consumeFields(data, func(num, _ int, _ uint64, data []byte) error {
+				switch num {
+				case 1:
+					name = string(data)
+				case 2:
+					values = append(values, string(data))
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			if m.Header == nil {
+				m.Header = make(http.Header)
+			}
+			m.Header[name] = append(m.Header[name], values...)
+		case 6:
+			m.Proxy = string(data)
+		}
+		return nil
+	})
+	return m, err
+}
+
+// The following append fields to a protocol buffers message. Fields with
+// the zero value are left out, as in proto3.
+
+func appendProtoInt(b []byte, num int, v int64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = appendVarint(b, uint64(num)<<3|wireVarint)
+	return appendVarint(b, uint64(v))
+}
+
+func appendProtoBool(b []byte, num int, v bool) []byte {
+	if !v {
+		return b
+	}
+	return appendProtoInt(b, num, 1)
+}
+
+func appendProtoTime(b []byte, num int, t time.Time) []byte {
+	if t.IsZero() {
+		return b
+	}
+	return appendProtoInt(b, num, t.UnixNano())
+}
+
+func appendProtoString(b []byte, num int, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = appendVarint(b, uint64(num)<<3|wireBytes)
+	b = appendVarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+func appendProtoBytes(b []byte, num int, v []byte) []byte {
+	b = appendVarint(b, uint64(num)<<3|wireBytes)
+	b = appendVarint(b, uint64(len(v)))
+	return append(b, v...)
+}
+
+func appendVarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
+
+func protoTime(v uint64) time.Time {
+	if v == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, int64(v))
+}
+
+// consumeFields calls f with each field of the protocol buffers message b:
+// with the value of varint and fixed fields as v, and the contents of
+// length-delimited fields as data.
+func consumeFields(b []byte, f func(num, typ int, v uint64, data []byte) error) error {
+	for len(b) > 0 {
+		tag, n := consumeVarint(b)
+		if n == 0 {
+			return errProtoTruncated
+		}
+		b = b[n:]
+		num, typ := int(tag>>3), int(tag&7)
+		if num == 0 {
+			return errors.New("httpstat: invalid protobuf field number 0")
+		}
+
+		var v uint64
+		var data []byte
+		switch typ {
+		case wireVarint:
+			v, n = consumeVarint(b)
+			if n == 0 {
+				return errProtoTruncated
+			}
+			b = b[n:]
+		case wireFixed64, wireFixed32:
+			size := 8
+			if typ == wireFixed32 {
+				size = 4
+			}
+			if len(b) < size {
+				return errProtoTruncated
+			}
+			for i := size - 1; i >= 0; i-- {
+				v = v<<8 | uint64(b[i])
+			}
+			b = b[size:]
+		case wireBytes:
+			l, n := consumeVarint(b)
+			if n == 0 || uint64(len(b)-n) < l {
+				return errProtoTruncated
+			}
+			data = b[n : n+int(l)]
+			b = b[n+int(l):]
+		default:
+			return errors.New("httpstat: unsupported protobuf wire type")
+		}
+		if err := f(num, typ, v, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// consumeVarint returns the varint at the start of b and its length, or a
+// length of 0 if b does not start with a valid varint.
+func consumeVarint(b []byte) (uint64, int) {
+	var v uint64
+	for i := 0; i < len(b) && i < 10; i++ {
+		v |= uint64(b[i]&0x7f) << (7 * i)
+		if b[i] < 0x80 {
+			return v, i + 1
+		}
+	}
+	return 0, 0
+}
@@ -0,0 +1,70 @@
+package httpstat
+
+import (
+	"bytes"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestResult_MarshalProto(t *testing.T) {
+	start := time.Unix(0, 1700000000123456789)
+	result := NewResultBuilder().Start(start).
+		Phase(PhaseDNS, 2*time.Millisecond).
+		Phase(PhaseTLS, 5*time.Millisecond).
+		Phase(PhaseServer, 20*time.Millisecond).
+		Phase(PhaseTransfer, 3*time.Millisecond).
+		Protocol("h2").
+		Build()
+	result.BodyLength = 1234
+	result.BodyDigest = []byte{1, 2, 3}
+	result.ServerTiming = []ServerTiming{{Name: "db", Duration: 7 * time.Millisecond, Description: "query"}}
+	result.customPhases = []CustomPhase{{Name: "parse", Start: start.Add(time.Second), Duration: time.Millisecond}}
+	result.SetLabel("region", "eu")
+	result.SetLabel("env", "prod")
+	result.Metadata = &Metadata{
+		Method:        "GET",
+		URL:           "https://example.com",
+		StatusCode:    200,
+		ContentLength: -1,
+		Header:        http.Header{"Content-Type": {"text/plain"}, "Vary": {"Accept", "Origin"}},
+	}
+	result.fail(PhaseServer)
+	result.failedAt = start.Add(time.Minute)
+
+	b, err := result.MarshalProto()
+	if err != nil {
+		t.Fatal("MarshalProto failed:", err)
+	}
+	if !bytes.HasPrefix(b, []byte{0x0a, 0x02, 'h', '2'}) {
+		t.Fatalf("expect the protocol as field 1, got % x", b[:4])
+	}
+	var got Result
+	if err := got.UnmarshalProto(b); err != nil {
+		t.Fatal("UnmarshalProto failed:", err)
+	}
+	if !reflect.DeepEqual(&got, result) {
+		t.Fatalf("expect\n%#v\ngot\n%#v", result, &got)
+	}
+
+	again, _ := got.MarshalProto()
+	if !bytes.Equal(again, b) {
+		t.Fatal("expect the encoding to be deterministic")
+	}
+}
+
+func TestResult_UnmarshalProtoInvalid(t *testing.T) {
+	result := NewResultBuilder().Phase(PhaseDNS, time.Millisecond).Protocol("h2").Build()
+	b, _ := result.MarshalProto()
+
+	var got Result
+	if err := got.UnmarshalProto(b[:len(b)-1]); err == nil {
+		t.Fatal("expect truncated message to fail")
+	}
+	// Unknown fields are skipped.
+	b = append(appendProtoString(nil, 1000, "future"), b...)
+	if err := got.UnmarshalProto(b); err != nil || got.Protocol != "h2" {
+		t.Fatalf("expect unknown fields to be skipped, got %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package httpstat
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ProxyFunc wraps proxy, the Proxy function of an http.Transport such as
+// http.ProxyFromEnvironment, to record the proxy chosen for each request as
+// ProxyURL, and in the Metadata whether the request was sent directly, so
+// direct and proxied requests can be told apart. For SOCKS5 proxies, whose
+// handshake is not reported by httptrace, it also records the handshake as
+// ProxyConnect, apart from the TCP connection to the proxy and the TLS
+// handshake with the origin.
+func ProxyFunc(proxy func(*http.Request) (*url.URL, error)) func(*http.Request) (*url.URL, error) {
+	return func(req *http.Request) (*url.URL, error) {
+		u, err := proxy(req)
+		if err != nil {
+			return u, err
+		}
+		if r, ok := FromContext(req.Context()); ok {
+			r.lock()
+			defer r.unlock()
+			r.proxyChosen = true
+			r.ProxyURL, r.isSOCKS = "", false
+			if u != nil {
+				r.ProxyURL = u.Redacted()
+				r.isSOCKS = strings.HasPrefix(u.Scheme, "socks5")
+			}
+		}
+		return u, nil
+	}
+}
+
+// endSOCKSHandshake records the SOCKS5 handshake, which lasts from the
+// connection to the proxy until t, as ProxyConnect. It must be called with r
+// locked.
+func (r *Result) endSOCKSHandshake(t time.Time) {
+	if r.isSOCKS && r.ProxyConnect == 0 && !r.tcpDone.IsZero() {
+		r.ProxyConnect = t.Sub(r.tcpDone)
+	}
+}
+
+// OnProxyConnectResponse records the duration of the CONNECT request which
+// establishes a tunnel through an HTTP proxy as ProxyConnect, and the proxy
+// used as ProxyURL. Set it as the OnProxyConnectResponse hook of
+// http.Transport to separate the tunnel setup from the TCP connection to
+// the proxy and the TLS handshake with the origin.
+func OnProxyConnectResponse(ctx context.Context, proxyURL *url.URL, _ *http.Request, _ *http.Response) error {
+	r, ok := FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	if !r.lockHook() {
+		return nil
+	}
+	defer r.unlock()
+	if !r.tcpDone.IsZero() {
+		r.ProxyConnect = time.Since(r.tcpDone)
+	}
+	if proxyURL != nil {
+		r.ProxyURL = proxyURL.Redacted()
+	}
+	return nil
+}
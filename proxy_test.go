@@ -0,0 +1,224 @@
+package httpstat
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newConnectProxy returns a proxy server which tunnels CONNECT requests
+// after the given delay.
+func newConnectProxy(t *testing.T, delay time.Duration) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			http.Error(w, "CONNECT only", http.StatusMethodNotAllowed)
+			return
+		}
+		time.Sleep(delay)
+
+		upstream, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error("Hijack failed:", err)
+			return
+		}
+		go func() {
+			io.Copy(upstream, buf)
+			upstream.Close()
+		}()
+		io.Copy(conn, upstream)
+		conn.Close()
+	}))
+}
+
+func TestOnProxyConnectResponse(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer ts.Close()
+
+	proxy := newConnectProxy(t, 20*time.Millisecond)
+	defer proxy.Close()
+
+	proxyURL, _ := url.Parse(proxy.URL)
+	proxyURL.User = url.UserPassword("user", "secret")
+
+	transport := ts.Client().Transport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyURL(proxyURL)
+	transport.OnProxyConnectResponse = OnProxyConnectResponse
+
+	var result Result
+	req := NewRequest(t, ts.URL, &result)
+	res, err := (&http.Client{Transport: transport}).Do(req)
+	if err != nil {
+		t.Fatal("client.Do failed:", err)
+	}
+	if _, err := io.Copy(io.Discard, res.Body); err != nil {
+		t.Fatal("io.Copy failed:", err)
+	}
+	res.Body.Close()
+	result.EndNow()
+
+	if result.ProxyConnect < 20*time.Millisecond {
+		t.Fatalf("ProxyConnect = %v, want at least 20ms", result.ProxyConnect)
+	}
+	if result.TLSHandshake <= 0 || result.TLSHandshake >= result.ProxyConnect {
+		t.Fatalf("TLSHandshake = %v, expect it to be measured separately from ProxyConnect %v", result.TLSHandshake, result.ProxyConnect)
+	}
+	if got, want := result.ProxyURL, "http://user:xxxxx@"+proxyURL.Host; got != want {
+		t.Fatalf("ProxyURL = %q, want %q", got, want)
+	}
+}
+
+// newSOCKS5Proxy returns the address of a SOCKS5 proxy without
+// authentication, which answers the connect request after the given delay.
+func newSOCKS5Proxy(t *testing.T, delay time.Duration) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Listen failed:", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				// Greeting: version, methods; reply no authentication.
+				var hdr [2]byte
+				if _, err := io.ReadFull(conn, hdr[:]); err != nil {
+					return
+				}
+				if _, err := io.ReadFull(conn, make([]byte, hdr[1])); err != nil {
+					return
+				}
+				conn.Write([]byte{5, 0})
+
+				// Request: version, command, reserved, address type.
+				var req [4]byte
+				if _, err := io.ReadFull(conn, req[:]); err != nil {
+					return
+				}
+				var host string
+				switch req[3] {
+				case 1:
+					ip := make([]byte, 4)
+					io.ReadFull(conn, ip)
+					host = net.IP(ip).String()
+				case 3:
+					var n [1]byte
+					io.ReadFull(conn, n[:])
+					name := make([]byte, n[0])
+					io.ReadFull(conn, name)
+					host = string(name)
+				default:
+					return
+				}
+				var port [2]byte
+				io.ReadFull(conn, port[:])
+				time.Sleep(delay)
+
+				upstream, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(port[0])<<8|int(port[1]))))
+				if err != nil {
+					conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+					return
+				}
+				defer upstream.Close()
+				conn.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+				go io.Copy(upstream, conn)
+				io.Copy(conn, upstream)
+			}()
+		}
+	}()
+	return l.Addr().String()
+}
+
+func TestProxyFunc_SOCKS5(t *testing.T) {
+	proxy := newSOCKS5Proxy(t, 20*time.Millisecond)
+	for _, newServer := range []func(http.Handler) *httptest.Server{httptest.NewServer, httptest.NewTLSServer} {
+		ts := newServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "ok")
+		}))
+		defer ts.Close()
+
+		transport := ts.Client().Transport.(*http.Transport).Clone()
+		transport.Proxy = ProxyFunc(http.ProxyURL(&url.URL{Scheme: "socks5", Host: proxy}))
+
+		var result Result
+		req := NewRequest(t, ts.URL, &result)
+		res, err := (&http.Client{Transport: transport}).Do(req)
+		if err != nil {
+			t.Fatal("client.Do failed:", err)
+		}
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+		result.EndNow()
+
+		if result.ProxyConnect < 20*time.Millisecond {
+			t.Errorf("%s: ProxyConnect = %v, want at least 20ms", ts.URL, result.ProxyConnect)
+		}
+		if result.TCPConnection >= result.ProxyConnect || result.Pretransfer < result.Connect+result.ProxyConnect {
+			t.Errorf("%s: expect the handshake apart from the connect, got %+v", ts.URL, result)
+		}
+		if got, want := result.ProxyURL, "socks5://"+proxy; got != want {
+			t.Errorf("ProxyURL = %q, want %q", got, want)
+		}
+		if got := fmt.Sprintf("%+v", result); !strings.Contains(got, "SOCKS handshake:") {
+			t.Errorf("expect the SOCKS handshake in:\n%s", got)
+		}
+	}
+}
+
+func TestProxyFunc_Metadata(t *testing.T) {
+	ts := httptest.NewTLSServer(http.NotFoundHandler())
+	defer ts.Close()
+	proxy := newConnectProxy(t, 0)
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	transport := ts.Client().Transport.(*http.Transport).Clone()
+	transport.Proxy = ProxyFunc(func(req *http.Request) (*url.URL, error) {
+		if req.URL.Path == "/proxied" {
+			return proxyURL, nil
+		}
+		return nil, nil
+	})
+	var results []*Result
+	client := &http.Client{Transport: &Transport{
+		Base:           transport,
+		RecordMetadata: true,
+		OnResult:       func(_ *http.Request, r *Result, _ error) { results = append(results, r) },
+	}}
+	for _, path := range []string{"/direct", "/proxied"} {
+		res, err := client.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal("request failed:", err)
+		}
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("got %d Results, want 2", len(results))
+	}
+	if got := results[0].Metadata.Proxy; got != "direct" {
+		t.Errorf("direct request: Proxy = %q, want direct", got)
+	}
+	if got, want := results[1].Metadata.Proxy, proxy.URL; got != want {
+		t.Errorf("proxied request: Proxy = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,77 @@
+package httpstat
+
+import (
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// Record is the Result of a request together with the request it measured.
+type Record struct {
+	// Time is the time the request was sent.
+	Time time.Time
+
+	Method     string
+	URL        string
+	StatusCode int
+
+	// Err is the error of the request, if it failed.
+	Err error
+
+	Result *Result
+
+	seq uint64
+}
+
+// Recorder keeps the Records of the most recent requests in a ring buffer,
+// for inspecting recent traffic at runtime. Set it as Transport.Recorder to
+// record the requests sent through a Transport. A Recorder is safe for
+// concurrent use and does not block writers.
+type Recorder struct {
+	slots []atomic.Pointer[Record]
+	next  atomic.Uint64
+}
+
+// NewRecorder returns a Recorder keeping the last n Records.
+func NewRecorder(n int) *Recorder {
+	if n < 1 {
+		n = 1
+	}
+	return &Recorder{slots: make([]atomic.Pointer[Record], n)}
+}
+
+// Add adds rec to the Recorder, replacing the oldest Record if it is full.
+func (rc *Recorder) Add(rec Record) {
+	rec.seq = rc.next.Add(1)
+	rc.slots[(rec.seq-1)%uint64(len(rc.slots))].Store(&rec)
+}
+
+// Records returns the recorded Records, oldest first.
+func (rc *Recorder) Records() []Record {
+	next := rc.next.Load()
+	var oldest uint64
+	if n := uint64(len(rc.slots)); next > n {
+		oldest = next - n
+	}
+
+	records := make([]Record, 0, next-oldest)
+	for i := range rc.slots {
+		// Skip slots which are empty or were overwritten since next was
+		// loaded, so the Records form a consistent window.
+		rec := rc.slots[i].Load()
+		if rec == nil || rec.seq <= oldest || rec.seq > next {
+			continue
+		}
+		records = append(records, *rec)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].seq < records[j].seq
+	})
+	return records
+}
+
+// Count returns the number of Records added to the Recorder, including the
+// ones no longer kept.
+func (rc *Recorder) Count() uint64 {
+	return rc.next.Load()
+}
@@ -0,0 +1,81 @@
+package httpstat
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	rc := NewRecorder(3)
+	if got := rc.Records(); len(got) != 0 {
+		t.Fatalf("expect no records, got %d", len(got))
+	}
+
+	for i := 0; i < 5; i++ {
+		rc.Add(Record{URL: fmt.Sprint(i)})
+	}
+	got := rc.Records()
+	if len(got) != 3 {
+		t.Fatalf("expect 3 records, got %d", len(got))
+	}
+	for i, rec := range got {
+		if want := fmt.Sprint(i + 2); rec.URL != want {
+			t.Fatalf("record %d: URL = %q, want %q", i, rec.URL, want)
+		}
+	}
+	if rc.Count() != 5 {
+		t.Fatalf("Count = %d, want 5", rc.Count())
+	}
+}
+
+func TestRecorder_Concurrent(t *testing.T) {
+	rc := NewRecorder(10)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				rc.Add(Record{})
+				rc.Records()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := len(rc.Records()); got != 10 {
+		t.Fatalf("expect 10 records, got %d", got)
+	}
+}
+
+func TestTransport_Recorder(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer ts.Close()
+
+	rc := NewRecorder(10)
+	client := &http.Client{Transport: &Transport{Recorder: rc}}
+	res, err := client.Get(ts.URL + "/tea")
+	if err != nil {
+		t.Fatal("client.Get failed:", err)
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+
+	records := rc.Records()
+	if len(records) != 1 {
+		t.Fatalf("expect 1 record, got %d", len(records))
+	}
+	rec := records[0]
+	if rec.Method != "GET" || rec.URL != ts.URL+"/tea" || rec.StatusCode != http.StatusTeapot {
+		t.Fatalf("unexpected record %+v", rec)
+	}
+	if rec.Time.IsZero() || rec.Result == nil || rec.Result.Total() <= 0 {
+		t.Fatalf("expect record to hold the finished Result, got %+v", rec)
+	}
+}
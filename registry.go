@@ -0,0 +1,76 @@
+package httpstat
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// Registry buckets the Results of requests into an Aggregator per
+// destination, to answer which upstream is slow in services talking to
+// many backends. Register it with Transport.Registry. A Registry is safe
+// for concurrent use.
+type Registry struct {
+	// Key returns the key of the bucket of req. If nil, the host of the
+	// request URL (including the port, if any) is used.
+	Key func(req *http.Request) string
+
+	// Window is the Window of the Aggregators of the buckets, the number
+	// of durations of each phase they keep. If zero, 1000 is used, so the
+	// buckets stay bounded on long-running Transports; if negative, all
+	// durations are kept.
+	Window int
+
+	mu   sync.Mutex
+	aggs map[string]*Aggregator
+}
+
+// Add adds r, the Result of req, to the bucket of req.
+func (g *Registry) Add(req *http.Request, r *Result) {
+	g.Aggregator(g.key(req)).Add(r)
+}
+
+func (g *Registry) key(req *http.Request) string {
+	if g.Key != nil {
+		return g.Key(req)
+	}
+	return req.URL.Host
+}
+
+// Aggregator returns the Aggregator of the bucket key, creating it if
+// needed.
+func (g *Registry) Aggregator(key string) *Aggregator {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.aggs == nil {
+		g.aggs = make(map[string]*Aggregator)
+	}
+	a, ok := g.aggs[key]
+	if !ok {
+		a = &Aggregator{Window: g.window()}
+		g.aggs[key] = a
+	}
+	return a
+}
+
+func (g *Registry) window() int {
+	switch {
+	case g.Window == 0:
+		return 1000
+	case g.Window < 0:
+		return 0
+	}
+	return g.Window
+}
+
+// Keys returns the keys of the buckets, sorted.
+func (g *Registry) Keys() []string {
+	g.mu.Lock()
+	keys := make([]string, 0, len(g.aggs))
+	for k := range g.aggs {
+		keys = append(keys, k)
+	}
+	g.mu.Unlock()
+	sort.Strings(keys)
+	return keys
+}
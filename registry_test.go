@@ -0,0 +1,82 @@
+package httpstat
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTransport_Registry(t *testing.T) {
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer fast.Close()
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(30 * time.Millisecond)
+	}))
+	defer slow.Close()
+
+	reg := &Registry{}
+	client := &http.Client{Transport: &Transport{Registry: reg}}
+	for _, u := range []string{fast.URL, slow.URL, fast.URL + "/again", "http://127.0.0.1:1"} {
+		res, err := client.Get(u)
+		if err != nil {
+			continue
+		}
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+	}
+
+	fastHost := strings.TrimPrefix(fast.URL, "http://")
+	slowHost := strings.TrimPrefix(slow.URL, "http://")
+	if keys := reg.Keys(); len(keys) != 2 || !(keys[0] == fastHost || keys[1] == fastHost) {
+		t.Fatalf("expect buckets for %s and %s, got %v", fastHost, slowHost, keys)
+	}
+	if n := reg.Aggregator(fastHost).Count(); n != 2 {
+		t.Fatalf("expect 2 requests to %s, got %d", fastHost, n)
+	}
+	if s := reg.Aggregator(slowHost).Phase(PhaseServer); s.Count != 1 || s.Min < 30*time.Millisecond {
+		t.Fatalf("unexpected server stats of %s: %+v", slowHost, s)
+	}
+}
+
+func TestRegistry_Key(t *testing.T) {
+	reg := &Registry{
+		Key: func(req *http.Request) string {
+			return req.Method + " " + req.URL.Path
+		},
+		Window: 1,
+	}
+	for _, target := range []string{"http://a/users", "http://b/users", "http://a/orders"} {
+		reg.Add(httptest.NewRequest("GET", target, nil), NewResultFromPhases(map[Phase]time.Duration{PhaseServer: time.Millisecond}))
+	}
+	if keys := strings.Join(reg.Keys(), ","); keys != "GET /orders,GET /users" {
+		t.Fatalf("unexpected keys %s", keys)
+	}
+	if a := reg.Aggregator("GET /users"); a.Count() != 2 || a.Total().Count != 1 {
+		t.Fatalf("expect the window of the bucket to be 1, got %+v", a.Total())
+	}
+}
+
+func TestRegistry_DefaultWindow(t *testing.T) {
+	// A Registry on a long-running Transport keeps a bounded number of
+	// durations per bucket.
+	var reg Registry
+	req := httptest.NewRequest("GET", "http://a/", nil)
+	r := NewResultFromPhases(map[Phase]time.Duration{PhaseServer: time.Millisecond})
+	for i := 0; i < 1500; i++ {
+		reg.Add(req, r)
+	}
+	if a := reg.Aggregator("a"); a.Count() != 1500 || a.Phase(PhaseServer).Count != 1000 {
+		t.Fatalf("expect 1000 durations of 1500 Results, got %d of %d", a.Phase(PhaseServer).Count, a.Count())
+	}
+
+	all := &Registry{Window: -1}
+	for i := 0; i < 1500; i++ {
+		all.Add(req, r)
+	}
+	if n := all.Aggregator("a").Phase(PhaseServer).Count; n != 1500 {
+		t.Fatalf("expect all durations with a negative window, got %d", n)
+	}
+}
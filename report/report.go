@@ -0,0 +1,390 @@
+// Package report renders self-contained HTML reports of batches of requests
+// measured with go-httpstat: the distributions and percentiles of the
+// phases, the slowest requests and a breakdown per host. The pages embed
+// their styles and charts (as inline SVG), so they can be archived or
+// attached to CI runs as single files.
+package report
+
+import (
+	"html/template"
+	"io"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/jakobilobi/go-httpstat"
+)
+
+// phases are the phases shown in the reports, in the order they happen.
+var phases = []httpstat.Phase{
+	httpstat.PhaseDNS,
+	httpstat.PhaseConnect,
+	httpstat.PhaseProxyConnect,
+	httpstat.PhaseTLS,
+	httpstat.PhaseContinueWait,
+	httpstat.PhaseServer,
+	httpstat.PhaseTransfer,
+}
+
+// phaseColors are the colors of the phases in the charts.
+var phaseColors = []string{"#4e79a7", "#f28e2b", "#b07aa1", "#e15759", "#9c755f", "#76b7b2", "#59a14f"}
+
+// Report collects the requests of a report. The zero value is an empty
+// report. A Report is not safe for concurrent use.
+type Report struct {
+	// Title is the title of the page. If empty, "httpstat report" is
+	// used.
+	Title string
+
+	// Slowest is the number of slowest requests listed. If zero, 10 are
+	// listed.
+	Slowest int
+
+	records []httpstat.Record
+	groups  []group
+}
+
+// group is a named Aggregator of the breakdown.
+type group struct {
+	name   string
+	agg    *httpstat.Aggregator
+	errors int
+}
+
+// Add adds the request of rec. Failed requests are counted as errors of
+// their host; the others are aggregated.
+func (rp *Report) Add(rec httpstat.Record) {
+	rp.records = append(rp.records, rec)
+}
+
+// AddResult adds a Result of an unknown request, which is only aggregated
+// and listed without its URL.
+func (rp *Report) AddResult(r *httpstat.Result) {
+	rp.Add(httpstat.Record{Result: r})
+}
+
+// AddAggregator adds the aggregated requests a as the group name of the
+// breakdown, e.g. a bucket of a Registry. Their durations are included in
+// the percentiles of the report, but not in the distribution and the
+// slowest requests, which need the single requests.
+func (rp *Report) AddAggregator(name string, a *httpstat.Aggregator) {
+	rp.groups = append(rp.groups, group{name: name, agg: a})
+}
+
+// WriteHTML writes the report as an HTML page to w.
+func (rp *Report) WriteHTML(w io.Writer) error {
+	return page.Execute(w, rp.view())
+}
+
+// view is the data of the page template.
+type view struct {
+	Title    string
+	Requests int
+	Errors   int
+	Phases   []phaseRow
+	Total    phaseRow
+	Chart    histogram
+	Slowest  []requestRow
+	Hosts    []hostRow
+	Legend   []legendItem
+}
+
+type phaseRow struct {
+	Name  string
+	Color string
+	httpstat.Stats
+
+	// Box positions the p50 to p95 box and the p99 whisker in percent of
+	// the widest p99.
+	BoxStart, BoxWidth, Whisker float64
+}
+
+type requestRow struct {
+	Time       string
+	Method     string
+	URL        string
+	StatusCode int
+	Total      time.Duration
+	Segments   []segment
+}
+
+// segment is a phase of a request in its stacked bar, in percent of the
+// slowest request.
+type segment struct {
+	Name         string
+	Color        string
+	Start, Width float64
+	Duration     time.Duration
+}
+
+type hostRow struct {
+	Host     string
+	Requests int
+	Errors   int
+	Total    httpstat.Stats
+}
+
+type legendItem struct {
+	Name, Color string
+}
+
+// histogram is the distribution of the total durations.
+type histogram struct {
+	Bars     []bar
+	Max      time.Duration
+	BarWidth float64
+}
+
+// bar is a bucket of the histogram, in the 100 by 40 units of its chart.
+type bar struct {
+	X, Y, Height float64
+	From, To     time.Duration
+	Count        int
+}
+
+const histogramBuckets = 20
+
+func (rp *Report) view() *view {
+	v := &view{Title: rp.Title}
+	if v.Title == "" {
+		v.Title = "httpstat report"
+	}
+
+	all := &httpstat.Aggregator{}
+	hosts := map[string]*group{}
+	var hostOrder []string
+	var ok []httpstat.Record
+	for _, rec := range rp.records {
+		host := "unknown"
+		if u, err := url.Parse(rec.URL); err == nil && u.Host != "" {
+			host = u.Host
+		}
+		g, seen := hosts[host]
+		if !seen {
+			g = &group{name: host, agg: &httpstat.Aggregator{}}
+			hosts[host] = g
+			hostOrder = append(hostOrder, host)
+		}
+		v.Requests++
+		if rec.Err != nil || rec.Result == nil {
+			v.Errors++
+			g.errors++
+			continue
+		}
+		all.Add(rec.Result)
+		g.agg.Add(rec.Result)
+		ok = append(ok, rec)
+	}
+
+	groups := make([]group, 0, len(hostOrder)+len(rp.groups))
+	for _, h := range hostOrder {
+		groups = append(groups, *hosts[h])
+	}
+	groups = append(groups, rp.groups...)
+	for _, g := range rp.groups {
+		v.Requests += g.agg.Count()
+		all.Merge(g.agg)
+	}
+	for _, g := range groups {
+		v.Hosts = append(v.Hosts, hostRow{
+			Host:     g.name,
+			Requests: g.agg.Count() + g.errors,
+			Errors:   g.errors,
+			Total:    g.agg.Total(),
+		})
+	}
+
+	v.Phases, v.Total = phaseRows(all)
+	v.Chart = newHistogram(ok)
+	v.Slowest = slowest(ok, rp.Slowest)
+	for i, p := range phases {
+		v.Legend = append(v.Legend, legendItem{Name: p.String(), Color: phaseColors[i]})
+	}
+	return v
+}
+
+// phaseRows returns the Stats of each phase and of the total.
+func phaseRows(all *httpstat.Aggregator) ([]phaseRow, phaseRow) {
+	var rows []phaseRow
+	for i, p := range phases {
+		s := all.Phase(p)
+		if s.Count == 0 {
+			continue
+		}
+		rows = append(rows, phaseRow{Name: p.String(), Color: phaseColors[i], Stats: s})
+	}
+	total := phaseRow{Name: "Total", Color: "#555", Stats: all.Total()}
+
+	widest := total.P99
+	for _, r := range rows {
+		if r.P99 > widest {
+			widest = r.P99
+		}
+	}
+	place := func(r *phaseRow) {
+		if widest <= 0 {
+			return
+		}
+		r.BoxStart = percent(r.P50, widest)
+		r.BoxWidth = percent(r.P95-r.P50, widest)
+		r.Whisker = percent(r.P99, widest)
+	}
+	for i := range rows {
+		place(&rows[i])
+	}
+	place(&total)
+	return rows, total
+}
+
+func newHistogram(records []httpstat.Record) histogram {
+	var h histogram
+	for _, rec := range records {
+		if d := rec.Result.Total(); d > h.Max {
+			h.Max = d
+		}
+	}
+	if h.Max <= 0 {
+		return h
+	}
+
+	var counts [histogramBuckets]int
+	width := h.Max / histogramBuckets
+	if width <= 0 {
+		width = 1
+	}
+	for _, rec := range records {
+		i := int(rec.Result.Total() / width)
+		if i >= histogramBuckets {
+			i = histogramBuckets - 1
+		}
+		counts[i]++
+	}
+	most := 0
+	for _, n := range counts {
+		if n > most {
+			most = n
+		}
+	}
+	h.BarWidth = 100.0 / histogramBuckets
+	for i, n := range counts {
+		height := 40 * float64(n) / float64(most)
+		h.Bars = append(h.Bars, bar{
+			X:      float64(i) * h.BarWidth,
+			Y:      40 - height,
+			Height: height,
+			From:   time.Duration(i) * width,
+			To:     time.Duration(i+1) * width,
+			Count:  n,
+		})
+	}
+	return h
+}
+
+func slowest(records []httpstat.Record, n int) []requestRow {
+	if n <= 0 {
+		n = 10
+	}
+	sorted := append([]httpstat.Record(nil), records...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Result.Total() > sorted[j].Result.Total()
+	})
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+	if len(sorted) == 0 {
+		return nil
+	}
+
+	max := sorted[0].Result.Total()
+	rows := make([]requestRow, 0, len(sorted))
+	for _, rec := range sorted {
+		row := requestRow{
+			Method:     rec.Method,
+			URL:        rec.URL,
+			StatusCode: rec.StatusCode,
+			Total:      rec.Result.Total(),
+		}
+		if !rec.Time.IsZero() {
+			row.Time = rec.Time.Format(time.RFC3339)
+		}
+		for _, pt := range rec.Result.Phases() {
+			if pt.Skipped || pt.Duration <= 0 || max <= 0 {
+				continue
+			}
+			row.Segments = append(row.Segments, segment{
+				Name:     pt.Name,
+				Color:    phaseColors[pt.Phase],
+				Start:    percent(pt.StartOffset, max),
+				Width:    percent(pt.Duration, max),
+				Duration: pt.Duration,
+			})
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func percent(d, of time.Duration) float64 {
+	return 100 * float64(d) / float64(of)
+}
+
+var page = template.Must(template.New("report").Funcs(template.FuncMap{
+	"dur": func(d time.Duration) string {
+		return (d.Round(10 * time.Microsecond)).String()
+	},
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font: 14px/1.4 system-ui, sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin: 1em 0 2em; }
+th, td { padding: 4px 10px; border-bottom: 1px solid #ddd; text-align: right; white-space: nowrap; }
+th:first-child, td:first-child, td.url { text-align: left; }
+td.url { max-width: 40em; overflow: hidden; text-overflow: ellipsis; }
+svg { display: block; }
+.legend span { display: inline-block; margin-right: 1em; }
+.legend i { display: inline-block; width: 10px; height: 10px; margin-right: 4px; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{.Requests}} requests, {{.Errors}} errors.</p>
+
+<h2>Phases</h2>
+<table>
+<tr><th>Phase</th><th>Count</th><th>Min</th><th>p50</th><th>p90</th><th>p95</th><th>p99</th><th>Max</th><th>p50–p95, p99</th></tr>
+{{range .Phases}}{{template "phase" .}}{{end}}{{template "phase" .Total}}
+</table>
+
+{{with .Chart}}{{if .Bars}}
+<h2>Distribution of total durations</h2>
+<svg viewBox="0 0 100 40" width="600" height="240" preserveAspectRatio="none" role="img">
+{{range .Bars}}<rect x="{{printf "%.2f" .X}}" y="{{printf "%.2f" .Y}}" width="{{printf "%.2f" $.Chart.BarWidth}}" height="{{printf "%.2f" .Height}}" fill="#4e79a7" stroke="#fff" stroke-width="0.1"><title>{{dur .From}}–{{dur .To}}: {{.Count}}</title></rect>
+{{end}}</svg>
+<p>0 to {{dur .Max}}</p>
+{{end}}{{end}}
+
+{{if .Slowest}}
+<h2>Slowest requests</h2>
+<p class="legend">{{range .Legend}}<span><i style="background: {{.Color}}"></i>{{.Name}}</span>{{end}}</p>
+<table>
+<tr><th>Time</th><th>Request</th><th>Status</th><th>Total</th><th>Phases</th></tr>
+{{range .Slowest}}<tr><td>{{.Time}}</td><td class="url">{{.Method}} {{if .URL}}{{.URL}}{{else}}(unknown){{end}}</td><td>{{if .StatusCode}}{{.StatusCode}}{{end}}</td><td>{{dur .Total}}</td>
+<td><svg viewBox="0 0 100 10" width="300" height="12" preserveAspectRatio="none">{{range .Segments}}<rect x="{{printf "%.2f" .Start}}" width="{{printf "%.2f" .Width}}" height="10" fill="{{.Color}}"><title>{{.Name}}: {{dur .Duration}}</title></rect>{{end}}</svg></td></tr>
+{{end}}</table>
+{{end}}
+
+{{if .Hosts}}
+<h2>Hosts</h2>
+<table>
+<tr><th>Host</th><th>Requests</th><th>Errors</th><th>p50</th><th>p95</th><th>p99</th></tr>
+{{range .Hosts}}<tr><td>{{.Host}}</td><td>{{.Requests}}</td><td>{{.Errors}}</td><td>{{dur .Total.P50}}</td><td>{{dur .Total.P95}}</td><td>{{dur .Total.P99}}</td></tr>
+{{end}}</table>
+{{end}}
+</body>
+</html>
+{{define "phase"}}<tr><td>{{.Name}}</td><td>{{.Count}}</td><td>{{dur .Min}}</td><td>{{dur .P50}}</td><td>{{dur .P90}}</td><td>{{dur .P95}}</td><td>{{dur .P99}}</td><td>{{dur .Max}}</td>
+<td><svg viewBox="0 0 100 10" width="200" height="12" preserveAspectRatio="none"><line x1="{{printf "%.2f" .BoxStart}}" x2="{{printf "%.2f" .Whisker}}" y1="5" y2="5" stroke="{{.Color}}" stroke-width="1"/><rect x="{{printf "%.2f" .BoxStart}}" y="1" width="{{printf "%.2f" .BoxWidth}}" height="8" fill="{{.Color}}"/></svg></td></tr>
+{{end}}`))
@@ -0,0 +1,68 @@
+package report
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jakobilobi/go-httpstat"
+)
+
+func TestReport_WriteHTML(t *testing.T) {
+	rp := &Report{Title: "Nightly <run>", Slowest: 2}
+	for i := 1; i <= 5; i++ {
+		rp.Add(httpstat.Record{
+			Time:       time.Date(2024, 5, 1, 12, 0, i, 0, time.UTC),
+			Method:     "GET",
+			URL:        "https://a.example/" + strings.Repeat("x", i),
+			StatusCode: 200,
+			Result: httpstat.NewResultFromPhases(map[httpstat.Phase]time.Duration{
+				httpstat.PhaseDNS:    time.Millisecond,
+				httpstat.PhaseServer: time.Duration(i) * 10 * time.Millisecond,
+			}),
+		})
+	}
+	rp.Add(httpstat.Record{Method: "GET", URL: "https://b.example/", Err: errors.New("refused")})
+	var agg httpstat.Aggregator
+	agg.Add(httpstat.NewResultFromPhases(map[httpstat.Phase]time.Duration{httpstat.PhaseTLS: 5 * time.Millisecond}))
+	rp.AddAggregator("c.example", &agg)
+
+	var buf bytes.Buffer
+	if err := rp.WriteHTML(&buf); err != nil {
+		t.Fatal("WriteHTML failed:", err)
+	}
+	page := buf.String()
+	for _, want := range []string{
+		"<title>Nightly &lt;run&gt;</title>",
+		"7 requests, 1 errors.",
+		"<td>DNS lookup</td><td>5</td>",
+		"<td>TLS handshake</td><td>1</td>",
+		"<td>a.example</td><td>5</td><td>0</td>",
+		"<td>b.example</td><td>1</td><td>1</td>",
+		"<td>c.example</td><td>1</td><td>0</td>",
+		"GET https://a.example/xxxxx",
+		"<svg",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("expect %q in the page", want)
+		}
+	}
+	if strings.Contains(page, "https://a.example/xxx<") {
+		t.Error("expect only the 2 slowest requests to be listed")
+	}
+	if strings.Contains(page, "http://") || strings.Contains(page, "<script") || strings.Contains(page, "<link") {
+		t.Error("expect the page to be self-contained")
+	}
+}
+
+func TestReport_Empty(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (&Report{}).WriteHTML(&buf); err != nil {
+		t.Fatal("WriteHTML failed:", err)
+	}
+	if !strings.Contains(buf.String(), "0 requests, 0 errors.") {
+		t.Fatalf("unexpected page:\n%s", buf.String())
+	}
+}
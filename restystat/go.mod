@@ -0,0 +1,12 @@
+module github.com/jakobilobi/go-httpstat/restystat
+
+go 1.20
+
+require (
+	github.com/go-resty/resty/v2 v2.16.5
+	github.com/jakobilobi/go-httpstat v0.0.0
+)
+
+require golang.org/x/net v0.33.0 // indirect
+
+replace github.com/jakobilobi/go-httpstat => ../
@@ -0,0 +1,90 @@
+// Package restystat integrates go-httpstat with the go-resty HTTP client
+// (https://github.com/go-resty/resty).
+//
+// Use registers request and response middleware on a resty.Client which
+// measure each request with a fresh httpstat.Result. The Result of a
+// response is returned by Result:
+//
+//	client := restystat.Use(resty.New())
+//	res, err := client.R().Get(url)
+//	if r, ok := restystat.Result(res); ok {
+//		fmt.Printf("%+v\n", r)
+//	}
+//
+// Each retry of resty is measured with its own Result. If the context of
+// the request carries an httpstat.AttemptSet, see httpstat.WithAttempts,
+// the Results of all attempts are added to it.
+package restystat
+
+import (
+	"context"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/jakobilobi/go-httpstat"
+)
+
+// parentKey is the context key of the context of a request before
+// OnBeforeRequest, so retries are not traced by the Results of the
+// attempts before.
+type parentKey struct{}
+
+// Use registers OnBeforeRequest, OnAfterResponse and OnError on c and
+// returns c.
+func Use(c *resty.Client) *resty.Client {
+	return c.OnBeforeRequest(OnBeforeRequest).OnAfterResponse(OnAfterResponse).OnError(OnError)
+}
+
+// OnBeforeRequest is a resty.RequestMiddleware which sets up a fresh
+// httpstat.Result in the context of r.
+func OnBeforeRequest(_ *resty.Client, r *resty.Request) error {
+	parent := r.Context()
+	if p, ok := parent.Value(parentKey{}).(context.Context); ok {
+		parent = p
+	}
+
+	var ctx context.Context
+	if set, ok := httpstat.AttemptsFromContext(parent); ok {
+		ctx, _ = set.Start(parent)
+	} else {
+		ctx = httpstat.WithHTTPStat(parent, &httpstat.Result{})
+	}
+	r.SetContext(context.WithValue(ctx, parentKey{}, parent))
+	return nil
+}
+
+// OnAfterResponse is a resty.ResponseMiddleware which ends the Result of
+// res at the time resty read its body. It must be registered after
+// OnBeforeRequest. resty does not call the response middleware for
+// responses it does not parse, see resty.Request.SetDoNotParseResponse;
+// wrap their RawBody with httpstat.Body instead.
+func OnAfterResponse(_ *resty.Client, res *resty.Response) error {
+	r, ok := Result(res)
+	if !ok {
+		return nil
+	}
+	r.End(res.ReceivedAt())
+	if set, ok := httpstat.AttemptsFromContext(res.Request.Context()); ok {
+		set.Finish(r, res.StatusCode(), nil)
+	}
+	return nil
+}
+
+// OnError is a resty.ErrorHook which finishes the attempt of a failed
+// request in the AttemptSet of its context, if any.
+func OnError(req *resty.Request, err error) {
+	r, ok := httpstat.FromContext(req.Context())
+	if !ok {
+		return
+	}
+	if set, ok := httpstat.AttemptsFromContext(req.Context()); ok {
+		set.Finish(r, 0, err)
+	}
+}
+
+// Result returns the Result of res recorded by the middleware.
+func Result(res *resty.Response) (*httpstat.Result, bool) {
+	if res == nil || res.Request == nil {
+		return nil, false
+	}
+	return httpstat.FromContext(res.Request.Context())
+}
@@ -0,0 +1,79 @@
+package restystat
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/jakobilobi/go-httpstat"
+)
+
+func TestUse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		io.WriteString(w, "ok")
+	}))
+	defer ts.Close()
+
+	client := Use(resty.New())
+	res, err := client.R().Get(ts.URL)
+	if err != nil {
+		t.Fatal("request failed:", err)
+	}
+	r, ok := Result(res)
+	if !ok {
+		t.Fatal("expect a Result")
+	}
+	if r.ServerProcessing < 10*time.Millisecond || r.Total() < r.StartTransfer || !r.IsComplete() {
+		t.Fatalf("unexpected Result %+v", r)
+	}
+}
+
+func TestUse_Retry(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		io.WriteString(w, "ok")
+	}))
+	defer ts.Close()
+
+	client := Use(resty.New()).
+		SetRetryCount(3).
+		SetRetryWaitTime(time.Millisecond).
+		AddRetryCondition(func(res *resty.Response, err error) bool {
+			return res.StatusCode() >= 500
+		})
+	var attempts httpstat.AttemptSet
+	res, err := client.R().SetContext(httpstat.WithAttempts(context.Background(), &attempts)).Get(ts.URL)
+	if err != nil {
+		t.Fatal("request failed:", err)
+	}
+
+	got := attempts.Attempts()
+	if len(got) != 3 {
+		t.Fatalf("expect 3 attempts, got %d", len(got))
+	}
+	for i, a := range got {
+		if !a.Done || a.Result.Total() <= 0 {
+			t.Errorf("expect attempt %d to be finished, got %+v", i+1, a)
+		}
+		// A retry must not be traced by the Results before it.
+		if i > 0 && got[i-1].Result.EndAt().After(a.Start) {
+			t.Errorf("expect attempt %d to end before attempt %d", i, i+1)
+		}
+	}
+	if r, _ := Result(res); r != got[2].Result || got[2].StatusCode != http.StatusOK {
+		t.Fatal("expect the Result of the response to be the last attempt")
+	}
+	if got[0].StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("unexpected status of the first attempt %d", got[0].StatusCode)
+	}
+}
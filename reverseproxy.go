@@ -0,0 +1,49 @@
+package httpstat
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"strconv"
+	"time"
+)
+
+// InstrumentReverseProxy makes p measure each round trip to its backends
+// with a Transport wrapping the Transport of p, and aggregate the Results
+// per backend into reg, if not nil. As the Director of p has rewritten the
+// request URL by then, the default Key of reg buckets them by backend host.
+// It returns the Transport, e.g. to set OnResult or Sink.
+func InstrumentReverseProxy(p *httputil.ReverseProxy, reg *Registry) *Transport {
+	t := &Transport{Base: p.Transport, Registry: reg}
+	p.Transport = t
+	return t
+}
+
+// UpstreamServerTiming adds the phases of the backend round trip of res
+// which are known when the response headers arrive to its Server-Timing
+// header, so the clients of a gateway see where the upstream latency comes
+// from, e.g. "upstream_tcp_connection;dur=0.412". It is meant to be used as
+// (or called from) the ModifyResponse of a ReverseProxy set up with
+// InstrumentReverseProxy, and does nothing for responses without a Result.
+func UpstreamServerTiming(res *http.Response) error {
+	if res.Request == nil {
+		return nil
+	}
+	r, ok := FromContext(res.Request.Context())
+	if !ok {
+		return nil
+	}
+	for i := range metricPhases {
+		p := Phase(i)
+		if p == PhaseTransfer {
+			// The body has not been read yet.
+			break
+		}
+		d := r.Duration(p)
+		if d <= 0 {
+			continue
+		}
+		ms := strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64)
+		res.Header.Add("Server-Timing", "upstream_"+metricPhases[p]+";dur="+ms)
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+package httpstat
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestInstrumentReverseProxy(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		io.WriteString(w, "ok")
+	}))
+	defer backend.Close()
+	u, _ := url.Parse(backend.URL)
+
+	p := httputil.NewSingleHostReverseProxy(u)
+	p.Transport = DefaultTransport()
+	var reg Registry
+	InstrumentReverseProxy(p, &reg)
+	p.ModifyResponse = UpstreamServerTiming
+	gateway := httptest.NewServer(p)
+	defer gateway.Close()
+
+	for i := 0; i < 2; i++ {
+		res, err := http.Get(gateway.URL)
+		if err != nil {
+			t.Fatal("request failed:", err)
+		}
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+		if i == 0 {
+			timing := strings.Join(res.Header.Values("Server-Timing"), ", ")
+			if !strings.Contains(timing, "upstream_server_processing;dur=") {
+				t.Fatalf("expect the upstream timing in the Server-Timing header, got %q", timing)
+			}
+		}
+	}
+
+	if keys := reg.Keys(); len(keys) != 1 || keys[0] != u.Host {
+		t.Fatalf("expect one bucket for %s, got %v", u.Host, keys)
+	}
+	if n := reg.Aggregator(u.Host).Count(); n != 2 {
+		t.Fatalf("expect 2 upstream Results, got %d", n)
+	}
+}
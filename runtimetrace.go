@@ -0,0 +1,112 @@
+package httpstat
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"runtime/trace"
+	"sync"
+)
+
+// requestTask traces a request as a runtime/trace task, with a region for
+// each phase. A region must end on the goroutine it started on, so the
+// phases are traced on the goroutines running them: the DNS lookup,
+// connect and TLS handshake on the goroutines dialing the connection, the
+// wait for the response on the goroutine sending the request, and the
+// content transfer around each read of the body. The methods may be called
+// on a nil requestTask, which does nothing.
+type requestTask struct {
+	ctx  context.Context
+	task *trace.Task
+
+	mu       sync.Mutex
+	dns      *trace.Region
+	tls      *trace.Region
+	wait     *trace.Region
+	connects map[string]*trace.Region
+}
+
+// newRequestTask starts the task of req, with the context of req.
+func newRequestTask(req *http.Request) *requestTask {
+	ctx, task := trace.NewTask(req.Context(), "httpstat.request")
+	trace.Log(ctx, "request", req.Method+" "+req.URL.Redacted())
+	return &requestTask{ctx: ctx, task: task, connects: make(map[string]*trace.Region)}
+}
+
+// withHooks returns a copy of ctx with the hooks starting and ending the
+// regions.
+func (t *requestTask) withHooks(ctx context.Context) context.Context {
+	start := func(dst **trace.Region, typ string) {
+		r := trace.StartRegion(t.ctx, typ)
+		t.mu.Lock()
+		*dst = r
+		t.mu.Unlock()
+	}
+	end := func(dst **trace.Region) {
+		t.mu.Lock()
+		r := *dst
+		*dst = nil
+		t.mu.Unlock()
+		if r != nil {
+			r.End()
+		}
+	}
+
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { start(&t.dns, "httpstat.dns") },
+		DNSDone:  func(httptrace.DNSDoneInfo) { end(&t.dns) },
+		// Several addresses may be dialed in parallel.
+		ConnectStart: func(network, addr string) {
+			r := trace.StartRegion(t.ctx, "httpstat.connect")
+			t.mu.Lock()
+			t.connects[network+" "+addr] = r
+			t.mu.Unlock()
+		},
+		ConnectDone: func(network, addr string, _ error) {
+			t.mu.Lock()
+			r := t.connects[network+" "+addr]
+			delete(t.connects, network+" "+addr)
+			t.mu.Unlock()
+			if r != nil {
+				r.End()
+			}
+		},
+		TLSHandshakeStart: func() { start(&t.tls, "httpstat.tls") },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { end(&t.tls) },
+		GotConn:           func(httptrace.GotConnInfo) { start(&t.wait, "httpstat.wait") },
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			trace.Log(t.ctx, "httpstat", "request written")
+		},
+		GotFirstResponseByte: func() {
+			trace.Log(t.ctx, "httpstat", "first response byte")
+		},
+	})
+}
+
+// endWait ends the region of the wait for the response. It must be called
+// on the goroutine which sent the request.
+func (t *requestTask) endWait() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	r := t.wait
+	t.wait = nil
+	t.mu.Unlock()
+	if r != nil {
+		r.End()
+	}
+}
+
+// transfer starts the region of a read of the body.
+func (t *requestTask) transfer() *trace.Region {
+	return trace.StartRegion(t.ctx, "httpstat.transfer")
+}
+
+// end ends the task.
+func (t *requestTask) end() {
+	if t != nil {
+		t.task.End()
+	}
+}
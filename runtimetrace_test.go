@@ -0,0 +1,38 @@
+package httpstat
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime/trace"
+	"testing"
+)
+
+func TestTransport_RuntimeTrace(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+	if err := trace.Start(&buf); err != nil {
+		t.Skip("trace already running:", err)
+	}
+	client := &http.Client{Transport: &Transport{Base: DefaultTransport(), RuntimeTrace: true}}
+	res, err := client.Get(ts.URL)
+	if err != nil {
+		trace.Stop()
+		t.Fatal("request failed:", err)
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+	trace.Stop()
+
+	// The names of tasks and regions are in the string table of the trace.
+	for _, name := range []string{"httpstat.request", "httpstat.connect", "httpstat.wait", "httpstat.transfer"} {
+		if !bytes.Contains(buf.Bytes(), []byte(name)) {
+			t.Errorf("expect %s in the trace", name)
+		}
+	}
+}
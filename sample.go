@@ -0,0 +1,38 @@
+package httpstat
+
+import (
+	"math/rand"
+	"net/http"
+)
+
+// SampleRate returns a Transport.Sample function measuring about the given
+// fraction of the requests, between 0 and 1.
+func SampleRate(rate float64) func(req *http.Request) bool {
+	return func(*http.Request) bool {
+		return sample(rate)
+	}
+}
+
+// SampleHosts returns a Transport.Sample function measuring about the
+// fraction rates[host] of the requests to each host, and the fraction def
+// of the requests to other hosts. Hosts are matched against the host of the
+// request URL, without the port.
+func SampleHosts(rates map[string]float64, def float64) func(req *http.Request) bool {
+	return func(req *http.Request) bool {
+		rate, ok := rates[req.URL.Hostname()]
+		if !ok {
+			rate = def
+		}
+		return sample(rate)
+	}
+}
+
+func sample(rate float64) bool {
+	switch {
+	case rate >= 1:
+		return true
+	case rate <= 0:
+		return false
+	}
+	return rand.Float64() < rate
+}
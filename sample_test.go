@@ -0,0 +1,66 @@
+package httpstat
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSampleRate(t *testing.T) {
+	req := httptest.NewRequest("GET", "http://example.com/", nil)
+	for _, rate := range []float64{0, 0.25, 1} {
+		sample := SampleRate(rate)
+		n := 0
+		for i := 0; i < 10000; i++ {
+			if sample(req) {
+				n++
+			}
+		}
+		if got := float64(n) / 10000; got < rate-0.03 || got > rate+0.03 {
+			t.Errorf("SampleRate(%v) sampled %v of the requests", rate, got)
+		}
+	}
+}
+
+func TestSampleHosts(t *testing.T) {
+	sample := SampleHosts(map[string]float64{"hot.example": 0, "rare.example": 1}, 0)
+	for target, want := range map[string]bool{
+		"http://hot.example/":       false,
+		"http://rare.example:8080/": true,
+		"http://other.example/":     false,
+	} {
+		if got := sample(httptest.NewRequest("GET", target, nil)); got != want {
+			t.Errorf("sample(%s) = %t, want %t", target, got, want)
+		}
+	}
+}
+
+func TestTransport_Sample(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+
+	var results int
+	client := &http.Client{Transport: &Transport{
+		Sample: func(req *http.Request) bool {
+			return req.URL.Path == "/sampled"
+		},
+		OnResult: func(req *http.Request, r *Result, err error) {
+			if req.URL.Path != "/sampled" {
+				t.Errorf("unexpected Result of %s", req.URL.Path)
+			}
+			results++
+		},
+	}}
+	for _, path := range []string{"/sampled", "/skipped", "/sampled"} {
+		res, err := client.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+	}
+	if results != 2 {
+		t.Fatalf("expect 2 Results, got %d", results)
+	}
+}
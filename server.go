@@ -0,0 +1,233 @@
+package httpstat
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ServerResult stores httpstat information of a request measured on the
+// server side by Handler.
+type ServerResult struct {
+	// TimeToHandler is the duration from when the server read the request
+	// until the handler was called. It is only measured for HTTP/1
+	// requests when the server is set up with InstrumentServer, because
+	// net/http does not report when each stream of an HTTP/2 connection
+	// is read.
+	TimeToHandler time.Duration
+
+	// HandlerDuration is the duration of the handler call.
+	HandlerDuration time.Duration
+
+	// ResponseWrite is the time spent writing the response, summed over
+	// all writes done by the handler.
+	ResponseWrite time.Duration
+
+	// BytesWritten is the number of response body bytes written.
+	BytesWritten int64
+
+	// StatusCode is the status code sent to the client.
+	StatusCode int
+
+	// ReceivedAt is the time the request was received and RespondedAt the
+	// time the response header was written, both as read from the server
+	// clock. Together with a client Result they form an Exchange.
+	ReceivedAt  time.Time
+	RespondedAt time.Time
+
+	// unit is the unit durations are formatted in
+	unit Unit
+}
+
+// Handler returns middleware that measures the server side phases of each
+// request served by next and passes them to fn once next returns.
+func Handler(next http.Handler, fn func(*http.Request, *ServerResult)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+
+		sr := ServerResult{ReceivedAt: start}
+		if ct, ok := req.Context().Value(connTimesKey{}).(*connTimes); ok && req.ProtoMajor == 1 {
+			if active := ct.take(); !active.IsZero() {
+				sr.TimeToHandler = start.Sub(active)
+				sr.ReceivedAt = active
+			}
+		}
+
+		rw := &responseWriter{ResponseWriter: w, result: &sr}
+		next.ServeHTTP(rw, req)
+		sr.HandlerDuration = time.Since(start)
+		if sr.StatusCode == 0 {
+			sr.StatusCode = http.StatusOK
+			sr.RespondedAt = time.Now()
+		}
+
+		fn(req, &sr)
+	})
+}
+
+// InstrumentServer sets up srv so that Handler can measure TimeToHandler.
+// Existing ConnContext and ConnState hooks of srv are preserved.
+func InstrumentServer(srv *http.Server) {
+	var conns sync.Map
+
+	connContext := srv.ConnContext
+	srv.ConnContext = func(ctx context.Context, c net.Conn) context.Context {
+		if connContext != nil {
+			ctx = connContext(ctx, c)
+		}
+		ct := &connTimes{}
+		conns.Store(c, ct)
+		return context.WithValue(ctx, connTimesKey{}, ct)
+	}
+
+	connState := srv.ConnState
+	srv.ConnState = func(c net.Conn, state http.ConnState) {
+		switch state {
+		case http.StateActive:
+			// HTTP/1 connections become active once per request, when
+			// its header has been read.
+			if ct, ok := conns.Load(c); ok {
+				ct.(*connTimes).setActive(time.Now())
+			}
+		case http.StateHijacked, http.StateClosed:
+			conns.Delete(c)
+		}
+		if connState != nil {
+			connState(c, state)
+		}
+	}
+}
+
+type connTimesKey struct{}
+
+// connTimes records when a connection last read a request that has not
+// reached Handler yet.
+type connTimes struct {
+	mu     sync.Mutex
+	active time.Time
+}
+
+func (c *connTimes) setActive(t time.Time) {
+	c.mu.Lock()
+	c.active = t
+	c.mu.Unlock()
+}
+
+// take returns the time recorded by setActive and clears it, so that each
+// request is measured from its own read.
+func (c *connTimes) take() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := c.active
+	c.active = time.Time{}
+	return t
+}
+
+type responseWriter struct {
+	http.ResponseWriter
+	result *ServerResult
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if w.result.StatusCode == 0 {
+		w.result.StatusCode = code
+		w.result.RespondedAt = time.Now()
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if w.result.StatusCode == 0 {
+		w.result.StatusCode = http.StatusOK
+		w.result.RespondedAt = time.Now()
+	}
+	start := time.Now()
+	n, err := w.ResponseWriter.Write(p)
+	w.result.ResponseWrite += time.Since(start)
+	w.result.BytesWritten += int64(n)
+	return n, err
+}
+
+func (w *responseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		start := time.Now()
+		f.Flush()
+		w.result.ResponseWrite += time.Since(start)
+	}
+}
+
+func (w *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("httpstat: %T does not implement http.Hijacker", w.ResponseWriter)
+	}
+	return h.Hijack()
+}
+
+// Unwrap returns the underlying http.ResponseWriter, for use with
+// http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// SetUnit sets the unit in which Format prints the durations of r.
+func (r *ServerResult) SetUnit(u Unit) {
+	r.unit = u
+}
+
+// Format formats server stats result, in the same way as Result.Format.
+func (r ServerResult) Format(s fmt.State, verb rune) {
+	var layout Layout
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			layout = LayoutMultiline
+		}
+	case 's', 'q':
+	default:
+		return
+	}
+
+	var buf [256]byte
+	s.Write(r.AppendFormat(buf[:0], layout))
+}
+
+// AppendFormat appends the formatted ServerResult to dst, in the same way as
+// Result.AppendFormat, and returns the extended buffer. LayoutPercent is
+// ignored.
+func (r *ServerResult) AppendFormat(dst []byte, layout Layout) []byte {
+	u := r.unit
+	if layout&LayoutMultiline != 0 {
+		w := u.width()
+		dst = appendPadded(dst, "Time to handler", ":", 19)
+		dst = appendDuration(dst, u, r.TimeToHandler, true, w)
+		dst = appendPadded(append(dst, '\n'), "Handler", ":", 19)
+		dst = appendDuration(dst, u, r.HandlerDuration, true, w)
+		dst = appendPadded(append(dst, '\n'), "Response write", ":", 19)
+		dst = appendDuration(dst, u, r.ResponseWrite, true, w)
+		dst = append(dst, "\n\n"...)
+
+		var buf [20]byte
+		dst = appendPadded(dst, "Status", ":", 16)
+		dst = appendRight(dst, strconv.AppendInt(buf[:0], int64(r.StatusCode), 10), 4)
+		dst = appendPadded(append(dst, '\n'), "Bytes written", ":", 16)
+		dst = appendRight(dst, strconv.AppendInt(buf[:0], r.BytesWritten, 10), 4)
+		return append(dst, '\n')
+	}
+
+	dst = append(dst, "TimeToHandler: "...)
+	dst = appendDuration(dst, u, r.TimeToHandler, true, 0)
+	dst = append(dst, ", HandlerDuration: "...)
+	dst = appendDuration(dst, u, r.HandlerDuration, true, 0)
+	dst = append(dst, ", ResponseWrite: "...)
+	dst = appendDuration(dst, u, r.ResponseWrite, true, 0)
+	dst = append(dst, ", StatusCode: "...)
+	dst = strconv.AppendInt(dst, int64(r.StatusCode), 10)
+	dst = append(dst, ", BytesWritten: "...)
+	return strconv.AppendInt(dst, r.BytesWritten, 10)
+}
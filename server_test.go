@@ -0,0 +1,179 @@
+package httpstat
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestHandler(t *testing.T) {
+	results := make(chan *ServerResult, 1)
+	h := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		w.WriteHeader(http.StatusAccepted)
+		io.WriteString(w, "hello")
+		io.WriteString(w, " world")
+	}), func(_ *http.Request, sr *ServerResult) {
+		results <- sr
+	})
+
+	ts := httptest.NewUnstartedServer(h)
+	InstrumentServer(ts.Config)
+	ts.Start()
+	defer ts.Close()
+
+	res, err := DefaultClient().Get(ts.URL)
+	if err != nil {
+		t.Fatal("Get failed:", err)
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+
+	sr := <-results
+	if got, want := sr.StatusCode, http.StatusAccepted; got != want {
+		t.Fatalf("StatusCode = %d, want %d", got, want)
+	}
+	if got, want := sr.BytesWritten, int64(len("hello world")); got != want {
+		t.Fatalf("BytesWritten = %d, want %d", got, want)
+	}
+	if sr.HandlerDuration < 10*time.Millisecond {
+		t.Fatalf("HandlerDuration = %v, want at least 10ms", sr.HandlerDuration)
+	}
+	if sr.TimeToHandler <= 0 || sr.ResponseWrite <= 0 {
+		t.Fatalf("expect TimeToHandler and ResponseWrite to be non-zero: %+v", *sr)
+	}
+	if sr.RespondedAt.Sub(sr.ReceivedAt) < 10*time.Millisecond {
+		t.Fatalf("expect RespondedAt to be at least 10ms after ReceivedAt: %+v", *sr)
+	}
+}
+
+func TestHandler_TimeToHandlerPerRequest(t *testing.T) {
+	results := make(chan *ServerResult, 2)
+	h := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		func(_ *http.Request, sr *ServerResult) { results <- sr })
+
+	ts := httptest.NewUnstartedServer(h)
+	InstrumentServer(ts.Config)
+	ts.Start()
+	defer ts.Close()
+
+	// Both requests reuse one connection, and each is measured from its
+	// own read rather than from when the connection was first used.
+	var prev time.Time
+	for i := 0; i < 2; i++ {
+		res, err := ts.Client().Get(ts.URL)
+		if err != nil {
+			t.Fatal("Get failed:", err)
+		}
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+
+		sr := <-results
+		if sr.TimeToHandler <= 0 || sr.TimeToHandler > 50*time.Millisecond {
+			t.Fatalf("#%d TimeToHandler = %v, want a short non-zero duration", i, sr.TimeToHandler)
+		}
+		if !sr.ReceivedAt.After(prev) {
+			t.Fatalf("#%d ReceivedAt = %v, want after the previous request at %v", i, sr.ReceivedAt, prev)
+		}
+		prev = sr.RespondedAt
+		time.Sleep(60 * time.Millisecond)
+	}
+}
+
+func TestHandler_HTTP2(t *testing.T) {
+	results := make(chan *ServerResult, 1)
+	h := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		func(_ *http.Request, sr *ServerResult) { results <- sr })
+
+	ts := httptest.NewUnstartedServer(h)
+	ts.EnableHTTP2 = true
+	InstrumentServer(ts.Config)
+	ts.StartTLS()
+	defer ts.Close()
+
+	res, err := ts.Client().Get(ts.URL)
+	if err != nil {
+		t.Fatal("Get failed:", err)
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+	if res.ProtoMajor != 2 {
+		t.Fatalf("ProtoMajor = %d, want 2", res.ProtoMajor)
+	}
+
+	if sr := <-results; sr.TimeToHandler != 0 {
+		t.Fatalf("TimeToHandler = %v, want 0 for HTTP/2", sr.TimeToHandler)
+	}
+}
+
+func TestHandler_DefaultStatus(t *testing.T) {
+	var sr *ServerResult
+	h := Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}),
+		func(_ *http.Request, r *ServerResult) { sr = r })
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	if got, want := sr.StatusCode, http.StatusOK; got != want {
+		t.Fatalf("StatusCode = %d, want %d", got, want)
+	}
+	if sr.TimeToHandler != 0 {
+		t.Fatalf("TimeToHandler = %v, want 0 without InstrumentServer", sr.TimeToHandler)
+	}
+}
+
+func TestServerResult_Format(t *testing.T) {
+	sr := ServerResult{
+		TimeToHandler:   1 * time.Millisecond,
+		HandlerDuration: 100 * time.Millisecond,
+		ResponseWrite:   10 * time.Millisecond,
+		BytesWritten:    512,
+		StatusCode:      200,
+	}
+
+	want := `Time to handler:      1 ms
+Handler:            100 ms
+Response write:      10 ms
+
+Status:          200
+Bytes written:   512
+`
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%+v", sr)
+	if got := buf.String(); want != got {
+		t.Fatalf("expect to be eq:\n\nwant:\n\n%s\ngot:\n\n%s\n", want, got)
+	}
+
+	// The fields of the line output are in a fixed order.
+	wantLine := "TimeToHandler: 1 ms, HandlerDuration: 100 ms, ResponseWrite: 10 ms, StatusCode: 200, BytesWritten: 512"
+	for i := 0; i < 10; i++ {
+		if got := fmt.Sprintf("%s", sr); got != wantLine {
+			t.Fatalf("got line\n%s\nwant\n%s", got, wantLine)
+		}
+	}
+
+	us := sr
+	us.SetUnit(UnitMicrosecond)
+	want = `Time to handler:      1000 µs
+Handler:            100000 µs
+Response write:      10000 µs
+
+Status:          200
+Bytes written:   512
+`
+	if got := fmt.Sprintf("%+v", us); got != want {
+		t.Fatalf("got\n%s\nwant\n%s", got, want)
+	}
+
+	b, err := json.Marshal(sr)
+	if err != nil {
+		t.Fatal("json.Marshal failed:", err)
+	}
+	wantJSON := `{"timeToHandler":1000000,"handler":100000000,"responseWrite":10000000,"bytesWritten":512,"statusCode":200}`
+	if got := string(b); got != wantJSON {
+		t.Fatalf("json.Marshal = %s, want %s", got, wantJSON)
+	}
+}
@@ -0,0 +1,77 @@
+package httpstat
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ServerTiming is a metric reported by the server in the Server-Timing
+// response header (https://www.w3.org/TR/server-timing/).
+type ServerTiming struct {
+	Name        string
+	Duration    time.Duration
+	Description string
+}
+
+// ParseServerTiming parses all Server-Timing values in h. Malformed
+// entries are skipped.
+func ParseServerTiming(h http.Header) []ServerTiming {
+	var timings []ServerTiming
+	for _, v := range h.Values("Server-Timing") {
+		for _, entry := range splitQuoted(v, ',') {
+			params := splitQuoted(entry, ';')
+			name := strings.TrimSpace(params[0])
+			if name == "" {
+				continue
+			}
+
+			st := ServerTiming{Name: name}
+			for _, p := range params[1:] {
+				k, v, _ := strings.Cut(p, "=")
+				v = unquote(strings.TrimSpace(v))
+				switch strings.ToLower(strings.TrimSpace(k)) {
+				case "dur":
+					ms, err := strconv.ParseFloat(v, 64)
+					if err != nil {
+						continue
+					}
+					st.Duration = time.Duration(ms * float64(time.Millisecond))
+				case "desc":
+					st.Description = v
+				}
+			}
+			timings = append(timings, st)
+		}
+	}
+	return timings
+}
+
+// splitQuoted splits s at sep, ignoring separators inside quoted strings.
+func splitQuoted(s string, sep byte) []string {
+	var parts []string
+	quoted, start := false, 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '\\' && quoted:
+			i++
+		case c == '"':
+			quoted = !quoted
+		case c == sep && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+func unquote(s string) string {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return s
+	}
+	if u, err := strconv.Unquote(s); err == nil {
+		return u
+	}
+	return s[1 : len(s)-1]
+}
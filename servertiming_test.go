@@ -0,0 +1,57 @@
+package httpstat
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseServerTiming(t *testing.T) {
+	h := http.Header{}
+	h.Add("Server-Timing", `db;dur=53.2;desc="Query, cached", app;dur=10`)
+	h.Add("Server-Timing", `miss, broken;dur=abc`)
+	h.Add("Server-Timing", `;dur=1`)
+
+	want := []ServerTiming{
+		{Name: "db", Duration: 53200 * time.Microsecond, Description: "Query, cached"},
+		{Name: "app", Duration: 10 * time.Millisecond},
+		{Name: "miss"},
+		{Name: "broken"},
+	}
+	if got := ParseServerTiming(h); !reflect.DeepEqual(got, want) {
+		t.Fatalf("ParseServerTiming = %+v, want %+v", got, want)
+	}
+}
+
+func TestBody_ServerTiming(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Trailer", "Server-Timing")
+		w.Header().Set("Server-Timing", "app;dur=5")
+		io.WriteString(w, "ok")
+		w.Header().Set("Server-Timing", "total;dur=7")
+	}))
+	defer ts.Close()
+
+	var result Result
+	req := NewRequest(t, ts.URL, &result)
+	res, err := DefaultClient().Do(req)
+	if err != nil {
+		t.Fatal("client.Do failed:", err)
+	}
+	body := Body(res, &result)
+	if _, err := io.Copy(io.Discard, body); err != nil {
+		t.Fatal("io.Copy failed:", err)
+	}
+	body.Close()
+
+	want := []ServerTiming{
+		{Name: "app", Duration: 5 * time.Millisecond},
+		{Name: "total", Duration: 7 * time.Millisecond},
+	}
+	if got := result.ServerTiming; !reflect.DeepEqual(got, want) {
+		t.Fatalf("ServerTiming = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,163 @@
+package httpstat
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sink receives the Records of completed requests, e.g. to store or forward
+// them. Set it as Transport.Sink to write the requests sent through a
+// Transport. A Sink must be safe for concurrent use.
+type Sink interface {
+	WriteRecord(rec Record) error
+}
+
+// FileSink is a Sink which appends each Record as one line of JSON to a
+// file, rotating the file once it grows too large or too old.
+type FileSink struct {
+	// Path is the path of the file. It is created if it does not exist.
+	Path string
+
+	// MaxSize, if positive, is the size in bytes the file may grow to
+	// before it is rotated.
+	MaxSize int64
+
+	// MaxAge, if positive, is the time after which the file is rotated.
+	MaxAge time.Duration
+
+	// MaxBackups, if positive, is the number of rotated files to keep. The
+	// oldest ones are removed.
+	MaxBackups int
+
+	mu     sync.Mutex
+	f      *os.File
+	size   int64
+	opened time.Time
+}
+
+// WriteRecord implements Sink.
+func (s *FileSink) WriteRecord(rec Record) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.f != nil && s.needsRotate(int64(len(b))) {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+	if s.f == nil {
+		if err := s.open(); err != nil {
+			return err
+		}
+	}
+	n, err := s.f.Write(b)
+	s.size += int64(n)
+	return err
+}
+
+// Rotate closes the file, renames it to a backup named after the current
+// time and starts a new file.
+func (s *FileSink) Rotate() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.rotate()
+}
+
+// Close closes the file.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f == nil {
+		return nil
+	}
+	err := s.f.Close()
+	s.f = nil
+	return err
+}
+
+func (s *FileSink) needsRotate(n int64) bool {
+	if s.MaxSize > 0 && s.size > 0 && s.size+n > s.MaxSize {
+		return true
+	}
+	return s.MaxAge > 0 && time.Since(s.opened) >= s.MaxAge
+}
+
+func (s *FileSink) open() error {
+	f, err := os.OpenFile(s.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	s.f, s.size, s.opened = f, info.Size(), time.Now()
+	return nil
+}
+
+// backupPrefix and backupExt split the path, so that backups of
+// "results.jsonl" are named "results-<time>.jsonl".
+func (s *FileSink) backupPrefix() (prefix, ext string) {
+	ext = filepath.Ext(s.Path)
+	return strings.TrimSuffix(s.Path, ext) + "-", ext
+}
+
+const backupTimeLayout = "20060102T150405.000000000"
+
+func (s *FileSink) rotate() error {
+	if s.f != nil {
+		if err := s.f.Close(); err != nil {
+			return err
+		}
+		s.f = nil
+	}
+
+	prefix, ext := s.backupPrefix()
+	backup := prefix + time.Now().Format(backupTimeLayout) + ext
+	if err := os.Rename(s.Path, backup); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := s.removeBackups(); err != nil {
+		return err
+	}
+	return s.open()
+}
+
+func (s *FileSink) removeBackups() error {
+	if s.MaxBackups <= 0 {
+		return nil
+	}
+	prefix, ext := s.backupPrefix()
+	matches, err := filepath.Glob(prefix + "*" + ext)
+	if err != nil {
+		return err
+	}
+	var backups []string
+	for _, m := range matches {
+		t := strings.TrimSuffix(strings.TrimPrefix(m, prefix), ext)
+		if _, err := time.Parse(backupTimeLayout, t); err == nil {
+			backups = append(backups, m)
+		}
+	}
+	// The time layout sorts chronologically.
+	sort.Strings(backups)
+	for len(backups) > s.MaxBackups {
+		if err := os.Remove(backups[0]); err != nil {
+			return err
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package httpstat
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func readLines(t *testing.T, path string) []string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal("ReadFile failed:", err)
+	}
+	return strings.Split(strings.TrimSuffix(string(b), "\n"), "\n")
+}
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	s := &FileSink{Path: path}
+	defer s.Close()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer ts.Close()
+
+	client := &http.Client{Transport: &Transport{Sink: s}}
+	for i := 0; i < 3; i++ {
+		res, err := client.Get(ts.URL)
+		if err != nil {
+			t.Fatal("client.Get failed:", err)
+		}
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+	}
+
+	lines := readLines(t, path)
+	if len(lines) != 3 {
+		t.Fatalf("expect 3 lines, got %d", len(lines))
+	}
+	var rec struct {
+		URL        string
+		StatusCode int
+		Result     struct{ Total time.Duration }
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &rec); err != nil {
+		t.Fatal("Unmarshal failed:", err)
+	}
+	if rec.URL != ts.URL || rec.StatusCode != http.StatusOK || rec.Result.Total <= 0 {
+		t.Fatalf("unexpected record %+v", rec)
+	}
+}
+
+func TestFileSink_Rotate(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "results.jsonl")
+	s := &FileSink{Path: path, MaxSize: 1, MaxBackups: 2}
+	defer s.Close()
+
+	// Every record exceeds MaxSize, so each one starts a new file.
+	for i := 0; i < 5; i++ {
+		if err := s.WriteRecord(Record{Method: "GET", Result: &Result{}}); err != nil {
+			t.Fatal("WriteRecord failed:", err)
+		}
+	}
+
+	if lines := readLines(t, path); len(lines) != 1 {
+		t.Fatalf("expect current file to hold 1 record, got %d", len(lines))
+	}
+	backups, _ := filepath.Glob(filepath.Join(dir, "results-*.jsonl"))
+	if len(backups) != 2 {
+		t.Fatalf("expect 2 backups to be kept, got %v", backups)
+	}
+	for _, b := range backups {
+		f, _ := os.Open(b)
+		sc := bufio.NewScanner(f)
+		n := 0
+		for sc.Scan() {
+			n++
+		}
+		f.Close()
+		if n != 1 {
+			t.Fatalf("expect backup %s to hold 1 record, got %d", b, n)
+		}
+	}
+}
+
+func TestFileSink_MaxAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.jsonl")
+	s := &FileSink{Path: path, MaxAge: 10 * time.Millisecond}
+	defer s.Close()
+
+	s.WriteRecord(Record{})
+	time.Sleep(20 * time.Millisecond)
+	s.WriteRecord(Record{})
+
+	if lines := readLines(t, path); len(lines) != 1 {
+		t.Fatalf("expect file to be rotated, got %d lines", len(lines))
+	}
+}
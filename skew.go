@@ -0,0 +1,122 @@
+package httpstat
+
+import (
+	"time"
+)
+
+// Exchange holds the client and server timestamps of a single request,
+// which are needed to correlate client and server timings across hosts.
+// Client timestamps are taken from the client clock and server timestamps
+// from the server clock, which may be skewed against each other.
+type Exchange struct {
+	ClientSend    time.Time
+	ServerReceive time.Time
+	ServerSend    time.Time
+	ClientReceive time.Time
+}
+
+// Exchange returns the Exchange of the request measured by r, given the
+// time the server received the request and the time it sent the response
+// (e.g. ServerResult.ReceivedAt and ServerResult.RespondedAt).
+func (r *Result) Exchange(serverReceive, serverSend time.Time) Exchange {
+	return Exchange{
+		ClientSend:    r.serverStart,
+		ServerReceive: serverReceive,
+		ServerSend:    serverSend,
+		ClientReceive: r.serverDone,
+	}
+}
+
+// RoundTrip returns the network round trip time of the exchange, which is
+// the time the client waited for the response minus the time the server
+// spent on the request. It does not depend on clock skew.
+func (e Exchange) RoundTrip() time.Duration {
+	rtt := e.ClientReceive.Sub(e.ClientSend) - e.ServerSend.Sub(e.ServerReceive)
+	if rtt < 0 {
+		return 0
+	}
+	return rtt
+}
+
+// Bounds is an estimated duration together with the range the true value
+// is known to lie in.
+type Bounds struct {
+	Estimate time.Duration
+	Min      time.Duration
+	Max      time.Duration
+}
+
+// Offset estimates the offset of the server clock relative to the client
+// clock from the exchange, in the way NTP does. The true offset is within
+// half the round trip time of the estimate.
+func (e Exchange) Offset() Bounds {
+	est := (e.ServerReceive.Sub(e.ClientSend) + e.ServerSend.Sub(e.ClientReceive)) / 2
+	half := e.RoundTrip() / 2
+	return Bounds{
+		Estimate: est,
+		Min:      est - half,
+		Max:      est + half,
+	}
+}
+
+// EstimateOffset estimates the offset of the server clock relative to the
+// client clock from several exchanges between the same hosts. The bounds of
+// the individual exchanges are intersected, so more exchanges give tighter
+// bounds. If the bounds do not overlap (e.g. because a clock was adjusted),
+// the bounds of the exchange with the shortest round trip are used.
+func EstimateOffset(exchanges ...Exchange) Bounds {
+	if len(exchanges) == 0 {
+		return Bounds{}
+	}
+
+	b := exchanges[0].Offset()
+	best, bestRTT := b, exchanges[0].RoundTrip()
+	for _, e := range exchanges[1:] {
+		o := e.Offset()
+		if o.Min > b.Min {
+			b.Min = o.Min
+		}
+		if o.Max < b.Max {
+			b.Max = o.Max
+		}
+		if rtt := e.RoundTrip(); rtt < bestRTT {
+			best, bestRTT = o, rtt
+		}
+	}
+	if b.Min > b.Max {
+		return best
+	}
+	b.Estimate = b.Min + (b.Max-b.Min)/2
+	return b
+}
+
+// OneWay estimates the one-way network latencies of the exchange from the
+// client to the server (up) and back (down), compensating for the clock
+// offset. The bounds are limited to the round trip time of the exchange.
+func (e Exchange) OneWay(offset Bounds) (up, down Bounds) {
+	rtt := e.RoundTrip()
+	naiveUp := e.ServerReceive.Sub(e.ClientSend)
+	naiveDown := e.ClientReceive.Sub(e.ServerSend)
+
+	up = Bounds{
+		Estimate: clamp(naiveUp-offset.Estimate, 0, rtt),
+		Min:      clamp(naiveUp-offset.Max, 0, rtt),
+		Max:      clamp(naiveUp-offset.Min, 0, rtt),
+	}
+	down = Bounds{
+		Estimate: clamp(naiveDown+offset.Estimate, 0, rtt),
+		Min:      clamp(naiveDown+offset.Min, 0, rtt),
+		Max:      clamp(naiveDown+offset.Max, 0, rtt),
+	}
+	return up, down
+}
+
+func clamp(d, min, max time.Duration) time.Duration {
+	if d < min {
+		return min
+	}
+	if d > max {
+		return max
+	}
+	return d
+}
@@ -0,0 +1,66 @@
+package httpstat
+
+import (
+	"testing"
+	"time"
+)
+
+func TestExchange_Offset(t *testing.T) {
+	// The server clock is 1s ahead, the network takes 10ms each way and the
+	// server spends 5ms on the request.
+	t0 := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	e := Exchange{
+		ClientSend:    t0,
+		ServerReceive: t0.Add(time.Second + 10*time.Millisecond),
+		ServerSend:    t0.Add(time.Second + 15*time.Millisecond),
+		ClientReceive: t0.Add(25 * time.Millisecond),
+	}
+
+	if got, want := e.RoundTrip(), 20*time.Millisecond; got != want {
+		t.Fatalf("RoundTrip = %v, want %v", got, want)
+	}
+
+	o := e.Offset()
+	if want := (Bounds{Estimate: time.Second, Min: time.Second - 10*time.Millisecond, Max: time.Second + 10*time.Millisecond}); o != want {
+		t.Fatalf("Offset = %+v, want %+v", o, want)
+	}
+
+	up, down := e.OneWay(o)
+	if want := (Bounds{Estimate: 10 * time.Millisecond, Min: 0, Max: 20 * time.Millisecond}); up != want {
+		t.Fatalf("up = %+v, want %+v", up, want)
+	}
+	if want := (Bounds{Estimate: 10 * time.Millisecond, Min: 0, Max: 20 * time.Millisecond}); down != want {
+		t.Fatalf("down = %+v, want %+v", down, want)
+	}
+}
+
+func TestEstimateOffset(t *testing.T) {
+	t0 := time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)
+	skew := 500 * time.Millisecond
+	exchange := func(up, down time.Duration) Exchange {
+		return Exchange{
+			ClientSend:    t0,
+			ServerReceive: t0.Add(skew + up),
+			ServerSend:    t0.Add(skew + up),
+			ClientReceive: t0.Add(up + down),
+		}
+	}
+
+	// Asymmetric exchanges bound the offset from both sides.
+	o := EstimateOffset(exchange(2*time.Millisecond, 18*time.Millisecond), exchange(18*time.Millisecond, 2*time.Millisecond))
+	if o.Min != skew-2*time.Millisecond || o.Max != skew+2*time.Millisecond {
+		t.Fatalf("EstimateOffset = %+v, want bounds of %v±2ms", o, skew)
+	}
+	if o.Estimate != skew {
+		t.Fatalf("Estimate = %v, want %v", o.Estimate, skew)
+	}
+
+	up, _ := exchange(2*time.Millisecond, 18*time.Millisecond).OneWay(o)
+	if up.Min != 0 || up.Max != 4*time.Millisecond {
+		t.Fatalf("up = %+v, want between 0 and 4ms", up)
+	}
+
+	if got := EstimateOffset(); got != (Bounds{}) {
+		t.Fatalf("EstimateOffset() = %+v, want zero", got)
+	}
+}
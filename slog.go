@@ -0,0 +1,60 @@
+//go:build go1.21
+// +build go1.21
+
+package httpstat
+
+import (
+	"context"
+	"log/slog"
+)
+
+// SlogSink is a Sink logging each Record to Logger, with the method, URL,
+// status code, body length and the phase durations as attributes. Failed
+// requests are logged at slog.LevelError, with the total duration until
+// they failed.
+type SlogSink struct {
+	Logger *slog.Logger
+
+	// Level is the level of the successful requests, slog.LevelInfo by
+	// default.
+	Level slog.Level
+
+	// Message is the message of the log entries. If empty, "http request"
+	// is used.
+	Message string
+}
+
+// WriteRecord implements Sink.
+func (s *SlogSink) WriteRecord(rec Record) error {
+	r := rec.Result
+	if r == nil {
+		r = &Result{}
+	}
+	phases := make([]any, 0, len(phaseNames))
+	for _, pt := range r.Phases() {
+		if !pt.Skipped {
+			phases = append(phases, slog.Duration(logPhases[pt.Phase], pt.Duration))
+		}
+	}
+	attrs := []slog.Attr{
+		slog.String("method", rec.Method),
+		slog.String("url", rec.URL),
+		slog.Int("status", rec.StatusCode),
+		slog.Int64("bytes", r.BodyLength),
+		slog.Group("phases", phases...),
+	}
+	if total := r.elapsed(); total > 0 {
+		attrs = append(attrs, slog.Duration("total", total))
+	}
+
+	level, msg := s.Level, s.Message
+	if rec.Err != nil {
+		level = slog.LevelError
+		attrs = append(attrs, slog.String("error", rec.Err.Error()))
+	}
+	if msg == "" {
+		msg = "http request"
+	}
+	s.Logger.LogAttrs(context.Background(), level, msg, attrs...)
+	return nil
+}
@@ -0,0 +1,79 @@
+//go:build go1.21
+// +build go1.21
+
+package httpstat
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSlogSink(t *testing.T) {
+	var buf bytes.Buffer
+	s := &SlogSink{Logger: slog.New(slog.NewJSONHandler(&buf, nil))}
+	r := NewResultFromPhases(map[Phase]time.Duration{
+		PhaseDNS:    10 * time.Millisecond,
+		PhaseServer: 30 * time.Millisecond,
+	})
+	if err := s.WriteRecord(Record{Method: "GET", URL: "https://example.com", StatusCode: 200, Result: r}); err != nil {
+		t.Fatal("WriteRecord failed:", err)
+	}
+	if err := s.WriteRecord(Record{Method: "GET", URL: "https://example.com", Err: errors.New("boom"), Result: &Result{}}); err != nil {
+		t.Fatal("WriteRecord failed:", err)
+	}
+
+	dec := json.NewDecoder(&buf)
+	var ok, failed struct {
+		Level  string
+		Msg    string
+		Status int
+		Phases map[string]int64
+		Total  int64
+		Error  string
+	}
+	if err := dec.Decode(&ok); err != nil {
+		t.Fatal(err)
+	}
+	if ok.Level != "INFO" || ok.Msg != "http request" || ok.Status != 200 || ok.Phases["dns"] != int64(10*time.Millisecond) || ok.Phases["server"] != int64(30*time.Millisecond) || ok.Total != int64(40*time.Millisecond) {
+		t.Fatalf("unexpected entry %+v", ok)
+	}
+	if err := dec.Decode(&failed); err != nil {
+		t.Fatal(err)
+	}
+	if failed.Level != "ERROR" || failed.Error != "boom" {
+		t.Fatalf("expect the failure at the error level, got %+v", failed)
+	}
+}
+
+func TestSlogSink_Failed(t *testing.T) {
+	var buf bytes.Buffer
+	s := &SlogSink{Logger: slog.New(slog.NewJSONHandler(&buf, nil))}
+	for _, rec := range failedRecords(t) {
+		if err := s.WriteRecord(rec); err != nil {
+			t.Fatal("WriteRecord failed:", err)
+		}
+	}
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d entries, want 2: %q", len(lines), buf.String())
+	}
+	var refused struct {
+		Level string
+		Total int64
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &refused); err != nil {
+		t.Fatal(err)
+	}
+	if refused.Level != "ERROR" || refused.Total <= 0 || refused.Total >= int64(100*time.Millisecond) {
+		t.Fatalf("expect the total until the request failed, got %+v", refused)
+	}
+	if strings.Contains(lines[1], `"total"`) {
+		t.Fatalf("expect no total for a request failing before it started, got %s", lines[1])
+	}
+}
@@ -0,0 +1,60 @@
+package httpstat
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUnixSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "s.sock")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skip("unix sockets not supported:", err)
+	}
+	ts := httptest.NewUnstartedServer(http.NotFoundHandler())
+	ts.Listener = l
+	ts.Start()
+	defer ts.Close()
+
+	dialers := map[string]func(ctx context.Context, _, _ string) (net.Conn, error){
+		"traced": func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", path)
+		},
+		// A dialer ignoring the context does not report the connect.
+		"opaque": func(context.Context, string, string) (net.Conn, error) {
+			time.Sleep(time.Millisecond)
+			return net.Dial("unix", path)
+		},
+	}
+	for name, dial := range dialers {
+		var result Result
+		req, _ := http.NewRequestWithContext(WithHTTPStat(context.Background(), &result), "GET", "http://unix/", nil)
+		client := &http.Client{Transport: &http.Transport{DialContext: dial}}
+		res, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("%s: request failed: %v", name, err)
+		}
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+		result.EndNow()
+
+		if result.SocketPath != path {
+			t.Errorf("%s: SocketPath = %q, want %q", name, result.SocketPath, path)
+		}
+		if result.DNSLookup != 0 || result.TCPConnection <= 0 || result.Pretransfer != result.Connect {
+			t.Errorf("%s: expect only a socket connect, got %+v", name, result)
+		}
+		if got := fmt.Sprintf("%s", result); !strings.Contains(got, "SocketConnect: ") {
+			t.Errorf("%s: expect a socket connect in %q", name, got)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package httpstat
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+)
+
+// SocketInfo is what the kernel reports about a TCP connection, e.g. its
+// smoothed round-trip time, which tells the network latency apart from the
+// server processing of a Result. It is read from TCP_INFO on Linux,
+// TCP_CONNECTION_INFO on macOS and SIO_TCP_INFO on Windows.
+type SocketInfo struct {
+	// RTT is the smoothed round-trip time, and RTTVar its variation.
+	// Windows does not report RTTVar.
+	RTT    time.Duration
+	RTTVar time.Duration
+
+	// MSS is the maximum segment size sent, and CongestionWindow the send
+	// congestion window in bytes.
+	MSS              int
+	CongestionWindow int
+
+	// Retransmits is the number of retransmitted segments. Windows does
+	// not report it.
+	Retransmits int
+}
+
+// ErrSocketInfoUnsupported is returned by ReadSocketInfo on platforms
+// without a backend.
+var ErrSocketInfoUnsupported = errors.New("httpstat: socket info is not supported on this platform")
+
+// ReadSocketInfo reads the SocketInfo of the TCP connection c, which may be
+// wrapped in a tls.Conn, e.g. in the GotConn hook of a ClientTrace passed
+// to WithHTTPStatTrace. Connections of custom dialers must implement
+// syscall.Conn.
+func ReadSocketInfo(c net.Conn) (SocketInfo, error) {
+	if tc, ok := c.(*tls.Conn); ok {
+		c = tc.NetConn()
+	}
+	sc, ok := c.(syscall.Conn)
+	if !ok {
+		return SocketInfo{}, fmt.Errorf("httpstat: %T is not a socket", c)
+	}
+	rc, err := sc.SyscallConn()
+	if err != nil {
+		return SocketInfo{}, err
+	}
+	var info SocketInfo
+	var infoErr error
+	if err := rc.Control(func(fd uintptr) { info, infoErr = socketInfo(fd) }); err != nil {
+		return SocketInfo{}, err
+	}
+	return info, infoErr
+}
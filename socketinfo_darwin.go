@@ -0,0 +1,59 @@
+//go:build darwin
+// +build darwin
+
+package httpstat
+
+import (
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// tcpConnectionInfo is the TCP_CONNECTION_INFO socket option.
+const tcpConnectionInfo = 0x106
+
+// tcpConnectionInfoData is struct tcp_connection_info of
+// <netinet/tcp.h>.
+type tcpConnectionInfoData struct {
+	State               uint8
+	SndWscale           uint8
+	RcvWscale           uint8
+	_                   uint8
+	Options             uint32
+	Flags               uint32
+	Rto                 uint32 // ms
+	Maxseg              uint32
+	SndSsthresh         uint32
+	SndCwnd             uint32 // bytes
+	SndWnd              uint32
+	SndSbbytes          uint32
+	RcvWnd              uint32
+	Rttcur              uint32 // ms
+	Srtt                uint32 // ms
+	Rttvar              uint32 // ms
+	TFOFlags            uint32
+	Txpackets           uint64
+	Txbytes             uint64
+	Txretransmitbytes   uint64
+	Rxpackets           uint64
+	Rxbytes             uint64
+	Rxoutoforderbytes   uint64
+	Txretransmitpackets uint64
+}
+
+func socketInfo(fd uintptr) (SocketInfo, error) {
+	var ti tcpConnectionInfoData
+	n := uint32(unsafe.Sizeof(ti))
+	_, _, errno := syscall.Syscall6(syscall.SYS_GETSOCKOPT, fd, syscall.IPPROTO_TCP, tcpConnectionInfo,
+		uintptr(unsafe.Pointer(&ti)), uintptr(unsafe.Pointer(&n)), 0)
+	if errno != 0 {
+		return SocketInfo{}, errno
+	}
+	return SocketInfo{
+		RTT:              time.Duration(ti.Srtt) * time.Millisecond,
+		RTTVar:           time.Duration(ti.Rttvar) * time.Millisecond,
+		MSS:              int(ti.Maxseg),
+		CongestionWindow: int(ti.SndCwnd),
+		Retransmits:      int(ti.Txretransmitpackets),
+	}, nil
+}
@@ -0,0 +1,27 @@
+//go:build linux && !386
+// +build linux,!386
+
+package httpstat
+
+import (
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+func socketInfo(fd uintptr) (SocketInfo, error) {
+	var ti syscall.TCPInfo
+	n := uint32(syscall.SizeofTCPInfo)
+	_, _, errno := syscall.Syscall6(syscall.SYS_GETSOCKOPT, fd, syscall.IPPROTO_TCP, syscall.TCP_INFO,
+		uintptr(unsafe.Pointer(&ti)), uintptr(unsafe.Pointer(&n)), 0)
+	if errno != 0 {
+		return SocketInfo{}, errno
+	}
+	return SocketInfo{
+		RTT:              time.Duration(ti.Rtt) * time.Microsecond,
+		RTTVar:           time.Duration(ti.Rttvar) * time.Microsecond,
+		MSS:              int(ti.Snd_mss),
+		CongestionWindow: int(ti.Snd_cwnd) * int(ti.Snd_mss),
+		Retransmits:      int(ti.Total_retrans),
+	}, nil
+}
@@ -0,0 +1,8 @@
+//go:build (!linux && !darwin && !windows) || (linux && 386)
+// +build !linux,!darwin,!windows linux,386
+
+package httpstat
+
+func socketInfo(uintptr) (SocketInfo, error) {
+	return SocketInfo{}, ErrSocketInfoUnsupported
+}
@@ -0,0 +1,57 @@
+package httpstat
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"testing"
+)
+
+func TestReadSocketInfo(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer ts.Close()
+
+	var info SocketInfo
+	var infoErr error
+	trace := &httptrace.ClientTrace{
+		GotConn: func(i httptrace.GotConnInfo) {
+			info, infoErr = ReadSocketInfo(i.Conn)
+		},
+	}
+	var result Result
+	req, err := http.NewRequestWithContext(WithHTTPStatTrace(context.Background(), &result, trace), "GET", ts.URL, nil)
+	if err != nil {
+		t.Fatal("NewRequest failed:", err)
+	}
+	res, err := ts.Client().Do(req)
+	if err != nil {
+		t.Fatal("client.Do failed:", err)
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+
+	if errors.Is(infoErr, ErrSocketInfoUnsupported) {
+		t.Skip(infoErr)
+	}
+	if infoErr != nil {
+		t.Fatal("ReadSocketInfo failed:", infoErr)
+	}
+	if info.MSS <= 0 || info.CongestionWindow < info.MSS {
+		t.Fatalf("expect the MSS and congestion window of the connection, got %+v", info)
+	}
+}
+
+func TestReadSocketInfo_NotSocket(t *testing.T) {
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+	if _, err := ReadSocketInfo(c1); err == nil {
+		t.Fatal("expect an error for a connection which is not a socket")
+	}
+}
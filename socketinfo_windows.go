@@ -0,0 +1,53 @@
+//go:build windows
+// +build windows
+
+package httpstat
+
+import (
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// sioTCPInfo is the SIO_TCP_INFO control code of WSAIoctl.
+const sioTCPInfo = syscall.IOC_INOUT | syscall.IOC_VENDOR | 39
+
+// tcpInfoV0 is TCP_INFO_v0 of <mstcpip.h>.
+type tcpInfoV0 struct {
+	State             int32
+	Mss               uint32
+	ConnectionTimeMs  uint64
+	TimestampsEnabled bool
+	RttUs             uint32
+	MinRttUs          uint32
+	BytesInFlight     uint32
+	Cwnd              uint32 // bytes
+	SndWnd            uint32
+	RcvWnd            uint32
+	RcvBuf            uint32
+	BytesOut          uint64
+	BytesIn           uint64
+	BytesReordered    uint32
+	BytesRetrans      uint32
+	FastRetrans       uint32
+	DupAcksIn         uint32
+	TimeoutEpisodes   uint32
+	SynRetrans        uint8
+}
+
+func socketInfo(fd uintptr) (SocketInfo, error) {
+	var version uint32 // TCP_INFO_v0
+	var ti tcpInfoV0
+	var n uint32
+	err := syscall.WSAIoctl(syscall.Handle(fd), sioTCPInfo,
+		(*byte)(unsafe.Pointer(&version)), uint32(unsafe.Sizeof(version)),
+		(*byte)(unsafe.Pointer(&ti)), uint32(unsafe.Sizeof(ti)), &n, nil, 0)
+	if err != nil {
+		return SocketInfo{}, err
+	}
+	return SocketInfo{
+		RTT:              time.Duration(ti.RttUs) * time.Microsecond,
+		MSS:              int(ti.Mss),
+		CongestionWindow: int(ti.Cwnd),
+	}, nil
+}
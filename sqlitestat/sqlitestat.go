@@ -0,0 +1,200 @@
+// Package sqlitestat stores go-httpstat Records in a SQLite database, one
+// row per request with a column per phase, for keeping a lightweight
+// latency history without extra infrastructure. It uses the pure Go SQLite
+// driver modernc.org/sqlite, so it needs no cgo.
+package sqlitestat
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/url"
+	"time"
+
+	"github.com/jakobilobi/go-httpstat"
+
+	_ "modernc.org/sqlite"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS results (
+	time              INTEGER NOT NULL,
+	day               TEXT NOT NULL,
+	method            TEXT NOT NULL,
+	url               TEXT NOT NULL,
+	host              TEXT NOT NULL,
+	status_code       INTEGER NOT NULL,
+	error             TEXT NOT NULL,
+	protocol          TEXT NOT NULL,
+	dns_lookup        INTEGER NOT NULL,
+	tcp_connection    INTEGER NOT NULL,
+	proxy_connect     INTEGER NOT NULL,
+	tls_handshake     INTEGER NOT NULL,
+	continue_wait     INTEGER NOT NULL,
+	server_processing INTEGER NOT NULL,
+	content_transfer  INTEGER NOT NULL,
+	total             INTEGER NOT NULL,
+	labels            TEXT NOT NULL DEFAULT '{}'
+);
+CREATE INDEX IF NOT EXISTS results_host_day ON results (host, day);
+`
+
+// Sink is an httpstat.Sink writing Records into the results table of a
+// SQLite database. Times are stored as Unix nanoseconds, durations as
+// nanoseconds and the labels of the Results as a JSON object, which can be
+// queried with json_extract.
+type Sink struct {
+	db     *sql.DB
+	insert *sql.Stmt
+}
+
+// Open opens the SQLite database at path, creating it if needed, and
+// returns a Sink writing to it.
+func Open(path string) (*Sink, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+	// SQLite allows a single writer at a time.
+	db.SetMaxOpenConns(1)
+
+	s, err := New(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// New returns a Sink writing to db, creating the results table if needed.
+func New(db *sql.DB) (*Sink, error) {
+	if _, err := db.Exec(schema); err != nil {
+		return nil, err
+	}
+	if err := migrate(db); err != nil {
+		return nil, err
+	}
+	insert, err := db.Prepare(`INSERT INTO results VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return nil, err
+	}
+	return &Sink{db: db, insert: insert}, nil
+}
+
+// migrate adds the columns missing in results tables created by older
+// versions.
+func migrate(db *sql.DB) error {
+	var n int
+	err := db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info('results') WHERE name = 'labels'`).Scan(&n)
+	if err != nil || n > 0 {
+		return err
+	}
+	_, err = db.Exec(`ALTER TABLE results ADD COLUMN labels TEXT NOT NULL DEFAULT '{}'`)
+	return err
+}
+
+// DB returns the database of the Sink, for custom queries.
+func (s *Sink) DB() *sql.DB {
+	return s.db
+}
+
+// Close closes the database.
+func (s *Sink) Close() error {
+	s.insert.Close()
+	return s.db.Close()
+}
+
+// WriteRecord implements httpstat.Sink.
+func (s *Sink) WriteRecord(rec httpstat.Record) error {
+	var host string
+	if u, err := url.Parse(rec.URL); err == nil {
+		host = u.Host
+	}
+	var errMsg string
+	if rec.Err != nil {
+		errMsg = rec.Err.Error()
+	}
+	r := rec.Result
+	if r == nil {
+		r = &httpstat.Result{}
+	}
+	// The Result of a failed request was not ended, so it has no content
+	// transfer or total.
+	var transfer, total time.Duration
+	if rec.Err == nil && r.IsComplete() {
+		transfer, total = r.ContentTransfer(), r.Total()
+	}
+	labels, err := json.Marshal(r.Labels())
+	if err != nil {
+		return err
+	}
+
+	_, err = s.insert.Exec(
+		rec.Time.UnixNano(), rec.Time.UTC().Format(dayLayout),
+		rec.Method, rec.URL, host, rec.StatusCode, errMsg, r.Protocol,
+		int64(r.DNSLookup), int64(r.TCPConnection), int64(r.ProxyConnect),
+		int64(r.TLSHandshake), int64(r.ContinueWait), int64(r.ServerProcessing),
+		int64(transfer), int64(total), string(labels),
+	)
+	return err
+}
+
+const dayLayout = "2006-01-02"
+
+// Day holds the aggregated Results of the successful requests to one host
+// on one day (in UTC).
+type Day struct {
+	Host string
+	Day  time.Time
+
+	*httpstat.Aggregator
+}
+
+// Days aggregates the successful requests sent in [from, to) per host and
+// day, ordered by host and day.
+func (s *Sink) Days(ctx context.Context, from, to time.Time) ([]Day, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT host, day, time, dns_lookup, tcp_connection, proxy_connect,
+			tls_handshake, continue_wait, server_processing, content_transfer
+		FROM results
+		WHERE time >= ? AND time < ? AND error = ''
+		ORDER BY host, day, time`,
+		from.UnixNano(), to.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var days []Day
+	for rows.Next() {
+		var (
+			host, day string
+			t         int64
+			d         [7]int64
+		)
+		if err := rows.Scan(&host, &day, &t, &d[0], &d[1], &d[2], &d[3], &d[4], &d[5], &d[6]); err != nil {
+			return nil, err
+		}
+
+		if n := len(days); n == 0 || days[n-1].Host != host || days[n-1].Day.Format(dayLayout) != day {
+			dt, err := time.Parse(dayLayout, day)
+			if err != nil {
+				return nil, err
+			}
+			days = append(days, Day{Host: host, Day: dt, Aggregator: &httpstat.Aggregator{}})
+		}
+
+		r := httpstat.NewResultBuilder().
+			Start(time.Unix(0, t)).
+			Phase(httpstat.PhaseDNS, time.Duration(d[0])).
+			Phase(httpstat.PhaseConnect, time.Duration(d[1])).
+			Phase(httpstat.PhaseProxyConnect, time.Duration(d[2])).
+			Phase(httpstat.PhaseTLS, time.Duration(d[3])).
+			Phase(httpstat.PhaseContinueWait, time.Duration(d[4])).
+			Phase(httpstat.PhaseServer, time.Duration(d[5])).
+			Phase(httpstat.PhaseTransfer, time.Duration(d[6])).
+			Build()
+		days[len(days)-1].Add(r)
+	}
+	return days, rows.Err()
+}
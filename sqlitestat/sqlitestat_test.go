@@ -0,0 +1,151 @@
+package sqlitestat
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jakobilobi/go-httpstat"
+)
+
+func TestSink(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "results.db"))
+	if err != nil {
+		t.Fatal("Open failed:", err)
+	}
+	defer s.Close()
+
+	day1 := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	write := func(t0 time.Time, url string, dns time.Duration, err error) {
+		t.Helper()
+		rec := httpstat.Record{
+			Time:       t0,
+			Method:     "GET",
+			URL:        url,
+			StatusCode: 200,
+			Err:        err,
+			Result: httpstat.NewResultFromPhases(map[httpstat.Phase]time.Duration{
+				httpstat.PhaseDNS:    dns,
+				httpstat.PhaseServer: 100 * time.Millisecond,
+			}),
+		}
+		if err := s.WriteRecord(rec); err != nil {
+			t.Fatal("WriteRecord failed:", err)
+		}
+	}
+	for i := 1; i <= 10; i++ {
+		write(day1, "https://a.example/x", time.Duration(i)*time.Millisecond, nil)
+	}
+	write(day2, "https://a.example/y", 50*time.Millisecond, nil)
+	write(day1, "https://b.example/", 5*time.Millisecond, nil)
+	write(day1, "https://b.example/", 0, errors.New("refused"))
+
+	var n int
+	if err := s.DB().QueryRow(`SELECT COUNT(*) FROM results`).Scan(&n); err != nil {
+		t.Fatal("query failed:", err)
+	}
+	if n != 13 {
+		t.Fatalf("expect 13 rows, got %d", n)
+	}
+
+	days, err := s.Days(context.Background(), day1.Add(-time.Hour), day2.Add(time.Hour))
+	if err != nil {
+		t.Fatal("Days failed:", err)
+	}
+	if len(days) != 3 {
+		t.Fatalf("expect 3 host days, got %d", len(days))
+	}
+
+	a := days[0]
+	if a.Host != "a.example" || !a.Day.Equal(time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected first day %s %s", a.Host, a.Day)
+	}
+	dns := a.Phase(httpstat.PhaseDNS)
+	if dns.Count != 10 || dns.P50 != 5*time.Millisecond || dns.P99 != 10*time.Millisecond {
+		t.Fatalf("unexpected DNS stats %+v", dns)
+	}
+	if s := a.Phase(httpstat.PhaseServer); s.P50 != 100*time.Millisecond {
+		t.Fatalf("unexpected server stats %+v", s)
+	}
+
+	if days[1].Host != "a.example" || days[1].Count() != 1 {
+		t.Fatalf("unexpected second day %s with %d results", days[1].Host, days[1].Count())
+	}
+	if days[2].Host != "b.example" || days[2].Count() != 1 {
+		t.Fatalf("expect failed request to be excluded, got %d results", days[2].Count())
+	}
+}
+
+func TestSink_Failed(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "results.db"))
+	if err != nil {
+		t.Fatal("Open failed:", err)
+	}
+	defer s.Close()
+
+	// The Result of a request which failed before any response was read.
+	rec := httpstat.Record{
+		Time:   time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+		Method: "GET",
+		URL:    "https://a.example/",
+		Err:    errors.New("refused"),
+		Result: &httpstat.Result{},
+	}
+	if err := s.WriteRecord(rec); err != nil {
+		t.Fatal("WriteRecord failed:", err)
+	}
+
+	var transfer, total int64
+	var errMsg string
+	if err := s.DB().QueryRow(`SELECT content_transfer, total, error FROM results`).Scan(&transfer, &total, &errMsg); err != nil {
+		t.Fatal("query failed:", err)
+	}
+	if transfer != 0 || total != 0 || errMsg != "refused" {
+		t.Fatalf("got content_transfer %d, total %d, error %q, want 0, 0, refused", transfer, total, errMsg)
+	}
+}
+
+func TestSink_Labels(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results.db")
+
+	// A results table of an older version without the labels column.
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := strings.Replace(schema, ",\n\tlabels            TEXT NOT NULL DEFAULT '{}'", "", 1)
+	if old == schema {
+		t.Fatal("expect the labels column in the schema")
+	}
+	if _, err := db.Exec(old); err != nil {
+		t.Fatal("creating old schema failed:", err)
+	}
+	db.Close()
+
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal("Open failed:", err)
+	}
+	defer s.Close()
+
+	r := httpstat.NewResultFromPhases(map[httpstat.Phase]time.Duration{
+		httpstat.PhaseServer: 10 * time.Millisecond,
+	})
+	r.SetLabel("tenant", "acme")
+	if err := s.WriteRecord(httpstat.Record{Time: time.Now(), Method: "GET", URL: "https://a.example/", Result: r}); err != nil {
+		t.Fatal("WriteRecord failed:", err)
+	}
+
+	var tenant string
+	if err := s.DB().QueryRow(`SELECT json_extract(labels, '$.tenant') FROM results`).Scan(&tenant); err != nil {
+		t.Fatal("query failed:", err)
+	}
+	if tenant != "acme" {
+		t.Fatalf("tenant = %q, want acme", tenant)
+	}
+}
@@ -0,0 +1,121 @@
+package httpstat
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// EventStream is a Sink and an http.Handler streaming the Records written
+// to it to its clients as Server-Sent Events, so a browser dashboard can
+// watch the client latencies of a running service live:
+//
+//	stream := &httpstat.EventStream{}
+//	transport := &httpstat.Transport{Sink: stream}
+//	http.Handle("/debug/httpstat/events", stream)
+//
+// Each Record is sent as an event of type "result" with the Record as JSON
+// data, which an EventSource in the browser receives with
+// addEventListener("result", ...). An EventStream is safe for concurrent
+// use.
+type EventStream struct {
+	// Buffer is the number of events buffered for each client. Events are
+	// dropped for clients which fall further behind. If zero, 64 is used.
+	Buffer int
+
+	// Heartbeat is the interval of the comments keeping idle connections
+	// open through proxies. If zero, 15 seconds is used.
+	Heartbeat time.Duration
+
+	mu      sync.Mutex
+	clients map[chan []byte]struct{}
+	id      uint64
+}
+
+// WriteRecord implements Sink. It does not wait for the clients.
+func (s *EventStream) WriteRecord(rec Record) error {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.id++
+	event := make([]byte, 0, len(data)+40)
+	event = append(event, "event: result\nid: "...)
+	event = strconv.AppendUint(event, s.id, 10)
+	event = append(event, "\ndata: "...)
+	event = append(event, data...)
+	event = append(event, "\n\n"...)
+	for c := range s.clients {
+		select {
+		case c <- event:
+		default:
+		}
+	}
+	return nil
+}
+
+// ServeHTTP implements http.Handler. It streams the events until the
+// client goes away.
+func (s *EventStream) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	n := s.Buffer
+	if n <= 0 {
+		n = 64
+	}
+	c := make(chan []byte, n)
+	s.mu.Lock()
+	if s.clients == nil {
+		s.clients = make(map[chan []byte]struct{})
+	}
+	s.clients[c] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, c)
+		s.mu.Unlock()
+	}()
+
+	heartbeat := s.Heartbeat
+	if heartbeat <= 0 {
+		heartbeat = 15 * time.Second
+	}
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+	for {
+		var err error
+		select {
+		case event := <-c:
+			_, err = w.Write(event)
+		case <-ticker.C:
+			_, err = w.Write([]byte(": heartbeat\n\n"))
+		case <-req.Context().Done():
+			return
+		}
+		if err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// clientCount returns the number of connected clients.
+func (s *EventStream) clientCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.clients)
+}
@@ -0,0 +1,61 @@
+package httpstat
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEventStream(t *testing.T) {
+	stream := &EventStream{}
+	ts := httptest.NewServer(stream)
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL)
+	if err != nil {
+		t.Fatal("http.Get failed:", err)
+	}
+	defer res.Body.Close()
+	if ct := res.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", ct)
+	}
+	for stream.clientCount() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	r := NewResultFromPhases(map[Phase]time.Duration{PhaseServer: 10 * time.Millisecond})
+	if err := stream.WriteRecord(Record{Method: "GET", URL: "https://example.com", StatusCode: 200, Result: r}); err != nil {
+		t.Fatal("WriteRecord failed:", err)
+	}
+
+	br := bufio.NewReader(res.Body)
+	var lines []string
+	for {
+		line, err := br.ReadString('\n')
+		if err != nil {
+			t.Fatal("reading the event failed:", err)
+		}
+		if line == "\n" {
+			break
+		}
+		lines = append(lines, strings.TrimSuffix(line, "\n"))
+	}
+	if len(lines) != 3 || lines[0] != "event: result" || lines[1] != "id: 1" || !strings.HasPrefix(lines[2], "data: ") {
+		t.Fatalf("unexpected event %q", lines)
+	}
+	var rec struct {
+		URL string `json:"url"`
+	}
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(lines[2], "data: ")), &rec); err != nil || rec.URL != "https://example.com" {
+		t.Fatalf("unexpected data %q: %v", lines[2], err)
+	}
+
+	res.Body.Close()
+	for stream.clientCount() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,78 @@
+package httpstat
+
+import (
+	"sync"
+	"time"
+)
+
+// WithStallDetection makes the body wrapper flag the Result as Stalled
+// when a read of the body waits for longer than window without a byte
+// arriving, telling hung connections from slow but flowing ones. onStall,
+// if not nil, is called from another goroutine as soon as that happens,
+// while the read is still waiting, e.g. to close the body.
+func WithStallDetection(window time.Duration, onStall func(r *Result)) BodyOption {
+	return func(b *body) {
+		b.stall = &stallWatchdog{window: window, onStall: onStall, result: b.result}
+	}
+}
+
+// stallWatchdog watches the reads of a body for stalls.
+type stallWatchdog struct {
+	window  time.Duration
+	onStall func(r *Result)
+	result  *Result
+
+	mu      sync.Mutex
+	timer   *time.Timer
+	stopped bool
+}
+
+// arm starts watching a read.
+func (w *stallWatchdog) arm() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.stopped {
+		return
+	}
+	if w.timer == nil {
+		w.timer = time.AfterFunc(w.window, w.fire)
+		return
+	}
+	w.timer.Reset(w.window)
+}
+
+// disarm stops watching a read which returned.
+func (w *stallWatchdog) disarm() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+}
+
+// stop stops the watchdog for good. The Result is not modified afterwards.
+func (w *stallWatchdog) stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.stopped = true
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+}
+
+func (w *stallWatchdog) fire() {
+	w.mu.Lock()
+	if w.stopped {
+		w.mu.Unlock()
+		return
+	}
+	// The Result may be read while the body read waits, e.g. by Phases.
+	w.result.lock()
+	w.result.Stalled = true
+	w.result.unlock()
+	w.mu.Unlock()
+
+	if w.onStall != nil {
+		w.onStall(w.result)
+	}
+}
@@ -0,0 +1,122 @@
+package httpstat
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTransport_StallWindow(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pause, _ := time.ParseDuration(r.URL.Query().Get("pause"))
+		for i := 0; i < 3; i++ {
+			io.WriteString(w, "chunk")
+			w.(http.Flusher).Flush()
+			time.Sleep(pause)
+		}
+	}))
+	defer ts.Close()
+
+	stalls := make(chan *Result, 10)
+	client := &http.Client{Transport: &Transport{
+		Base:        DefaultTransport(),
+		StallWindow: 50 * time.Millisecond,
+		OnStall: func(req *http.Request, r *Result) {
+			stalls <- r
+		},
+	}}
+	get := func(pause string) *Result {
+		res, err := client.Get(ts.URL + "?pause=" + pause)
+		if err != nil {
+			t.Fatal(err)
+		}
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+		return res.Body.(*body).result
+	}
+
+	if r := get("10ms"); r.Stalled {
+		t.Fatal("expect a flowing body not to be flagged")
+	}
+	r := get("100ms")
+	if !r.Stalled {
+		t.Fatal("expect a stalled body to be flagged")
+	}
+	select {
+	case s := <-stalls:
+		if s != r {
+			t.Fatal("expect OnStall to be called with the Result of the request")
+		}
+	default:
+		t.Fatal("expect OnStall to be called")
+	}
+}
+
+func TestWithStallDetection_SlowReader(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "chunk")
+		w.(http.Flusher).Flush()
+		io.WriteString(w, "chunk")
+	}))
+	defer ts.Close()
+
+	var result Result
+	res, err := DefaultClient().Do(NewRequest(t, ts.URL, &result))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := Body(res, &result, WithStallDetection(20*time.Millisecond, nil))
+	p := make([]byte, 5)
+	b.Read(p)
+	// Time the caller does not read is no stall.
+	time.Sleep(50 * time.Millisecond)
+	io.Copy(io.Discard, b)
+	b.Close()
+	if result.Stalled {
+		t.Fatal("expect a slow reader not to be flagged")
+	}
+}
+
+func TestWithStallDetection_ConcurrentRead(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "chunk")
+		w.(http.Flusher).Flush()
+		time.Sleep(100 * time.Millisecond)
+		io.WriteString(w, "chunk")
+	}))
+	defer ts.Close()
+
+	var result Result
+	res, err := DefaultClient().Do(NewRequest(t, ts.URL, &result))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stalled := make(chan struct{})
+	b := Body(res, &result, WithStallDetection(20*time.Millisecond, func(*Result) {
+		close(stalled)
+	}))
+	p := make([]byte, 5)
+	b.Read(p)
+
+	// The Result is read while the next read waits and the watchdog fires.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stalled:
+				return
+			default:
+				result.Phases()
+			}
+		}
+	}()
+	io.Copy(io.Discard, b)
+	b.Close()
+	<-done
+	if !result.Stalled {
+		t.Fatal("expect a stalled body to be flagged")
+	}
+}
@@ -0,0 +1,104 @@
+package httpstat
+
+import (
+	"mime"
+	"time"
+)
+
+// WithStreamEvents makes the body wrapper record the time each event of a
+// streamed response arrives in Result.StreamEvents. The events are
+// Server-Sent Events for responses with the content type
+// text/event-stream, not counting comments such as keep-alive pings, and
+// lines otherwise, as in NDJSON streams.
+func WithStreamEvents() BodyOption {
+	return func(b *body) {
+		b.events = &eventScanner{}
+		ct, _, _ := mime.ParseMediaType(b.res.Header.Get("Content-Type"))
+		b.events.sse = ct == "text/event-stream"
+	}
+}
+
+// TimeToFirstEvent returns the time from the start of the request to the
+// first event of the stream, or zero if none arrived.
+func (r *Result) TimeToFirstEvent() time.Duration {
+	if len(r.StreamEvents) == 0 || r.dnsStart.IsZero() {
+		return 0
+	}
+	return r.StreamEvents[0].Sub(r.dnsStart)
+}
+
+// EventGaps returns the times between consecutive events of the stream.
+func (r *Result) EventGaps() []time.Duration {
+	if len(r.StreamEvents) < 2 {
+		return nil
+	}
+	gaps := make([]time.Duration, len(r.StreamEvents)-1)
+	for i := range gaps {
+		gaps[i] = r.StreamEvents[i+1].Sub(r.StreamEvents[i])
+	}
+	return gaps
+}
+
+// eventScanner finds the ends of the events in the bytes of a stream.
+type eventScanner struct {
+	// sse is set for Server-Sent Events, which end with a blank line;
+	// otherwise, each line is an event.
+	sse bool
+
+	// started is set once the first line started
+	started bool
+
+	// lineStart is set before the first byte of a line, and cr after a
+	// carriage return, which may be followed by a line feed
+	lineStart bool
+	cr        bool
+
+	// comment is set in SSE comment lines, and data in lines with other
+	// content; pending is set if the current SSE event has a field line
+	comment bool
+	data    bool
+	pending bool
+}
+
+// scan returns the number of events ending in p.
+func (s *eventScanner) scan(p []byte) int {
+	if !s.started {
+		s.started, s.lineStart = true, true
+	}
+	n := 0
+	for _, c := range p {
+		if c == '\n' && s.cr {
+			s.cr = false
+			continue
+		}
+		s.cr = c == '\r'
+		if c != '\r' && c != '\n' {
+			if s.lineStart {
+				s.comment = s.sse && c == ':'
+				s.lineStart = false
+			}
+			if !s.comment {
+				s.data = true
+			}
+			continue
+		}
+
+		// A line ended.
+		switch {
+		case !s.sse:
+			if s.data {
+				n++
+			}
+		case s.lineStart:
+			// A blank line dispatches the event.
+			if s.pending {
+				n++
+				s.pending = false
+			}
+		case s.data:
+			s.pending = true
+		}
+		s.lineStart, s.comment, s.data = true, false, false
+	}
+	return n
+}
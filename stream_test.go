@@ -0,0 +1,69 @@
+package httpstat
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestEventScanner(t *testing.T) {
+	cases := []struct {
+		sse    bool
+		chunks []string
+		want   int
+	}{
+		{true, []string{"data: a\n\ndata: b\n\n"}, 2},
+		{true, []string{"event: x\ndata: a\ndata: b\n\n"}, 1},
+		{true, []string{": ping\n\n", "data: a\r\n\r\n"}, 1},
+		{true, []string{"data: a\r", "\n", "\r", "\ndata: b\r\r"}, 2},
+		{true, []string{"data: a\n", "\n", "data: b\n"}, 1},
+		{false, []string{"{\"a\":1}\n{\"b\"", ":2}\n\n{}"}, 2},
+		{false, []string{"a\r\nb\r\n"}, 2},
+	}
+	for _, c := range cases {
+		s := &eventScanner{sse: c.sse}
+		n := 0
+		for _, chunk := range c.chunks {
+			n += s.scan([]byte(chunk))
+		}
+		if n != c.want {
+			t.Errorf("sse %v, %q: expect %d events, got %d", c.sse, c.chunks, c.want, n)
+		}
+	}
+}
+
+func TestBody_StreamEvents(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+		for i := 0; i < 3; i++ {
+			time.Sleep(20 * time.Millisecond)
+			io.WriteString(w, ": keep-alive\n\ndata: tick\n\n")
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer ts.Close()
+
+	var result Result
+	req := NewRequest(t, ts.URL, &result)
+	res, err := DefaultClient().Do(req)
+	if err != nil {
+		t.Fatal("request failed:", err)
+	}
+	body := Body(res, &result, WithStreamEvents())
+	io.Copy(io.Discard, body)
+	body.Close()
+
+	if len(result.StreamEvents) != 3 {
+		t.Fatalf("expect 3 events, got %d", len(result.StreamEvents))
+	}
+	if result.TimeToFirstEvent() < 20*time.Millisecond {
+		t.Fatalf("expect the first event after at least 20ms, got %v", result.TimeToFirstEvent())
+	}
+	for _, gap := range result.EventGaps() {
+		if gap < 15*time.Millisecond {
+			t.Fatalf("expect gaps of about 20ms, got %v", result.EventGaps())
+		}
+	}
+}
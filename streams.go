@@ -0,0 +1,55 @@
+package httpstat
+
+import (
+	"net"
+	"sync"
+)
+
+// streams counts the requests in flight on each connection. Connections
+// are removed once no request is in flight on them, so closed connections
+// are not kept.
+var (
+	streamsMu sync.Mutex
+	streams   = map[net.Conn]int{}
+)
+
+// stream is a request in flight on conn. It is shared by copies of the
+// Result, so it is released once.
+type stream struct {
+	conn     net.Conn
+	released bool
+}
+
+// acquireStream records that the request is in flight on c, and returns
+// the number of other requests in flight on it. A request retried on
+// another connection releases the previous one. It must be called with r
+// locked.
+func (r *Result) acquireStream(c net.Conn) int {
+	if c == nil {
+		return 0
+	}
+	r.stream.release()
+	streamsMu.Lock()
+	defer streamsMu.Unlock()
+	n := streams[c]
+	streams[c] = n + 1
+	r.stream = &stream{conn: c}
+	return n
+}
+
+func (s *stream) release() {
+	if s == nil {
+		return
+	}
+	streamsMu.Lock()
+	defer streamsMu.Unlock()
+	if s.released {
+		return
+	}
+	s.released = true
+	if n := streams[s.conn] - 1; n > 0 {
+		streams[s.conn] = n
+	} else {
+		delete(streams, s.conn)
+	}
+}
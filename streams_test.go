@@ -0,0 +1,92 @@
+package httpstat
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestTransport_ConcurrentStreams(t *testing.T) {
+	var arrived sync.WaitGroup
+	arrived.Add(2)
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/wait" {
+			arrived.Done()
+			arrived.Wait()
+		}
+	}))
+	ts.EnableHTTP2 = true
+	ts.StartTLS()
+	defer ts.Close()
+
+	var mu sync.Mutex
+	var results []*Result
+	client := &http.Client{Transport: &Transport{
+		Base: ts.Client().Transport,
+		OnResult: func(_ *http.Request, r *Result, _ error) {
+			mu.Lock()
+			results = append(results, r)
+			mu.Unlock()
+		},
+	}}
+	get := func(path string) {
+		res, err := client.Get(ts.URL + path)
+		if err != nil {
+			t.Error("request failed:", err)
+			return
+		}
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+	}
+	// Establish the connection first, so both requests share it.
+	get("/")
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			get("/wait")
+		}()
+	}
+	wg.Wait()
+
+	if len(results) != 3 {
+		t.Fatalf("got %d Results, want 3", len(results))
+	}
+	concurrent := 0
+	for _, r := range results {
+		if r.Protocol != "h2" || r.StreamWait <= 0 {
+			t.Errorf("expect a stream wait for HTTP/2, got %q and %v", r.Protocol, r.StreamWait)
+		}
+		concurrent += r.ConcurrentStreams
+		streamsMu.Lock()
+		if !r.stream.released {
+			t.Error("expect the stream to be released")
+		}
+		streamsMu.Unlock()
+	}
+	if results[0].ConcurrentStreams != 0 || concurrent != 1 {
+		t.Errorf("expect one of the waiting requests to share the connection, got %d", concurrent)
+	}
+}
+
+func TestResult_StreamReleasedOnce(t *testing.T) {
+	c, _ := net.Pipe()
+	var r Result
+	r.acquireStream(c)
+	cp := r
+	if n := cp.acquireStream(c); n != 0 {
+		t.Fatalf("expect the copy to take over the stream, got %d others", n)
+	}
+	r.Reset()
+	cp.Reset()
+
+	streamsMu.Lock()
+	defer streamsMu.Unlock()
+	if n, ok := streams[c]; ok {
+		t.Errorf("expect no requests in flight, got %d", n)
+	}
+}
@@ -0,0 +1,171 @@
+package httpstat
+
+import (
+	"bufio"
+	"fmt"
+	"html"
+	"io"
+	"time"
+)
+
+// svgColors are the colors of the phases in SVG waterfalls.
+var svgColors = [...]string{
+	PhaseDNS:          "#4e79a7",
+	PhaseConnect:      "#f28e2b",
+	PhaseProxyConnect: "#b07aa1",
+	PhaseTLS:          "#e15759",
+	PhaseContinueWait: "#9c755f",
+	PhaseServer:       "#76b7b2",
+	PhaseTransfer:     "#59a14f",
+}
+
+// RenderSVG draws the timeline of the request as an SVG waterfall, with
+// one bar per phase proportional to its duration, e.g. to embed it in a
+// report or dashboard.
+func (r *Result) RenderSVG(w io.Writer) error {
+	var wf Waterfall
+	wf.Add("", r)
+	_, err := wf.WriteTo(w)
+	return err
+}
+
+// Waterfall draws the timelines of many requests as an SVG waterfall, one
+// row per request on a common time axis, so requests sent concurrently or
+// one after another can be seen side by side.
+type Waterfall struct {
+	// Width is the width of the graphic in pixels. If zero, 800 is used.
+	Width int
+
+	rows []waterfallRow
+}
+
+type waterfallRow struct {
+	label  string
+	result *Result
+}
+
+// Add adds a row for r. If label is not empty, it is shown in front of the
+// row, which tells requests apart.
+func (wf *Waterfall) Add(label string, r *Result) {
+	wf.rows = append(wf.rows, waterfallRow{label: label, result: r})
+}
+
+const (
+	svgRowHeight = 20
+	svgBarHeight = 14
+	svgAxis      = 24
+	svgMargin    = 10
+	svgLegend    = 24
+)
+
+// WriteTo writes the SVG document to w.
+func (wf *Waterfall) WriteTo(w io.Writer) (int64, error) {
+	width := wf.Width
+	if width <= 0 {
+		width = 800
+	}
+	labelWidth := 0
+	for _, row := range wf.rows {
+		if n := 7*len(row.label) + svgMargin; row.label != "" && n > labelWidth {
+			labelWidth = n
+		}
+	}
+	if labelWidth > width/3 {
+		labelWidth = width / 3
+	}
+
+	// The rows share a time axis from the start of the first request to
+	// the end of the last one. Results without timestamps start at zero.
+	var t0 time.Time
+	for _, row := range wf.rows {
+		if s := row.result.dnsStart; !s.IsZero() && (t0.IsZero() || s.Before(t0)) {
+			t0 = s
+		}
+	}
+	start := func(r *Result) time.Duration {
+		if r.dnsStart.IsZero() || t0.IsZero() {
+			return 0
+		}
+		return r.dnsStart.Sub(t0)
+	}
+	var span time.Duration
+	for _, row := range wf.rows {
+		if end := start(row.result) + timelineLength(row.result); end > span {
+			span = end
+		}
+	}
+
+	plot := float64(width - labelWidth - 2*svgMargin)
+	x := func(d time.Duration) float64 {
+		if span <= 0 {
+			return float64(labelWidth + svgMargin)
+		}
+		return float64(labelWidth+svgMargin) + plot*float64(d)/float64(span)
+	}
+	height := svgAxis + len(wf.rows)*svgRowHeight + svgLegend + svgMargin
+
+	cw := &countWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	fmt.Fprintf(bw, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="11">`+"\n", width, height, width, height)
+
+	// Time axis with five ticks.
+	for i := 0; i <= 4; i++ {
+		d := span * time.Duration(i) / 4
+		tx := x(d)
+		fmt.Fprintf(bw, `<line x1="%.1f" x2="%.1f" y1="%d" y2="%d" stroke="#ddd"/>`+"\n", tx, tx, svgAxis-6, svgAxis+len(wf.rows)*svgRowHeight)
+		anchor := "middle"
+		switch i {
+		case 0:
+			anchor = "start"
+		case 4:
+			anchor = "end"
+		}
+		fmt.Fprintf(bw, `<text x="%.1f" y="%d" text-anchor="%s" fill="#555">%s</text>`+"\n", tx, svgAxis-10, anchor, svgDuration(d))
+	}
+
+	for i, row := range wf.rows {
+		y := svgAxis + i*svgRowHeight
+		if row.label != "" {
+			fmt.Fprintf(bw, `<text x="%d" y="%d" fill="#222">%s</text>`+"\n", svgMargin, y+svgBarHeight-3, html.EscapeString(row.label))
+		}
+		offset := start(row.result)
+		for _, pt := range row.result.Phases() {
+			if pt.Skipped || pt.Duration <= 0 {
+				continue
+			}
+			x0, x1 := x(offset+pt.StartOffset), x(offset+pt.StartOffset+pt.Duration)
+			fmt.Fprintf(bw, `<rect x="%.1f" y="%d" width="%.1f" height="%d" fill="%s"><title>%s: %s</title></rect>`+"\n",
+				x0, y+(svgRowHeight-svgBarHeight)/2, x1-x0, svgBarHeight, svgColors[pt.Phase], pt.Name, svgDuration(pt.Duration))
+		}
+	}
+
+	// Legend of the phases.
+	lx, ly := svgMargin, height-svgMargin-4
+	for p := PhaseDNS; p <= PhaseTransfer; p++ {
+		fmt.Fprintf(bw, `<rect x="%d" y="%d" width="10" height="10" fill="%s"/><text x="%d" y="%d" fill="#555">%s</text>`+"\n",
+			lx, ly-9, svgColors[p], lx+14, ly, p)
+		lx += 14 + 7*len(p.String()) + 12
+	}
+	bw.WriteString("</svg>\n")
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// timelineLength returns the duration from the start of r to its end, or
+// to the end of the last phase if End was not called yet.
+func timelineLength(r *Result) time.Duration {
+	if t := r.Total(); t > 0 {
+		return t
+	}
+	var end time.Duration
+	for _, pt := range r.Phases() {
+		if !pt.Skipped && pt.StartOffset+pt.Duration > end {
+			end = pt.StartOffset + pt.Duration
+		}
+	}
+	return end
+}
+
+func svgDuration(d time.Duration) string {
+	return html.EscapeString(d.Round(10 * time.Microsecond).String())
+}
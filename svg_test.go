@@ -0,0 +1,56 @@
+package httpstat
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResult_RenderSVG(t *testing.T) {
+	r := NewResultFromPhases(map[Phase]time.Duration{
+		PhaseDNS:      10 * time.Millisecond,
+		PhaseConnect:  10 * time.Millisecond,
+		PhaseServer:   60 * time.Millisecond,
+		PhaseTransfer: 20 * time.Millisecond,
+	})
+	var buf bytes.Buffer
+	if err := r.RenderSVG(&buf); err != nil {
+		t.Fatal("RenderSVG failed:", err)
+	}
+	if err := xml.Unmarshal(buf.Bytes(), new(struct{})); err != nil {
+		t.Fatalf("invalid SVG: %v\n%s", err, buf.String())
+	}
+	// The plot is 780 pixels wide, of which the server processing takes
+	// 60%, starting after 20%.
+	if !strings.Contains(buf.String(), `<rect x="166.0" y="27" width="468.0" height="14" fill="#76b7b2"><title>Server processing: 60ms</title></rect>`) {
+		t.Fatalf("expect a proportional server processing bar in:\n%s", buf.String())
+	}
+}
+
+func TestWaterfall(t *testing.T) {
+	start := time.Now()
+	first := NewResultBuilder().Start(start).Phase(PhaseServer, 50*time.Millisecond).Build()
+	second := NewResultBuilder().Start(start.Add(50*time.Millisecond)).Phase(PhaseServer, 50*time.Millisecond).Build()
+
+	wf := Waterfall{Width: 420}
+	wf.Add("GET /a & b", first)
+	wf.Add("GET /c", second)
+	var buf bytes.Buffer
+	if _, err := wf.WriteTo(&buf); err != nil {
+		t.Fatal("WriteTo failed:", err)
+	}
+	svg := buf.String()
+	if err := xml.Unmarshal(buf.Bytes(), new(struct{})); err != nil {
+		t.Fatalf("invalid SVG: %v\n%s", err, svg)
+	}
+	if !strings.Contains(svg, "GET /a &amp; b") || !strings.Contains(svg, ">100ms</text>") {
+		t.Fatalf("expect the labels and a common time axis in:\n%s", svg)
+	}
+	// The second request starts where the first ends, in the middle of
+	// the plot.
+	if n := strings.Count(svg, `fill="#76b7b2"><title>`); n != 2 {
+		t.Fatalf("expect a bar per request, got %d", n)
+	}
+}
@@ -0,0 +1,67 @@
+package httpstat
+
+import (
+	"time"
+)
+
+// TimeoutReport attributes the time a request used of its timeout (e.g.
+// http.Client.Timeout) to its phases, for tuning per-phase timeouts such
+// as http.Transport.TLSHandshakeTimeout.
+type TimeoutReport struct {
+	Timeout time.Duration
+
+	// Phases are the phases which happened, in order. A phase which was in
+	// progress when the request failed lasted until the failure.
+	Phases []TimeoutShare
+
+	// Used is the time from the start of the request until it completed or
+	// failed, and Remaining the part of the timeout which was left. Used
+	// may exceed the timeout, in which case Remaining is zero.
+	Used      time.Duration
+	Remaining time.Duration
+}
+
+// TimeoutShare is the part of a timeout used by a phase.
+type TimeoutShare struct {
+	Phase    Phase
+	Duration time.Duration
+
+	// Fraction is Duration as a fraction of the timeout.
+	Fraction float64
+}
+
+// TimeoutReport returns how much of timeout each phase of the request used
+// and how much of it remained. The Result may be incomplete.
+func (r *Result) TimeoutReport(timeout time.Duration) TimeoutReport {
+	// The hooks may still record into r, so the report is built from a
+	// consistent copy.
+	r = r.snapshot()
+	rep := TimeoutReport{Timeout: timeout}
+	failedPhase, failed := r.FailedPhase()
+
+	for _, p := range r.Phases() {
+		d := p.Duration
+		if failed && p.Phase == failedPhase && d <= 0 && !r.failedAt.IsZero() && !r.dnsStart.IsZero() {
+			d = r.failedAt.Sub(r.dnsStart) - p.StartOffset
+		}
+		if d <= 0 {
+			continue
+		}
+		share := TimeoutShare{Phase: p.Phase, Duration: d}
+		if timeout > 0 {
+			share.Fraction = float64(d) / float64(timeout)
+		}
+		rep.Phases = append(rep.Phases, share)
+		if end := p.StartOffset + d; end > rep.Used {
+			rep.Used = end
+		}
+	}
+	if r.total > 0 {
+		rep.Used = r.total
+	}
+
+	if rep.Remaining = timeout - rep.Used; rep.Remaining < 0 {
+		rep.Remaining = 0
+	}
+	return rep
+}
@@ -0,0 +1,66 @@
+package httpstat
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestResult_TimeoutReport(t *testing.T) {
+	ms := time.Millisecond
+	r := NewResultFromPhases(map[Phase]time.Duration{
+		PhaseDNS:      100 * ms,
+		PhaseConnect:  100 * ms,
+		PhaseServer:   300 * ms,
+		PhaseTransfer: 100 * ms,
+	})
+
+	rep := r.TimeoutReport(time.Second)
+	if rep.Used != 600*ms || rep.Remaining != 400*ms {
+		t.Fatalf("Used = %v, Remaining = %v, want 600ms and 400ms", rep.Used, rep.Remaining)
+	}
+	if len(rep.Phases) != 4 {
+		t.Fatalf("expect 4 phases, got %+v", rep.Phases)
+	}
+	if s := rep.Phases[2]; s.Phase != PhaseServer || s.Fraction != 0.3 {
+		t.Fatalf("unexpected server share %+v", s)
+	}
+
+	if rep := r.TimeoutReport(500 * ms); rep.Remaining != 0 {
+		t.Fatalf("expect nothing to remain of an exceeded timeout, got %v", rep.Remaining)
+	}
+}
+
+func TestResult_TimeoutReportFailed(t *testing.T) {
+	// A server which accepts connections but never completes a handshake.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal("Listen failed:", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	const timeout = 100 * time.Millisecond
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	r, _, _ := failedPhase(t, ctx, &http.Transport{}, "https://"+ln.Addr().String())
+
+	rep := r.TimeoutReport(timeout)
+	last := rep.Phases[len(rep.Phases)-1]
+	if last.Phase != PhaseTLS || last.Duration < 80*time.Millisecond {
+		t.Fatalf("expect the handshake to use up the timeout, got %+v", rep.Phases)
+	}
+	if rep.Remaining > 20*time.Millisecond {
+		t.Fatalf("expect little of the timeout to remain, got %v", rep.Remaining)
+	}
+}
@@ -0,0 +1,78 @@
+package httpstat
+
+import (
+	"time"
+)
+
+// The following return the times at which the phases of the request
+// started and ended, e.g. to correlate them with external logs. They return
+// the zero time for phases which did not happen. For a reused connection,
+// DNSStartAt, ConnectStartAt and TLSStartAt are the time the request was
+// written.
+
+// DNSStartAt returns the time the DNS lookup started, which is the start
+// of the request.
+func (r *Result) DNSStartAt() time.Time {
+	return r.dnsStart
+}
+
+// DNSDoneAt returns the time the DNS lookup was done.
+func (r *Result) DNSDoneAt() time.Time {
+	return addIfSet(r.dnsStart, r.DNSLookup)
+}
+
+// ConnectStartAt returns the time the TCP connection was started.
+func (r *Result) ConnectStartAt() time.Time {
+	return r.tcpStart
+}
+
+// ConnectDoneAt returns the time the TCP connection was established.
+func (r *Result) ConnectDoneAt() time.Time {
+	return r.tcpDone
+}
+
+// TLSStartAt returns the time the TLS handshake started.
+func (r *Result) TLSStartAt() time.Time {
+	return r.tlsStart
+}
+
+// TLSDoneAt returns the time the TLS handshake was done.
+func (r *Result) TLSDoneAt() time.Time {
+	return addIfSet(r.tlsStart, r.TLSHandshake)
+}
+
+// RequestWrittenAt returns the time the request was written.
+func (r *Result) RequestWrittenAt() time.Time {
+	return r.serverStart
+}
+
+// FirstByteAt returns the time the first byte of the response was
+// received.
+func (r *Result) FirstByteAt() time.Time {
+	return r.serverDone
+}
+
+// LastByteAt returns the time the last byte of the response body was read
+// through the body wrapper.
+func (r *Result) LastByteAt() time.Time {
+	return r.lastByte
+}
+
+// TrailersAt returns the time the trailers of the response were received,
+// which is the time the body wrapper read the end of the body. It returns
+// the zero time for responses without trailers.
+func (r *Result) TrailersAt() time.Time {
+	return r.trailersAt
+}
+
+// EndAt returns the time passed to End.
+func (r *Result) EndAt() time.Time {
+	return addIfSet(r.dnsStart, r.total)
+}
+
+func addIfSet(t time.Time, d time.Duration) time.Time {
+	if t.IsZero() || d <= 0 {
+		return time.Time{}
+	}
+	return t.Add(d)
+}
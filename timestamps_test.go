@@ -0,0 +1,52 @@
+package httpstat
+
+import (
+	"testing"
+	"time"
+)
+
+func TestResult_Timestamps(t *testing.T) {
+	start := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	ms := time.Millisecond
+	r := NewResultBuilder().
+		Start(start).
+		Phase(PhaseDNS, 10*ms).
+		Phase(PhaseConnect, 20*ms).
+		Phase(PhaseTLS, 30*ms).
+		Phase(PhaseServer, 40*ms).
+		Phase(PhaseTransfer, 50*ms).
+		Build()
+
+	cases := []struct {
+		name string
+		got  time.Time
+		want time.Duration
+	}{
+		{"DNSStartAt", r.DNSStartAt(), 0},
+		{"DNSDoneAt", r.DNSDoneAt(), 10 * ms},
+		{"ConnectStartAt", r.ConnectStartAt(), 10 * ms},
+		{"ConnectDoneAt", r.ConnectDoneAt(), 30 * ms},
+		{"TLSStartAt", r.TLSStartAt(), 30 * ms},
+		{"TLSDoneAt", r.TLSDoneAt(), 60 * ms},
+		{"RequestWrittenAt", r.RequestWrittenAt(), 60 * ms},
+		{"FirstByteAt", r.FirstByteAt(), 100 * ms},
+		{"EndAt", r.EndAt(), 150 * ms},
+	}
+	for _, c := range cases {
+		if want := start.Add(c.want); !c.got.Equal(want) {
+			t.Errorf("%s = %v, want %v", c.name, c.got, want)
+		}
+	}
+}
+
+func TestResult_TimestampsSkipped(t *testing.T) {
+	r := NewResultFromPhases(map[Phase]time.Duration{PhaseServer: time.Millisecond})
+	if !r.DNSDoneAt().IsZero() || !r.TLSDoneAt().IsZero() {
+		t.Fatal("expect skipped phases to have zero times")
+	}
+
+	var empty Result
+	if !empty.EndAt().IsZero() || !empty.FirstByteAt().IsZero() {
+		t.Fatal("expect an empty Result to have zero times")
+	}
+}
@@ -0,0 +1,56 @@
+package httpstat
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBody_TrailerWait(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/trailers" {
+			w.Header().Set("Trailer", "Grpc-Status")
+		}
+		io.WriteString(w, "message")
+		w.(http.Flusher).Flush()
+		time.Sleep(50 * time.Millisecond)
+		if r.URL.Path == "/trailers" {
+			w.Header().Set("Grpc-Status", "0")
+		}
+	}))
+	defer ts.Close()
+
+	get := func(path string) *Result {
+		var result Result
+		res, err := DefaultClient().Do(NewRequest(t, ts.URL+path, &result))
+		if err != nil {
+			t.Fatal(err)
+		}
+		b := Body(res, &result)
+		io.Copy(io.Discard, b)
+		b.Close()
+		return &result
+	}
+
+	r := get("/trailers")
+	if r.TrailerWait < 40*time.Millisecond {
+		t.Fatalf("TrailerWait = %v, want at least 40ms", r.TrailerWait)
+	}
+	if r.LastByteAt().IsZero() || !r.TrailersAt().Equal(r.LastByteAt().Add(r.TrailerWait)) {
+		t.Fatalf("unexpected LastByteAt %v and TrailersAt %v", r.LastByteAt(), r.TrailersAt())
+	}
+	var names []string
+	for _, e := range r.Events() {
+		names = append(names, e.Name)
+	}
+	if n := len(names); n < 4 || names[n-4] != "Last body byte" || names[n-3] != "Trailers received" {
+		t.Fatalf("unexpected events %v", names)
+	}
+
+	r = get("/plain")
+	if r.TrailerWait != 0 || !r.TrailersAt().IsZero() || r.LastByteAt().IsZero() {
+		t.Fatalf("expect no trailer wait without trailers, got %v at %v", r.TrailerWait, r.TrailersAt())
+	}
+}
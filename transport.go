@@ -0,0 +1,260 @@
+package httpstat
+
+import (
+	"net/http"
+	"runtime/pprof"
+	"runtime/trace"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Transport is an http.RoundTripper which measures each request it sends
+// with a fresh Result. Unlike WithHTTPStat, it is safe to send several
+// concurrent requests sharing one context through it.
+type Transport struct {
+	// Base is the RoundTripper used to send the requests. If nil,
+	// http.DefaultTransport is used.
+	Base http.RoundTripper
+
+	// OnResult is called with the Result of each request once End was
+	// called on it, i.e. when the response body was read to the end or
+	// closed. If the request failed, it is called with the error and the
+	// partial Result.
+	OnResult func(req *http.Request, r *Result, err error)
+
+	// Observer, if not nil, is registered with the Result of each request.
+	Observer Observer
+
+	// Sample, if not nil, decides which requests are measured, e.g. with
+	// SampleRate, so only a fraction of the requests in hot paths pay for
+	// the tracing. Other requests are sent through Base as they are, and
+	// none of the callbacks are called for them.
+	Sample func(req *http.Request) bool
+
+	// ProgressInterval, if positive, makes the response bodies report their
+	// progress to the Observer at most once per interval, see WithProgress.
+	ProgressInterval time.Duration
+
+	// StallWindow, if positive, flags the Results of responses whose body
+	// stalled for longer than StallWindow, see WithStallDetection.
+	StallWindow time.Duration
+
+	// OnStall, if not nil, is called as soon as the body of the response
+	// to req stalls. It is called from another goroutine.
+	OnStall func(req *http.Request, r *Result)
+
+	// StreamEvents makes the response bodies record the times the events
+	// of streamed responses arrive, see WithStreamEvents.
+	StreamEvents bool
+
+	// Decompress makes the Transport ask for gzip encoded responses and
+	// decompress them itself, like http.Transport does, so the time spent
+	// decompressing is recorded in Result.Decompression apart from the
+	// network transfer. Requests with their own Accept-Encoding header are
+	// left alone.
+	Decompress bool
+
+	// RecordMetadata records the Metadata of each request which got a
+	// response into its Result.
+	RecordMetadata bool
+
+	// Hooks selects the httptrace hooks registered for each request, see
+	// WithHTTPStatHooks. If zero, AllHooks is used.
+	Hooks Hooks
+
+	// PprofLabels sets the pprof label "phase" on the goroutines running
+	// the phases of each request, so CPU and block profiles of services
+	// sending many requests can be sliced by phase: "dns", "connect" and
+	// "tls" while dialing, "wait" while sending the request and waiting for
+	// the response, and "transfer" while reading the body. Other labels are
+	// taken from the context of the request, and restored at the end of
+	// each phase, so they should match the labels of the calling goroutine,
+	// as set by pprof.Do.
+	PprofLabels bool
+
+	// RuntimeTrace traces each request as a runtime/trace task with a
+	// region for each phase, so go tool trace shows the phases next to the
+	// scheduling of the goroutines. It only has an effect while a trace is
+	// being recorded.
+	RuntimeTrace bool
+
+	// Recorder, if not nil, records the Result of each request once
+	// OnResult would be called.
+	Recorder *Recorder
+
+	// Sink, if not nil, is written the Record of each request once
+	// OnResult would be called. Errors of the Sink are ignored.
+	Sink Sink
+
+	// Registry, if not nil, aggregates the Result of each request which
+	// got a response, once OnResult would be called.
+	Registry *Registry
+
+	// ResultsBuffer is the capacity of the channel returned by Results. If
+	// zero, 100 is used.
+	ResultsBuffer int
+
+	resultsMu sync.Mutex
+	results   chan *Result
+	dropped   atomic.Uint64
+}
+
+// Results returns a channel receiving the Result of each request once
+// OnResult would be called, so measurements can be processed in another
+// goroutine without a callback. Only requests completing after the first
+// call of Results are sent. The channel is buffered with ResultsBuffer; if
+// it is full, Results are dropped rather than holding up the requests, and
+// counted by Dropped. The channel is never closed.
+func (t *Transport) Results() <-chan *Result {
+	t.resultsMu.Lock()
+	defer t.resultsMu.Unlock()
+	if t.results == nil {
+		n := t.ResultsBuffer
+		if n <= 0 {
+			n = 100
+		}
+		t.results = make(chan *Result, n)
+	}
+	return t.results
+}
+
+// Dropped returns the number of Results which were not sent to the channel
+// returned by Results as it was full.
+func (t *Transport) Dropped() uint64 {
+	return t.dropped.Load()
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if t.Sample != nil && !t.Sample(req) {
+		return base.RoundTrip(req)
+	}
+
+	start := time.Now()
+	hooks := t.Hooks
+	if hooks == 0 {
+		hooks = AllHooks
+	}
+	r := &Result{observer: t.Observer}
+	if set, ok := AttemptsFromContext(req.Context()); ok {
+		set.add(r, start)
+	}
+	var task *requestTask
+	ctx := req.Context()
+	if t.RuntimeTrace && trace.IsEnabled() {
+		task = newRequestTask(req)
+		ctx = task.withHooks(task.ctx)
+	}
+	ctx = WithHTTPStatHooks(ctx, r, hooks)
+	if t.PprofLabels {
+		ctx = withPprofLabels(ctx, req.Context())
+	}
+	out := req.WithContext(ctx)
+	askedGzip := t.Decompress && req.Header.Get("Accept-Encoding") == "" && req.Method != "HEAD"
+	if askedGzip {
+		out.Header = req.Header.Clone()
+		out.Header.Set("Accept-Encoding", "gzip")
+	}
+	res, err := base.RoundTrip(out)
+	if err == nil {
+		r.gotResponse(time.Now())
+	}
+	task.endWait()
+	if ht, ok := base.(*http.Transport); ok && ht.TLSClientConfig != nil {
+		// The http.Transport adds "h2" to its TLSClientConfig on the
+		// first request if HTTP/2 is enabled, so it is only read after it.
+		r.SetOfferedProtocols(ht.TLSClientConfig.NextProtos)
+	}
+	if t.PprofLabels {
+		pprof.SetGoroutineLabels(req.Context())
+	}
+	if err != nil {
+		r.abort()
+		task.end()
+		t.deliver(req, start, 0, r, err)
+		return nil, err
+	}
+
+	if askedGzip && res.Header.Get("Content-Encoding") == "gzip" {
+		decompress(res, r)
+	}
+	if t.RecordMetadata {
+		r.RecordResponse(res)
+	}
+	if res.StatusCode == http.StatusSwitchingProtocols {
+		// The body is the upgraded connection, e.g. of a WebSocket, which
+		// must stay an io.ReadWriteCloser. The request ends with the
+		// response.
+		r.EndNow()
+		task.end()
+		t.deliver(req, start, res.StatusCode, r, nil)
+		return res, nil
+	}
+
+	b := newBody(res, r)
+	b.progressInterval = t.ProgressInterval
+	if t.PprofLabels {
+		b.labels, b.transferLabels = req.Context(), transferLabels(req.Context())
+	}
+	if t.StreamEvents {
+		WithStreamEvents()(b)
+	}
+	if t.StallWindow > 0 {
+		var onStall func(r *Result)
+		if t.OnStall != nil {
+			onStall = func(r *Result) { t.OnStall(req, r) }
+		}
+		WithStallDetection(t.StallWindow, onStall)(b)
+	}
+	b.task = task
+	b.onDone = func() {
+		task.end()
+		t.deliver(req, start, res.StatusCode, r, nil)
+	}
+	res.Body = b
+	return res, nil
+}
+
+func (t *Transport) deliver(req *http.Request, start time.Time, status int, r *Result, err error) {
+	if t.Recorder != nil || t.Sink != nil {
+		rec := Record{
+			Time:       start,
+			Method:     req.Method,
+			URL:        req.URL.Redacted(),
+			StatusCode: status,
+			Err:        err,
+			Result:     r,
+		}
+		if t.Recorder != nil {
+			t.Recorder.Add(rec)
+		}
+		if t.Sink != nil {
+			t.Sink.WriteRecord(rec)
+		}
+	}
+	if t.Registry != nil && err == nil {
+		t.Registry.Add(req, r)
+	}
+	if set, ok := AttemptsFromContext(req.Context()); ok {
+		set.Finish(r, status, err)
+	}
+	if t.OnResult != nil {
+		t.OnResult(req, r, err)
+	}
+
+	t.resultsMu.Lock()
+	results := t.results
+	t.resultsMu.Unlock()
+	if results != nil {
+		select {
+		case results <- r:
+		default:
+			t.dropped.Add(1)
+		}
+	}
+}
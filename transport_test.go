@@ -0,0 +1,128 @@
+package httpstat
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTransport(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		d, _ := strconv.Atoi(r.URL.Query().Get("sleep"))
+		time.Sleep(time.Duration(d) * time.Millisecond)
+		io.WriteString(w, "ok")
+	}))
+	defer ts.Close()
+
+	var mu sync.Mutex
+	results := make(map[string]*Result)
+	client := &http.Client{
+		Transport: &Transport{
+			Base: DefaultTransport(),
+			OnResult: func(req *http.Request, r *Result, err error) {
+				if err != nil {
+					t.Error("request failed:", err)
+				}
+				mu.Lock()
+				results[req.URL.Query().Get("sleep")] = r
+				mu.Unlock()
+			},
+		},
+	}
+
+	// All requests share one context.
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for _, sleep := range []string{"10", "50", "100"} {
+		wg.Add(1)
+		go func(sleep string) {
+			defer wg.Done()
+			req, err := http.NewRequestWithContext(ctx, "GET", ts.URL+"?sleep="+sleep, nil)
+			if err != nil {
+				t.Error("NewRequest failed:", err)
+				return
+			}
+			res, err := client.Do(req)
+			if err != nil {
+				t.Error("client.Do failed:", err)
+				return
+			}
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}(sleep)
+	}
+	wg.Wait()
+
+	if len(results) != 3 {
+		t.Fatalf("expect 3 results, got %d", len(results))
+	}
+	for sleep, r := range results {
+		d, _ := strconv.Atoi(sleep)
+		want := time.Duration(d) * time.Millisecond
+		if r.ServerProcessing < want {
+			t.Fatalf("ServerProcessing of sleep=%s = %v, want at least %v", sleep, r.ServerProcessing, want)
+		}
+		if r.Total() < r.ServerProcessing {
+			t.Fatalf("expect End to be called, Total = %v", r.Total())
+		}
+	}
+	if results["10"].ServerProcessing >= results["100"].ServerProcessing {
+		t.Fatal("expect each request to be measured into its own Result")
+	}
+}
+
+func TestTransport_Error(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	ts.Close()
+
+	var gotErr error
+	client := &http.Client{
+		Transport: &Transport{
+			OnResult: func(_ *http.Request, _ *Result, err error) {
+				gotErr = err
+			},
+		},
+	}
+	if _, err := client.Get(ts.URL); err == nil {
+		t.Fatal("expect request to a closed server to fail")
+	}
+	if gotErr == nil {
+		t.Fatal("expect OnResult to be called with the error")
+	}
+}
+
+func TestTransport_Results(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	defer ts.Close()
+
+	transport := &Transport{Base: DefaultTransport(), ResultsBuffer: 2}
+	results := transport.Results()
+	client := &http.Client{Transport: transport}
+	for i := 0; i < 3; i++ {
+		res, err := client.Get(ts.URL)
+		if err != nil {
+			t.Fatal("client.Get failed:", err)
+		}
+		io.Copy(io.Discard, res.Body)
+		res.Body.Close()
+	}
+
+	for i := 0; i < 2; i++ {
+		if r := <-results; !r.IsComplete() {
+			t.Fatalf("expect a completed Result, got %+v", r)
+		}
+	}
+	if n := transport.Dropped(); n != 1 {
+		t.Fatalf("Dropped = %d, want 1", n)
+	}
+	if results != transport.Results() {
+		t.Fatal("expect Results to return the same channel")
+	}
+}
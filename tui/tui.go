@@ -0,0 +1,195 @@
+// Package tui provides a terminal dashboard of go-httpstat Results, built on
+// bubbletea (https://github.com/charmbracelet/bubbletea).
+//
+// The dashboard consumes a channel of Results, e.g. of requests sent in a
+// loop, and shows a sparkline of the recent durations and the percentiles
+// of each phase. It is the building block of watch modes:
+//
+//	results := make(chan *httpstat.Result)
+//	go func() {
+//		defer close(results)
+//		for ctx.Err() == nil {
+//			results <- measure(ctx, url)
+//		}
+//	}()
+//	err := tui.Run(ctx, "GET "+url, results)
+//
+// Model can also be embedded in larger bubbletea programs.
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jakobilobi/go-httpstat"
+)
+
+// Run shows the dashboard of results titled title in the terminal until
+// the user quits with q or ctrl+c, or ctx is done.
+func Run(ctx context.Context, title string, results <-chan *httpstat.Result) error {
+	m := New(results)
+	m.Title = title
+	_, err := tea.NewProgram(m, tea.WithContext(ctx)).Run()
+	if err == tea.ErrProgramKilled && ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// ResultMsg is the message by which Model receives a Result. Programs
+// embedding Model without a channel can send it themselves.
+type ResultMsg struct {
+	Result *httpstat.Result
+}
+
+// doneMsg is sent once the channel of Results is closed.
+type doneMsg struct{}
+
+// sparks are the bars of the sparklines, from lowest to highest.
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// Model is the bubbletea model of the dashboard.
+type Model struct {
+	// Title is shown above the dashboard.
+	Title string
+
+	// Width is the number of recent Results shown by the sparklines. If
+	// zero, 40 is used.
+	Width int
+
+	results <-chan *httpstat.Result
+	agg     *httpstat.Aggregator
+	history [httpstat.PhaseTransfer + 2][]time.Duration // phases and total
+	failed  int
+	last    time.Time
+	done    bool
+}
+
+// New returns a Model consuming results, which may be nil if Results are
+// sent as ResultMsg. The percentiles are those of the last 1000 successful
+// Results.
+func New(results <-chan *httpstat.Result) *Model {
+	return &Model{
+		results: results,
+		agg:     &httpstat.Aggregator{Window: 1000},
+	}
+}
+
+// Init implements tea.Model.
+func (m *Model) Init() tea.Cmd {
+	return m.next()
+}
+
+// next returns the command waiting for the next Result.
+func (m *Model) next() tea.Cmd {
+	if m.results == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		r, ok := <-m.results
+		if !ok {
+			return doneMsg{}
+		}
+		return ResultMsg{Result: r}
+	}
+}
+
+// Update implements tea.Model.
+func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case ResultMsg:
+		m.add(msg.Result)
+		return m, m.next()
+	case doneMsg:
+		m.done = true
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "ctrl+c", "esc":
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+func (m *Model) add(r *httpstat.Result) {
+	if r == nil {
+		return
+	}
+	m.last = time.Now()
+	// A failed Result has no total, so it is only counted.
+	if _, failed := r.FailedPhase(); failed {
+		m.failed++
+		return
+	}
+	m.agg.Add(r)
+	width := m.Width
+	if width <= 0 {
+		width = 40
+	}
+	for i := range m.history {
+		d := r.Total()
+		if p := httpstat.Phase(i); p <= httpstat.PhaseTransfer {
+			d = r.Duration(p)
+		}
+		h := append(m.history[i], d)
+		if len(h) > width {
+			h = h[len(h)-width:]
+		}
+		m.history[i] = h
+	}
+}
+
+// View implements tea.Model.
+func (m *Model) View() string {
+	var b strings.Builder
+	if m.Title != "" {
+		b.WriteString(m.Title)
+		b.WriteString("\n\n")
+	}
+	fmt.Fprintf(&b, "Requests: %d  Failed: %d", m.agg.Count()+m.failed, m.failed)
+	if m.done {
+		b.WriteString("  (done)")
+	} else if !m.last.IsZero() {
+		fmt.Fprintf(&b, "  Last: %s", m.last.Format("15:04:05"))
+	}
+	b.WriteString("\n\n")
+
+	tw := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "Phase\tRecent\tp50\tp90\tp99\tMax\t")
+	row := func(name string, s httpstat.Stats, history []time.Duration) {
+		fmt.Fprintf(tw, "%s\t%s\t%v\t%v\t%v\t%v\t\n", name, sparkline(history), s.P50, s.P90, s.P99, s.Max)
+	}
+	for p := httpstat.PhaseDNS; p <= httpstat.PhaseTransfer; p++ {
+		if s := m.agg.Phase(p); s.Count > 0 {
+			row(p.String(), s, m.history[p])
+		}
+	}
+	row("Total", m.agg.Total(), m.history[len(m.history)-1])
+	tw.Flush()
+
+	b.WriteString("\nq: quit\n")
+	return b.String()
+}
+
+// sparkline returns a bar per duration, scaled to the longest one.
+func sparkline(durations []time.Duration) string {
+	var max time.Duration
+	for _, d := range durations {
+		if d > max {
+			max = d
+		}
+	}
+	line := make([]rune, len(durations))
+	for i, d := range durations {
+		n := 0
+		if max > 0 {
+			n = int(int64(len(sparks)-1) * int64(d) / int64(max))
+		}
+		line[i] = sparks[n]
+	}
+	return string(line)
+}
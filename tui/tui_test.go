@@ -0,0 +1,58 @@
+package tui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jakobilobi/go-httpstat"
+)
+
+func TestModel(t *testing.T) {
+	results := make(chan *httpstat.Result, 4)
+	for _, d := range []time.Duration{10, 20, 40} {
+		results <- httpstat.NewResultFromPhases(map[httpstat.Phase]time.Duration{
+			httpstat.PhaseServer: d * time.Millisecond,
+		})
+	}
+	// A Result which was never ended counts as failed, and its Total must
+	// not skew the percentiles or the sparklines.
+	results <- &httpstat.Result{}
+	close(results)
+
+	m := New(results)
+	m.Title = "GET https://example.com"
+	cmd := m.Init()
+	for cmd != nil {
+		_, cmd = m.Update(cmd())
+	}
+
+	view := m.View()
+	for _, want := range []string{
+		"GET https://example.com",
+		"Requests: 4  Failed: 1  (done)",
+		"Server processing  ▂▄█",
+		"Total              ▂▄█     20ms  40ms  40ms  40ms",
+	} {
+		if !strings.Contains(view, want) {
+			t.Errorf("expect %q in view:\n%s", want, view)
+		}
+	}
+	if strings.Contains(view, "DNS lookup") {
+		t.Errorf("expect phases without durations to be left out:\n%s", view)
+	}
+
+	if _, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")}); cmd == nil || cmd() != tea.Quit() {
+		t.Fatal("expect q to quit")
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	if got, want := sparkline([]time.Duration{0, 1, 7}), "▁▂█"; got != want {
+		t.Fatalf("sparkline = %q, want %q", got, want)
+	}
+	if got := sparkline(nil); got != "" {
+		t.Fatalf("sparkline of no durations = %q, want empty", got)
+	}
+}
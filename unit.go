@@ -0,0 +1,110 @@
+package httpstat
+
+import (
+	"strconv"
+	"time"
+)
+
+// Unit is the unit in which the durations of a Result are formatted.
+type Unit int
+
+const (
+	// UnitMillisecond formats durations as whole milliseconds, e.g. "12 ms".
+	// It is the default.
+	UnitMillisecond Unit = iota
+
+	// UnitMicrosecond formats durations as whole microseconds, e.g.
+	// "834 µs".
+	UnitMicrosecond
+
+	// UnitNanosecond formats durations as nanoseconds, e.g. "834125 ns".
+	UnitNanosecond
+
+	// UnitAuto picks a unit per duration and keeps three significant
+	// digits, e.g. "834µs" or "1.24s".
+	UnitAuto
+)
+
+// SetUnit sets the unit in which Format prints the durations of r.
+func (r *Result) SetUnit(u Unit) {
+	r.unit = u
+}
+
+// appendTo appends d in unit u to dst.
+func (u Unit) appendTo(dst []byte, d time.Duration) []byte {
+	switch u {
+	case UnitMicrosecond:
+		return append(strconv.AppendInt(dst, int64(d/time.Microsecond), 10), " µs"...)
+	case UnitNanosecond:
+		return append(strconv.AppendInt(dst, int64(d), 10), " ns"...)
+	case UnitAuto:
+		return appendHuman(dst, d)
+	default:
+		return append(strconv.AppendInt(dst, int64(d/time.Millisecond), 10), " ms"...)
+	}
+}
+
+// appendUnknown appends the placeholder printed in place of a duration
+// which is not known yet.
+func (u Unit) appendUnknown(dst []byte) []byte {
+	switch u {
+	case UnitMicrosecond:
+		return append(dst, "- µs"...)
+	case UnitNanosecond:
+		return append(dst, "- ns"...)
+	case UnitAuto:
+		return append(dst, '-')
+	default:
+		return append(dst, "- ms"...)
+	}
+}
+
+// width is the width durations in unit u are right-aligned to in the
+// multi-line output.
+func (u Unit) width() int {
+	switch u {
+	case UnitMicrosecond:
+		return 10
+	case UnitNanosecond:
+		return 13
+	case UnitAuto:
+		return 8
+	default:
+		return 7
+	}
+}
+
+// appendHuman appends d with three significant digits in the largest unit
+// below d.
+func appendHuman(dst []byte, d time.Duration) []byte {
+	if d < 0 {
+		return appendHuman(append(dst, '-'), -d)
+	}
+	var unit time.Duration
+	var suffix string
+	switch {
+	case d < time.Microsecond:
+		return append(strconv.AppendInt(dst, int64(d), 10), "ns"...)
+	case d < time.Millisecond:
+		unit, suffix = time.Microsecond, "µs"
+	case d < time.Second:
+		unit, suffix = time.Millisecond, "ms"
+	case d < time.Minute:
+		unit, suffix = time.Second, "s"
+	default:
+		// Like time.Duration.String of d rounded to seconds.
+		s := int64(d.Round(time.Second) / time.Second)
+		if h := s / 3600; h > 0 {
+			dst = append(strconv.AppendInt(dst, h, 10), 'h')
+		}
+		dst = append(strconv.AppendInt(dst, s/60%60, 10), 'm')
+		return append(strconv.AppendInt(dst, s%60, 10), 's')
+	}
+	v := float64(d) / float64(unit)
+	// Values just below 1000 would round to "1e+03", so they are
+	// printed in the next unit.
+	if v >= 999.5 && unit < time.Second {
+		return appendHuman(dst, 1000*unit)
+	}
+	return append(strconv.AppendFloat(dst, v, 'g', 3, 64), suffix...)
+}
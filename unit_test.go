@@ -0,0 +1,63 @@
+package httpstat
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppendHuman(t *testing.T) {
+	cases := []struct {
+		d    time.Duration
+		want string
+	}{
+		{0, "0ns"},
+		{834, "834ns"},
+		{1500 * time.Nanosecond, "1.5µs"},
+		{834125 * time.Nanosecond, "834µs"},
+		{999700 * time.Nanosecond, "1ms"},
+		{12345 * time.Microsecond, "12.3ms"},
+		{1236 * time.Millisecond, "1.24s"},
+		{90 * time.Second, "1m30s"},
+		{3600*time.Second + 1500*time.Millisecond, "1h0m2s"},
+	}
+	for _, c := range cases {
+		if got := string(appendHuman(nil, c.d)); got != c.want {
+			t.Errorf("appendHuman(%d) = %q, want %q", c.d, got, c.want)
+		}
+	}
+}
+
+func TestResult_SetUnit(t *testing.T) {
+	result := Result{
+		DNSLookup:        834 * time.Microsecond,
+		TCPConnection:    120 * time.Microsecond,
+		ServerProcessing: 1236 * time.Millisecond,
+		contentTransfer:  15 * time.Microsecond,
+		NameLookup:       834 * time.Microsecond,
+		total:            1237 * time.Millisecond,
+	}
+
+	cases := []struct {
+		unit   Unit
+		format string
+		want   []string
+	}{
+		{UnitMillisecond, "%s", []string{"DNSLookup: 0 ms", "ServerProcessing: 1236 ms"}},
+		{UnitMicrosecond, "%s", []string{"DNSLookup: 834 µs", "Total: 1237000 µs"}},
+		{UnitNanosecond, "%s", []string{"TCPConnection: 120000 ns"}},
+		{UnitAuto, "%s", []string{"DNSLookup: 834µs", "ContentTransfer: 15µs", "Total: 1.24s"}},
+		{UnitMicrosecond, "%+v", []string{"DNS lookup:            834 µs\n", "Total:          1237000 µs\n"}},
+		{UnitAuto, "%+v", []string{"DNS lookup:           834µs\n", "Server processing:    1.24s\n"}},
+	}
+	for _, c := range cases {
+		result.SetUnit(c.unit)
+		got := fmt.Sprintf(c.format, result)
+		for _, want := range c.want {
+			if !strings.Contains(got, want) {
+				t.Errorf("unit %d, %s: expect %q in:\n%s", c.unit, c.format, want, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package httpstat
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// CompareOptions configures CompareURLs.
+type CompareOptions struct {
+	// Client is used to send the requests. If nil, http.DefaultClient is
+	// used.
+	Client *http.Client
+
+	// Repeat is the number of requests per URL. If zero, 1 is used.
+	Repeat int
+
+	// Concurrency is the number of requests in flight at once. If zero,
+	// the requests are sent one after another.
+	Concurrency int
+}
+
+// URLStats holds the aggregated Results of the requests to one URL.
+type URLStats struct {
+	URL string
+
+	// Errors is the number of failed requests and requests answered with a
+	// status code of 400 or above. They are not aggregated.
+	Errors int
+
+	*Aggregator
+}
+
+// URLComparison is the outcome of CompareURLs.
+type URLComparison struct {
+	// URLs are ordered by their median total duration, fastest first.
+	// URLs without successful requests come last.
+	URLs []URLStats
+}
+
+// CompareURLs sends GET requests to each of urls and aggregates the Results
+// per URL, e.g. to pick the fastest of several CDN endpoints or regions.
+// The requests of repeated rounds are interleaved, so all URLs are measured
+// under the same conditions. opts may be nil.
+func CompareURLs(ctx context.Context, urls []string, opts *CompareOptions) *URLComparison {
+	if opts == nil {
+		opts = &CompareOptions{}
+	}
+	repeat, concurrency := opts.Repeat, opts.Concurrency
+	if repeat <= 0 {
+		repeat = 1
+	}
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	stats := make([]URLStats, len(urls))
+	for i, u := range urls {
+		stats[i] = URLStats{URL: u, Aggregator: &Aggregator{}}
+	}
+
+	jobs := make(chan int)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				rec := probe(ctx, opts.Client, urls[i])
+				if rec.Err != nil || rec.StatusCode >= 400 {
+					mu.Lock()
+					stats[i].Errors++
+					mu.Unlock()
+					continue
+				}
+				stats[i].Add(rec.Result)
+			}
+		}()
+	}
+
+rounds:
+	for n := 0; n < repeat; n++ {
+		for i := range urls {
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				break rounds
+			}
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	sort.SliceStable(stats, func(i, j int) bool {
+		ti, tj := stats[i].Total(), stats[j].Total()
+		if ti.Count == 0 || tj.Count == 0 {
+			return ti.Count > tj.Count
+		}
+		return ti.P50 < tj.P50
+	})
+	return &URLComparison{URLs: stats}
+}
+
+// WriteTo writes a table of the median phase durations of each URL to w.
+func (c *URLComparison) WriteTo(w io.Writer) (int64, error) {
+	cw := &countWriter{w: w}
+	tw := tabwriter.NewWriter(cw, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "URL\tDNS\tConnect\tTLS\tServer\tTransfer\tTotal\tOK\tErrors\t")
+	for _, s := range c.URLs {
+		fmt.Fprintf(tw, "%s\t", s.URL)
+		for _, p := range []Phase{PhaseDNS, PhaseConnect, PhaseTLS, PhaseServer, PhaseTransfer} {
+			fmt.Fprintf(tw, "%d ms\t", s.Phase(p).P50/time.Millisecond)
+		}
+		total := s.Total()
+		fmt.Fprintf(tw, "%d ms\t%d\t%d\t\n", total.P50/time.Millisecond, total.Count, s.Errors)
+	}
+	err := tw.Flush()
+	return cw.n, err
+}
@@ -0,0 +1,58 @@
+package httpstat
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompareURLs(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/fast", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+	})
+	mux.HandleFunc("/down", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	c := CompareURLs(context.Background(),
+		[]string{ts.URL + "/slow", ts.URL + "/down", ts.URL + "/fast"},
+		&CompareOptions{Repeat: 3, Concurrency: 2})
+
+	var order []string
+	for _, s := range c.URLs {
+		order = append(order, strings.TrimPrefix(s.URL, ts.URL))
+	}
+	if got := strings.Join(order, " "); got != "/fast /slow /down" {
+		t.Fatalf("expect URLs ordered by median total, got %s", got)
+	}
+
+	if n := c.URLs[0].Count(); n != 3 {
+		t.Fatalf("expect 3 requests to /fast, got %d", n)
+	}
+	if c.URLs[2].Errors != 3 || c.URLs[2].Count() != 0 {
+		t.Fatalf("expect requests to /down to be errors, got %d errors and %d results",
+			c.URLs[2].Errors, c.URLs[2].Count())
+	}
+
+	var buf bytes.Buffer
+	if _, err := c.WriteTo(&buf); err != nil {
+		t.Fatal("WriteTo failed:", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[0], "URL") || !strings.HasPrefix(lines[1], ts.URL+"/fast") {
+		t.Fatalf("unexpected table:\n%s", buf.String())
+	}
+	// The slow handler sleeps for 20 ms, which may round to 21 ms.
+	if !regexp.MustCompile(`\b2\d ms`).MatchString(lines[2]) {
+		t.Fatalf("expect server median of /slow in table, got %q", lines[2])
+	}
+}
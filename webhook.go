@@ -0,0 +1,233 @@
+package httpstat
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrBatchDropped is passed to WebhookSink.OnError when a batch is dropped
+// because too many batches are waiting to be sent.
+var ErrBatchDropped = errors.New("httpstat: webhook batch dropped")
+
+// WebhookSink is a Sink which collects Records into batches and POSTs
+// each batch as a JSON array to a URL, retrying failed requests with
+// exponential backoff. Batches are sent in the background, so WriteRecord
+// never blocks on the network.
+type WebhookSink struct {
+	// URL is the URL the batches are POSTed to.
+	URL string
+
+	// Client is used to send the batches. If nil, http.DefaultClient is
+	// used. It must not write its own requests to the WebhookSink.
+	Client *http.Client
+
+	// BatchSize is the number of Records which are sent at once. If zero,
+	// 100 is used.
+	BatchSize int
+
+	// FlushInterval is the interval at which incomplete batches are sent.
+	// If zero, 10 seconds is used.
+	FlushInterval time.Duration
+
+	// MaxRetries is the number of times a failed batch is sent again. If
+	// zero, 3 is used; if negative, batches are not retried.
+	MaxRetries int
+
+	// Backoff is the delay before the first retry, which is doubled for
+	// every following retry. If zero, 1 second is used.
+	Backoff time.Duration
+
+	// MaxPending is the number of full batches which may wait to be sent.
+	// Further batches are dropped. If zero, 10 is used.
+	MaxPending int
+
+	// OnError, if not nil, is called with the error of each batch which
+	// could not be sent. The sink is not locked meanwhile, so OnError may
+	// call its methods.
+	OnError func(err error, batch []Record)
+
+	once    sync.Once
+	mu      sync.Mutex
+	batch   []Record
+	closed  bool
+	queue   chan []Record
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+func (s *WebhookSink) init() {
+	pending := s.MaxPending
+	if pending <= 0 {
+		pending = 10
+	}
+	s.queue = make(chan []Record, pending)
+	s.stop = make(chan struct{})
+	s.stopped = make(chan struct{})
+	go s.run()
+}
+
+// WriteRecord implements Sink.
+func (s *WebhookSink) WriteRecord(rec Record) error {
+	s.once.Do(s.init)
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return errors.New("httpstat: write to closed WebhookSink")
+	}
+	s.batch = append(s.batch, rec)
+	var dropped []Record
+	if len(s.batch) >= s.batchSize() {
+		dropped = s.enqueue()
+	}
+	s.mu.Unlock()
+
+	// OnError may write to the sink, so it is called without s.mu held.
+	if dropped != nil {
+		s.report(ErrBatchDropped, dropped)
+	}
+	return nil
+}
+
+// Close sends the Records which were not sent yet and stops the sink. It
+// waits for the pending batches to be sent, including their retries.
+func (s *WebhookSink) Close() error {
+	s.once.Do(s.init)
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	batch := s.batch
+	s.batch = nil
+	s.mu.Unlock()
+
+	close(s.stop)
+	<-s.stopped
+	if len(batch) > 0 {
+		s.send(batch)
+	}
+	return nil
+}
+
+// enqueue hands the current batch to the sender, and returns it if it was
+// dropped instead, to be reported once s.mu is released. It must be called
+// with s.mu held.
+func (s *WebhookSink) enqueue() (dropped []Record) {
+	batch := s.batch
+	s.batch = nil
+	select {
+	case s.queue <- batch:
+		return nil
+	default:
+		return batch
+	}
+}
+
+func (s *WebhookSink) run() {
+	defer close(s.stopped)
+
+	interval := s.FlushInterval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case batch := <-s.queue:
+			s.send(batch)
+		case <-ticker.C:
+			var dropped []Record
+			s.mu.Lock()
+			if len(s.batch) > 0 {
+				dropped = s.enqueue()
+			}
+			s.mu.Unlock()
+			if dropped != nil {
+				s.report(ErrBatchDropped, dropped)
+			}
+		case <-s.stop:
+			for {
+				select {
+				case batch := <-s.queue:
+					s.send(batch)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// send POSTs batch, retrying on errors and on 429 and 5xx responses.
+func (s *WebhookSink) send(batch []Record) {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		s.report(err, batch)
+		return
+	}
+
+	retries := s.MaxRetries
+	if retries == 0 {
+		retries = 3
+	}
+	backoff := s.Backoff
+	if backoff <= 0 {
+		backoff = time.Second
+	}
+
+	for attempt := 0; ; attempt++ {
+		retry, err := s.post(body)
+		if err == nil {
+			return
+		}
+		if !retry || attempt >= retries {
+			s.report(err, batch)
+			return
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// post POSTs body once and reports whether a failure is worth retrying.
+func (s *WebhookSink) post(body []byte) (retry bool, err error) {
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Post(s.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return true, err
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+	if res.StatusCode >= 300 {
+		retry = res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500
+		return retry, fmt.Errorf("httpstat: webhook responded with %s", res.Status)
+	}
+	return false, nil
+}
+
+func (s *WebhookSink) batchSize() int {
+	if s.BatchSize <= 0 {
+		return 100
+	}
+	return s.BatchSize
+}
+
+func (s *WebhookSink) report(err error, batch []Record) {
+	if s.OnError != nil {
+		s.OnError(err, batch)
+	}
+}
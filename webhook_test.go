@@ -0,0 +1,155 @@
+package httpstat
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWebhookSink(t *testing.T) {
+	var mu sync.Mutex
+	var batches [][]map[string]interface{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var batch []map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			t.Error("Decode failed:", err)
+		}
+		mu.Lock()
+		batches = append(batches, batch)
+		mu.Unlock()
+	}))
+	defer ts.Close()
+
+	s := &WebhookSink{URL: ts.URL, BatchSize: 2, FlushInterval: time.Hour}
+	for i := 0; i < 5; i++ {
+		s.WriteRecord(Record{Method: "GET", URL: "https://example.com", Result: &Result{}})
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal("Close failed:", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(batches) != 3 {
+		t.Fatalf("expect 3 batches, got %d", len(batches))
+	}
+	if len(batches[0]) != 2 || len(batches[2]) != 1 {
+		t.Fatalf("unexpected batch sizes %d, %d", len(batches[0]), len(batches[2]))
+	}
+	if batches[0][0]["method"] != "GET" {
+		t.Fatalf("expect records to be sent as JSON, got %v", batches[0][0])
+	}
+	if err := s.WriteRecord(Record{}); err == nil {
+		t.Fatal("expect write to closed sink to fail")
+	}
+}
+
+func TestWebhookSink_FlushInterval(t *testing.T) {
+	sent := make(chan struct{}, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent <- struct{}{}
+	}))
+	defer ts.Close()
+
+	s := &WebhookSink{URL: ts.URL, FlushInterval: 10 * time.Millisecond}
+	defer s.Close()
+	s.WriteRecord(Record{})
+
+	select {
+	case <-sent:
+	case <-time.After(time.Second):
+		t.Fatal("expect incomplete batch to be sent after FlushInterval")
+	}
+}
+
+func TestWebhookSink_Retry(t *testing.T) {
+	var attempts int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer ts.Close()
+
+	var gotErr error
+	s := &WebhookSink{
+		URL:     ts.URL,
+		Backoff: time.Millisecond,
+		OnError: func(err error, _ []Record) { gotErr = err },
+	}
+	s.WriteRecord(Record{})
+	s.Close()
+
+	if attempts != 3 {
+		t.Fatalf("expect batch to be sent 3 times, got %d", attempts)
+	}
+	if gotErr != nil {
+		t.Fatal("expect batch to be sent, got", gotErr)
+	}
+}
+
+func TestWebhookSink_ClientError(t *testing.T) {
+	var attempts int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer ts.Close()
+
+	var failed []Record
+	s := &WebhookSink{
+		URL:     ts.URL,
+		Backoff: time.Millisecond,
+		OnError: func(_ error, batch []Record) { failed = batch },
+	}
+	s.WriteRecord(Record{})
+	s.Close()
+
+	if attempts != 1 {
+		t.Fatalf("expect client errors not to be retried, got %d attempts", attempts)
+	}
+	if len(failed) != 1 {
+		t.Fatalf("expect OnError to be called with the batch, got %v", failed)
+	}
+}
+
+func TestWebhookSink_DroppedReentrant(t *testing.T) {
+	release := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer ts.Close()
+	defer close(release)
+
+	// OnError writes to the sink, which deadlocked while the sink held its
+	// lock to report the dropped batch.
+	var s *WebhookSink
+	var reported atomic.Bool
+	dropped := make(chan struct{})
+	s = &WebhookSink{
+		URL:        ts.URL,
+		BatchSize:  1,
+		MaxPending: 1,
+		OnError: func(err error, batch []Record) {
+			if err == ErrBatchDropped {
+				if !reported.Swap(true) {
+					s.WriteRecord(Record{Method: "DROPPED"})
+					close(dropped)
+				}
+			}
+		},
+	}
+	for i := 0; i < 5; i++ {
+		s.WriteRecord(Record{})
+	}
+	select {
+	case <-dropped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expect a batch to be dropped without deadlocking")
+	}
+}
@@ -0,0 +1,68 @@
+package httpstat
+
+import (
+	"context"
+	"net"
+	"net/http/httptrace"
+)
+
+// WebSocketNetDial wraps dial, or a net.Dialer if nil, to be set as the
+// NetDialContext of a gorilla/websocket Dialer, so the phases of the
+// opening handshake are recorded into the Result of the context passed to
+// DialContext:
+//
+//	var result httpstat.Result
+//	dialer := websocket.Dialer{NetDialContext: httpstat.WebSocketNetDial(nil)}
+//	conn, _, err := dialer.DialContext(httpstat.WithHTTPStat(ctx, &result), url, nil)
+//	result.EndNow()
+//
+// gorilla/websocket reports the DNS lookup, connect, TLS handshake and first
+// response byte to httptrace, but writes the upgrade request directly to
+// the connection; the wrapped connection reports it once written.
+//
+// Dialers sending the upgrade request through net/http, such as the one of
+// nhooyr.io/websocket, need no wrapper: passing the context of WithHTTPStat
+// to Dial, or a Client of a Transport in the options, is enough.
+func WebSocketNetDial(dial func(ctx context.Context, network, addr string) (net.Conn, error)) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	if dial == nil {
+		var d net.Dialer
+		dial = d.DialContext
+	}
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		r, ok := FromContext(ctx)
+		if !ok {
+			return conn, nil
+		}
+		return &handshakeConn{Conn: conn, r: r}, nil
+	}
+}
+
+// handshakeConn reports the first write after the TLS handshake, if any, as
+// the written request. Writes to a WebSocket connection are not concurrent.
+type handshakeConn struct {
+	net.Conn
+	r       *Result
+	written bool
+}
+
+func (c *handshakeConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	if c.written {
+		return n, err
+	}
+	// The records of the TLS handshake are written through the connection
+	// too. They are reported by the dial goroutine, so r is locked.
+	c.r.lock()
+	inHandshake := !c.r.tlsStart.IsZero() && c.r.TLSHandshake == 0
+	c.r.unlock()
+	if inHandshake {
+		return n, err
+	}
+	c.written = true
+	c.r.onWroteRequest(httptrace.WroteRequestInfo{Err: err})
+	return n, err
+}
@@ -0,0 +1,104 @@
+package httpstat
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"testing"
+	"time"
+)
+
+// newUpgradeServer returns a server which switches every request to an
+// echo protocol after a delay, like a WebSocket server.
+func newUpgradeServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error("Hijack failed:", err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: echo\r\n\r\n")
+		rw.Flush()
+		io.Copy(conn, rw)
+	}))
+}
+
+func TestWebSocketNetDial(t *testing.T) {
+	ts := newUpgradeServer(t)
+	defer ts.Close()
+
+	// Do the handshake like gorilla/websocket.
+	var result Result
+	ctx := WithHTTPStat(context.Background(), &result)
+	conn, err := WebSocketNetDial(nil)(ctx, "tcp", ts.Listener.Addr().String())
+	if err != nil {
+		t.Fatal("dial failed:", err)
+	}
+	defer conn.Close()
+	req, _ := http.NewRequest("GET", ts.URL, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "echo")
+	if err := req.Write(conn); err != nil {
+		t.Fatal("Write failed:", err)
+	}
+	br := bufio.NewReader(conn)
+	if _, err := br.Peek(1); err != nil {
+		t.Fatal("Peek failed:", err)
+	}
+	httptrace.ContextClientTrace(ctx).GotFirstResponseByte()
+	res, err := http.ReadResponse(br, req)
+	if err != nil {
+		t.Fatal("ReadResponse failed:", err)
+	}
+	result.EndNow()
+
+	if res.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("unexpected status %s", res.Status)
+	}
+	if result.TCPConnection <= 0 {
+		t.Fatal("expect the connect to be measured")
+	}
+	if result.ServerProcessing < 20*time.Millisecond {
+		t.Fatalf("expect the upgrade response to take at least 20ms, got %v", result.ServerProcessing)
+	}
+	if !result.IsComplete() {
+		t.Fatal("expect the Result to be complete")
+	}
+}
+
+func TestTransport_SwitchingProtocols(t *testing.T) {
+	ts := newUpgradeServer(t)
+	defer ts.Close()
+
+	var got *Result
+	client := &http.Client{Transport: &Transport{
+		Base:     DefaultTransport(),
+		OnResult: func(req *http.Request, r *Result, err error) { got = r },
+	}}
+	req, _ := http.NewRequest("GET", ts.URL, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "echo")
+	res, err := client.Do(req)
+	if err != nil {
+		t.Fatal("request failed:", err)
+	}
+	rwc, ok := res.Body.(io.ReadWriteCloser)
+	if !ok {
+		t.Fatal("expect the body to stay an io.ReadWriteCloser")
+	}
+	defer rwc.Close()
+	if got == nil || got.ServerProcessing < 20*time.Millisecond || got.Total() <= 0 {
+		t.Fatalf("expect the Result to be delivered with the response, got %v", got)
+	}
+
+	io.WriteString(rwc, "ping")
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(rwc, buf); err != nil || string(buf) != "ping" {
+		t.Fatalf("expect the echo, got %q (%v)", buf, err)
+	}
+}